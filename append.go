@@ -0,0 +1,60 @@
+package msgpack
+
+// AppendString appends a msgpack encoded string to dst, returning the
+// extended slice.
+//
+// Unlike String, no Encoder is involved and no defensive copy is made;
+// the encoded string is appended directly to the caller's buffer.
+//
+// AppendString panics with ErrTooLarge if the length of the string
+// exceeds the msgpack limit of 2^32-1 bytes.
+func AppendString(dst []byte, s string) []byte {
+	dst = appendStringHeader(dst, int64(len(s)))
+	return append(dst, s...)
+}
+
+// appendStringHeader appends the msgpack type and length of a string
+// to dst, returning the extended slice.  The function panics with
+// ErrTooLarge if the length exceeds the maximum length of a string.
+func appendStringHeader(dst []byte, n int64) []byte {
+	if err := checkLength("AppendString", n); err != nil {
+		panic(err)
+	}
+
+	switch {
+	case n < 32:
+		return append(dst, maskFixString|byte(n))
+	case n < 256:
+		return append(dst, typeString8, byte(n))
+	case n < 65536:
+		return append(dst, typeString16, byte(n>>8), byte(n))
+	default:
+		return append(dst, typeString32, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+}
+
+// appendArrayHeader appends the msgpack type and length of an array
+// to dst, returning the extended slice.
+func appendArrayHeader(dst []byte, n int64) []byte {
+	switch {
+	case n < 16:
+		return append(dst, maskFixArray|byte(n))
+	case n < 65536:
+		return append(dst, typeArray16, byte(n>>8), byte(n))
+	default:
+		return append(dst, typeArray32, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+}
+
+// appendMapHeader appends the msgpack type and length of a map to dst,
+// returning the extended slice.
+func appendMapHeader(dst []byte, n int64) []byte {
+	switch {
+	case n < 16:
+		return append(dst, maskFixMap|byte(n))
+	case n < 65536:
+		return append(dst, typeMap16, byte(n>>8), byte(n))
+	default:
+		return append(dst, typeMap32, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+}
@@ -0,0 +1,47 @@
+package msgpack
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAppendString(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		len int
+	}{
+		{len: 0},
+		{len: 1},
+		{len: 31},
+		{len: 32},
+		{len: 255},
+		{len: 256},
+		{len: 65535},
+		{len: 65536},
+	}
+	for _, tc := range testcases {
+		t.Run(fmt.Sprintf("string of length %d", tc.len), func(t *testing.T) {
+			// ARRANGE
+			str := strings.Repeat("a", tc.len)
+			dst := []byte{0x01, 0x02}
+
+			// ACT
+			got := AppendString(dst, str)
+
+			// ASSERT
+			wanted := append([]byte{0x01, 0x02}, String(str)...)
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %x\ngot    %x", wanted, got)
+			}
+		})
+	}
+	t.Run("string too long", func(t *testing.T) {
+		// ASSERT
+		defer testPanic(t, ErrTooLarge)
+
+		// ACT
+		_ = appendStringHeader(nil, maxLength+1)
+	})
+}
@@ -0,0 +1,39 @@
+package msgpack
+
+import "fmt"
+
+// Array returns a []byte containing a msgpack encoded array of the
+// specified values, each encoded using the Encoder.Encode method.
+//
+// An error is returned if any value is of an unsupported type.
+func Array(vs ...any) (b []byte, err error) {
+	defer recoverError(&err)
+
+	return encodeBytes(func(enc Encoder) error { return EncodeArray(enc, vs, nil) })
+}
+
+// JoinArray returns a []byte containing a msgpack encoded array of the
+// specified values, each of which must be an already-encoded value.  The
+// values are copied without being re-encoded (or validated), e.g. to
+// batch pre-serialized events into a single message:
+//
+//	batch := msgpack.JoinArray(events...)
+//
+// JoinArray panics with ErrTooLarge if the number of values exceeds the
+// maximum length of an array (2^32-1).
+func JoinArray(values ...[]byte) []byte {
+	if int64(len(values)) > maxLength {
+		panic(fmt.Errorf("JoinArray: %w: %d values (max %d)", ErrTooLarge, len(values), maxLength))
+	}
+
+	size := arrayHeaderLen(len(values))
+	for _, v := range values {
+		size += len(v)
+	}
+
+	b := appendArrayHeader(make([]byte, 0, size), int64(len(values)))
+	for _, v := range values {
+		b = append(b, v...)
+	}
+	return b
+}
@@ -0,0 +1,68 @@
+package msgpack
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestArray(t *testing.T) {
+	// ARRANGE
+	type expect struct {
+		result []byte
+		error
+	}
+	testcases := []struct {
+		spec   string // for information only, not part of the test
+		values []any
+		expect
+	}{
+		{spec: "Array()", values: nil, expect: expect{result: []byte{atomEmptyArray}}},
+		{spec: "Array(1, \"a\", nil)", values: []any{1, "a", nil}, expect: expect{result: []byte{maskFixArray | 3, 0x01, maskFixString | 1, 'a', atomNil}}},
+		{spec: "Array(1, struct{}{})", values: []any{1, struct{}{}}, expect: expect{error: ErrUnsupportedType}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			// ACT
+			got, err := Array(tc.values...)
+
+			// ASSERT
+			testError(t, tc.expect.error, err)
+
+			wanted := tc.result
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+			}
+		})
+	}
+}
+
+func TestJoinArray(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		spec   string
+		values [][]byte
+		result []byte
+	}{
+		{spec: "no values", values: nil, result: []byte{atomEmptyArray}},
+		{spec: "values",
+			values: [][]byte{Int(1), String("a"), {atomNil}},
+			result: []byte{maskFixArray | 3, 0x01, maskFixString | 1, 'a', atomNil},
+		},
+		{spec: "16 values",
+			values: [][]byte{{1}, {2}, {3}, {4}, {5}, {6}, {7}, {8}, {9}, {10}, {11}, {12}, {13}, {14}, {15}, {16}},
+			result: []byte{typeArray16, 0x00, 0x10, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			// ACT
+			got := JoinArray(tc.values...)
+
+			// ASSERT
+			wanted := tc.result
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %x\ngot    %x", wanted, got)
+			}
+		})
+	}
+}
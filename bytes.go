@@ -0,0 +1,8 @@
+package msgpack
+
+// Bytes returns a []byte containing the specified []byte encoded as
+// msgpack binary data.  A nil slice is encoded as nil.
+func Bytes(b []byte) []byte {
+	r, _ := encodeBytes(func(enc Encoder) error { return enc.EncodeBytes(b) })
+	return r
+}
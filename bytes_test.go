@@ -0,0 +1,49 @@
+package msgpack
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestBytes(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		len  int
+		lead []byte
+	}{
+		{len: -1, lead: []byte{atomNil}},
+		{len: 0, lead: []byte{typeBin8, 0x00}},
+		{len: 255, lead: []byte{typeBin8, 0xff}},
+		{len: 256, lead: []byte{typeBin16, 0x01, 0x00}},
+		{len: 65536, lead: []byte{typeBin32, 0x00, 0x01, 0x00, 0x00}},
+	}
+	for _, tc := range testcases {
+		t.Run(fmt.Sprintf("[]byte of length %d", tc.len), func(t *testing.T) {
+			// ARRANGE
+			var b []byte
+			if tc.len >= 0 {
+				b = bytes.Repeat([]byte{0x01}, tc.len)
+			}
+
+			// ACT
+			got := Bytes(b)
+
+			// ASSERT
+			t.Run("lead bytes", func(t *testing.T) {
+				wanted := tc.lead
+				if !bytes.Equal(wanted, got[:len(tc.lead)]) {
+					t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+				}
+			})
+
+			t.Run("data bytes", func(t *testing.T) {
+				wanted := b
+				got := got[len(tc.lead):]
+				if !bytes.Equal(wanted, got) {
+					t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+				}
+			})
+		})
+	}
+}
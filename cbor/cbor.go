@@ -0,0 +1,60 @@
+// Package cbor converts between msgpack and CBOR (RFC 8949) at the
+// token level, for gateways bridging CBOR and msgpack ecosystems.
+//
+// Values are converted to the equivalent type in the target format,
+// preserving the order of map entries.  Timestamps are converted
+// between the msgpack timestamp extension and CBOR tag 1 (epoch-based
+// date/time) or, for times with a fractional second, tag 1001 (extended
+// time, RFC 9581).  Other extension types and tags have no equivalent
+// and cannot be converted.
+package cbor
+
+// CBOR major types.
+const (
+	majorUint   byte = 0
+	majorNegInt byte = 1
+	majorBytes  byte = 2
+	majorText   byte = 3
+	majorArray  byte = 4
+	majorMap    byte = 5
+	majorTag    byte = 6
+	majorSimple byte = 7
+)
+
+// CBOR simple values, floats and the break code (major type 7).
+const (
+	cborFalse   byte = 0xf4
+	cborTrue    byte = 0xf5
+	cborNull    byte = 0xf6
+	cborUndef   byte = 0xf7
+	cborFloat16 byte = 0xf9
+	cborFloat32 byte = 0xfa
+	cborFloat64 byte = 0xfb
+	cborBreak   byte = 0xff
+)
+
+// additional information values indicating the size of the argument
+// of a data item, or an indefinite length.
+const (
+	info8bit       byte = 24
+	info16bit      byte = 25
+	info32bit      byte = 26
+	info64bit      byte = 27
+	infoIndefinite byte = 31
+)
+
+// CBOR tags supported by the converter.
+const (
+	tagDateTime     uint64 = 0     // RFC 3339 date/time string
+	tagEpoch        uint64 = 1     // epoch-based date/time
+	tagExtendedTime uint64 = 1001  // extended time (RFC 9581)
+	tagSelfDescribe uint64 = 55799 // self-described CBOR
+)
+
+// keys of the extended time map (tag 1001).
+const (
+	keySeconds     = 1
+	keyMillis      = -3
+	keyMicros      = -6
+	keyNanoseconds = -9
+)
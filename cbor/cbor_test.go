@@ -0,0 +1,42 @@
+package cbor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+
+	"github.com/blugnu/msgpack/msgpacktest"
+)
+
+// unhex returns the bytes of a hex string, panicking if it is invalid.
+func unhex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		// ARRANGE
+		data, _ := msgpacktest.Generate(r, 3)
+
+		// ACT
+		cbor := &bytes.Buffer{}
+		if err := FromMsgpack(cbor, data); err != nil {
+			t.Fatalf("FromMsgpack: unexpected error: %v", err)
+		}
+		result := &bytes.Buffer{}
+		if err := ToMsgpack(result, cbor.Bytes()); err != nil {
+			t.Fatalf("ToMsgpack: unexpected error: %v", err)
+		}
+
+		// ASSERT
+		if wanted, got := data, result.Bytes(); !bytes.Equal(wanted, got) {
+			t.Fatalf("\nwanted %x\ngot    %x", wanted, got)
+		}
+	}
+}
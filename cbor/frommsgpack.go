@@ -0,0 +1,199 @@
+package cbor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/blugnu/msgpack"
+)
+
+// FromMsgpack converts msgpack data (a sequence of zero or more msgpack
+// values) to CBOR, writing the result to w.
+//
+// An error is returned (and nothing is written) if the data is not
+// valid msgpack (wrapping msgpack.ErrTruncated or
+// msgpack.ErrInvalidFormat), contains an extension type other than
+// a timestamp (wrapping msgpack.ErrUnsupportedType) or has arrays and
+// maps nested more deeply than msgpack.MaxDepth (wrapping
+// msgpack.ErrDepthExceeded).
+func FromMsgpack(w io.Writer, data []byte) error {
+	c := &fromMsgpack{data: data}
+	for c.pos < len(data) {
+		if err := c.value(); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(c.out)
+	return err
+}
+
+// fromMsgpack holds the state of a conversion from msgpack to CBOR.
+type fromMsgpack struct {
+	data  []byte
+	pos   int
+	out   []byte
+	depth int // the number of arrays and maps enclosing the current position
+}
+
+// value converts the msgpack value at the current position.
+func (c *fromMsgpack) value() error {
+	start := c.pos
+	f, n, hl, err := msgpack.ReadHeader(c.data[c.pos:])
+	if err != nil {
+		return fmt.Errorf("offset %d: %w", start, err)
+	}
+
+	// the data following the header (for containers, the elements or
+	// entries are converted as separate values)
+	var p []byte
+	if !msgpack.IsArray(c.data[c.pos]) && !msgpack.IsMap(c.data[c.pos]) {
+		end := int64(c.pos+hl) + n
+		if end > int64(len(c.data)) {
+			return fmt.Errorf("offset %d: %w: %s requires %d bytes, got %d", start, msgpack.ErrTruncated, f, end-int64(start), len(c.data)-start)
+		}
+		p = c.data[c.pos+hl : end]
+	}
+	b := c.data[c.pos]
+	c.pos += hl + len(p)
+
+	switch f {
+	case msgpack.FormatNil:
+		c.out = append(c.out, cborNull)
+	case msgpack.FormatBool:
+		if b == msgpack.AtomTrue {
+			c.out = append(c.out, cborTrue)
+		} else {
+			c.out = append(c.out, cborFalse)
+		}
+	case msgpack.FormatFixInt, msgpack.FormatNegFixInt:
+		c.out = appendInt(c.out, int64(int8(b)))
+	case msgpack.FormatInt8, msgpack.FormatInt16, msgpack.FormatInt32, msgpack.FormatInt64:
+		c.out = appendInt(c.out, readInt(p))
+	case msgpack.FormatUint8, msgpack.FormatUint16, msgpack.FormatUint32, msgpack.FormatUint64:
+		c.out = appendHead(c.out, majorUint, readUint(p))
+	case msgpack.FormatFloat32:
+		c.out = append(append(c.out, cborFloat32), p...)
+	case msgpack.FormatFloat64:
+		c.out = append(append(c.out, cborFloat64), p...)
+	case msgpack.FormatFixStr, msgpack.FormatStr8, msgpack.FormatStr16, msgpack.FormatStr32:
+		c.out = append(appendHead(c.out, majorText, uint64(n)), p...)
+	case msgpack.FormatBin8, msgpack.FormatBin16, msgpack.FormatBin32:
+		c.out = append(appendHead(c.out, majorBytes, uint64(n)), p...)
+	case msgpack.FormatFixArray, msgpack.FormatArray16, msgpack.FormatArray32:
+		c.out = appendHead(c.out, majorArray, uint64(n))
+		return c.values(n)
+	case msgpack.FormatFixMap, msgpack.FormatMap16, msgpack.FormatMap32:
+		c.out = appendHead(c.out, majorMap, uint64(n))
+		return c.values(n * 2)
+	case msgpack.FormatTimestamp:
+		c.out = appendTime(c.out, readTimestamp(p))
+	default:
+		return fmt.Errorf("offset %d: %w: %s extension type %d", start, msgpack.ErrUnsupportedType, f, int8(c.data[start+hl-1]))
+	}
+	return nil
+}
+
+// values converts n values at the current position, being the elements
+// (or entries) of an array (or map).
+func (c *fromMsgpack) values(n int64) error {
+	if c.depth == msgpack.MaxDepth {
+		return fmt.Errorf("offset %d: %w: maximum depth is %d", c.pos, msgpack.ErrDepthExceeded, msgpack.MaxDepth)
+	}
+	c.depth++
+	defer func() { c.depth-- }()
+
+	for i := int64(0); i < n; i++ {
+		if c.pos >= len(c.data) {
+			return fmt.Errorf("offset %d: %w: expected %d more values", c.pos, msgpack.ErrTruncated, n-i)
+		}
+		if err := c.value(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendHead appends the head of a CBOR data item with the specified
+// major type and argument.
+func appendHead(b []byte, major byte, arg uint64) []byte {
+	major <<= 5
+	switch {
+	case arg < uint64(info8bit):
+		return append(b, major|byte(arg))
+	case arg <= 0xff:
+		return append(b, major|info8bit, byte(arg))
+	case arg <= 0xffff:
+		return append(b, major|info16bit, byte(arg>>8), byte(arg))
+	case arg <= 0xffffffff:
+		return append(b, major|info32bit, byte(arg>>24), byte(arg>>16), byte(arg>>8), byte(arg))
+	default:
+		return append(b, major|info64bit, byte(arg>>56), byte(arg>>48), byte(arg>>40), byte(arg>>32), byte(arg>>24), byte(arg>>16), byte(arg>>8), byte(arg))
+	}
+}
+
+// appendInt appends a CBOR integer.
+func appendInt(b []byte, i int64) []byte {
+	if i < 0 {
+		return appendHead(b, majorNegInt, uint64(-1-i))
+	}
+	return appendHead(b, majorUint, uint64(i))
+}
+
+// appendTime appends a CBOR date/time: an epoch-based date/time (tag 1)
+// for whole seconds, otherwise an extended time (tag 1001) with seconds
+// and nanoseconds.
+func appendTime(b []byte, t time.Time) []byte {
+	if t.Nanosecond() == 0 {
+		b = appendHead(b, majorTag, tagEpoch)
+		return appendInt(b, t.Unix())
+	}
+	b = appendHead(b, majorTag, tagExtendedTime)
+	b = appendHead(b, majorMap, 2)
+	b = appendInt(b, keySeconds)
+	b = appendInt(b, t.Unix())
+	b = appendInt(b, keyNanoseconds)
+	return appendInt(b, int64(t.Nanosecond()))
+}
+
+// readUint reads a big-endian unsigned integer of 1, 2, 4 or 8 bytes.
+func readUint(p []byte) uint64 {
+	switch len(p) {
+	case 1:
+		return uint64(p[0])
+	case 2:
+		return uint64(binary.BigEndian.Uint16(p))
+	case 4:
+		return uint64(binary.BigEndian.Uint32(p))
+	default:
+		return binary.BigEndian.Uint64(p)
+	}
+}
+
+// readInt reads a big-endian signed integer of 1, 2, 4 or 8 bytes.
+func readInt(p []byte) int64 {
+	switch len(p) {
+	case 1:
+		return int64(int8(p[0]))
+	case 2:
+		return int64(int16(binary.BigEndian.Uint16(p)))
+	case 4:
+		return int64(int32(binary.BigEndian.Uint32(p)))
+	default:
+		return int64(binary.BigEndian.Uint64(p))
+	}
+}
+
+// readTimestamp reads the data of a msgpack timestamp (4, 8 or 12 bytes).
+func readTimestamp(p []byte) time.Time {
+	switch len(p) {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(p)), 0)
+	case 8:
+		v := binary.BigEndian.Uint64(p)
+		return time.Unix(int64(v&(1<<34-1)), int64(v>>34))
+	default:
+		return time.Unix(int64(binary.BigEndian.Uint64(p[4:])), int64(binary.BigEndian.Uint32(p)))
+	}
+}
@@ -0,0 +1,92 @@
+package cbor
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/blugnu/msgpack"
+)
+
+func TestFromMsgpack(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		spec    string
+		msgpack string
+		result  string
+		error
+	}{
+		{spec: "empty", msgpack: "", result: ""},
+		{spec: "nil", msgpack: "c0", result: "f6"},
+		{spec: "false", msgpack: "c2", result: "f4"},
+		{spec: "true", msgpack: "c3", result: "f5"},
+		{spec: "0", msgpack: "00", result: "00"},
+		{spec: "23", msgpack: "17", result: "17"},
+		{spec: "24", msgpack: "18", result: "1818"},
+		{spec: "1000", msgpack: "cd03e8", result: "1903e8"},
+		{spec: "1000000", msgpack: "ce000f4240", result: "1a000f4240"},
+		{spec: "MaxUint64", msgpack: "cfffffffffffffffff", result: "1bffffffffffffffff"},
+		{spec: "-1", msgpack: "ff", result: "20"},
+		{spec: "-100", msgpack: "d09c", result: "3863"},
+		{spec: "-1000", msgpack: "d1fc18", result: "3903e7"},
+		{spec: "MinInt64", msgpack: "d38000000000000000", result: "3b7fffffffffffffff"},
+		{spec: "float32", msgpack: "ca3fc00000", result: "fa3fc00000"},
+		{spec: "float64", msgpack: "cb3ff8000000000000", result: "fb3ff8000000000000"},
+		{spec: "\"\"", msgpack: "a0", result: "60"},
+		{spec: "\"IETF\"", msgpack: "a449455446", result: "6449455446"},
+		{spec: "bin", msgpack: "c40401020304", result: "4401020304"},
+		{spec: "[]", msgpack: "90", result: "80"},
+		{spec: "[1,2,3]", msgpack: "93010203", result: "83010203"},
+		{spec: "{\"a\":1,\"b\":[2,3]}", msgpack: "82a16101a162920203", result: "a26161016162820203"},
+		{spec: "timestamp 32", msgpack: "d6ff514b67b0", result: "c11a514b67b0"},
+		{spec: "timestamp 64", msgpack: "d7ff00000004514b67b0", result: "d903e9a2011a514b67b02801"},
+		{spec: "multiple values", msgpack: "c001", result: "f601"},
+		{spec: "ext", msgpack: "d40101", result: "", error: msgpack.ErrUnsupportedType},
+		{spec: "invalid", msgpack: "c1", result: "", error: msgpack.ErrInvalidFormat},
+		{spec: "truncated string", msgpack: "a461", result: "", error: msgpack.ErrTruncated},
+		{spec: "truncated array", msgpack: "9201", result: "", error: msgpack.ErrTruncated},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			// ACT
+			err := FromMsgpack(buf, unhex(tc.msgpack))
+
+			// ASSERT
+			if !errors.Is(err, tc.error) {
+				t.Errorf("\nwanted error %v\ngot          %v", tc.error, err)
+			}
+
+			wanted := unhex(tc.result)
+			got := buf.Bytes()
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %x\ngot    %x", wanted, got)
+			}
+		})
+	}
+	t.Run("nesting", func(t *testing.T) {
+		testcases := []struct {
+			spec    string
+			msgpack string
+			error
+		}{
+			{spec: "arrays at max depth", msgpack: strings.Repeat("91", msgpack.MaxDepth) + "00"},
+			{spec: "arrays exceeding max depth", msgpack: strings.Repeat("91", msgpack.MaxDepth+1) + "00", error: msgpack.ErrDepthExceeded},
+			{spec: "maps exceeding max depth", msgpack: strings.Repeat("8100", msgpack.MaxDepth+1) + "00", error: msgpack.ErrDepthExceeded},
+		}
+		for _, tc := range testcases {
+			t.Run(tc.spec, func(t *testing.T) {
+				// ACT
+				err := FromMsgpack(io.Discard, unhex(tc.msgpack))
+
+				// ASSERT
+				if !errors.Is(err, tc.error) {
+					t.Errorf("\nwanted error %v\ngot          %v", tc.error, err)
+				}
+			})
+		}
+	})
+}
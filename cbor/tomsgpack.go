@@ -0,0 +1,422 @@
+package cbor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/blugnu/msgpack"
+)
+
+// ToMsgpack converts CBOR data (a sequence of zero or more CBOR data
+// items) to msgpack, writing the result to w.
+//
+// Indefinite-length strings, arrays and maps are converted to their
+// definite-length msgpack equivalents; undefined is converted to nil
+// and half-precision floats to float32.  The self-described CBOR tag
+// (55799) is ignored.
+//
+// Date/time tags (0, 1 and 1001) are converted to msgpack timestamps.
+// No other tag has a msgpack equivalent and the content of the tag is
+// not passed through (the meaning of the tag, e.g. a bignum, would be
+// lost); an error wrapping msgpack.ErrUnsupportedType is returned.
+//
+// The output does not depend on any default Encoder options (see
+// msgpack.SetDefaultOptions); values are written in the most compact
+// formats of the current msgpack spec, with no limit on the size of the
+// output.
+//
+// An error is returned if the data is not valid CBOR (wrapping
+// msgpack.ErrTruncated or msgpack.ErrInvalidFormat), contains a tag or
+// simple value with no msgpack equivalent (msgpack.ErrUnsupportedType),
+// a negative integer less than math.MinInt64 (msgpack.ErrOverflow) or an
+// epoch-based date/time that is NaN, infinite or beyond the range of a
+// time.Time (msgpack.ErrValueOutOfRange), or has arrays, maps and tags
+// nested more deeply than msgpack.MaxDepth (msgpack.ErrDepthExceeded).
+// Any msgpack output up to the error will have been written to w, other
+// than the items of an indefinite length array or map that was not
+// complete.
+func ToMsgpack(w io.Writer, data []byte) error {
+	// the zero value Encoder has no options, default or otherwise
+	c := &toMsgpack{data: data}
+	c.enc.SetWriter(w)
+	for c.pos < len(data) {
+		if err := c.value(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toMsgpack holds the state of a conversion from CBOR to msgpack.
+type toMsgpack struct {
+	data  []byte
+	pos   int
+	enc   msgpack.Encoder
+	depth int // the number of arrays, maps and tags enclosing the current position
+}
+
+// nest records the start of an array, map or tag at offset start,
+// returning an error if the maximum depth of nesting is exceeded.  The
+// caller must call unnest when the array, map or tag is complete.
+func (c *toMsgpack) nest(start int) error {
+	if c.depth == msgpack.MaxDepth {
+		return fmt.Errorf("offset %d: %w: maximum depth is %d", start, msgpack.ErrDepthExceeded, msgpack.MaxDepth)
+	}
+	c.depth++
+	return nil
+}
+
+// unnest records the end of an array, map or tag.
+func (c *toMsgpack) unnest() {
+	c.depth--
+}
+
+// head reads the head of the data item at the current position,
+// returning the major type, the additional information and the
+// argument.  For an indefinite length item the argument is 0.
+func (c *toMsgpack) head() (major, info byte, arg uint64, err error) {
+	if c.pos >= len(c.data) {
+		return 0, 0, 0, fmt.Errorf("offset %d: %w: no data", c.pos, msgpack.ErrTruncated)
+	}
+	start := c.pos
+	b := c.data[c.pos]
+	major, info = b>>5, b&0x1f
+
+	size := 0
+	switch {
+	case info < info8bit:
+		arg = uint64(info)
+	case info <= info64bit:
+		size = 1 << (info - info8bit)
+	case info == infoIndefinite && (major >= majorBytes && major <= majorMap || b == cborBreak):
+	default:
+		return 0, 0, 0, fmt.Errorf("offset %d: %w: %#02x", start, msgpack.ErrInvalidFormat, b)
+	}
+
+	if c.pos+1+size > len(c.data) {
+		return 0, 0, 0, fmt.Errorf("offset %d: %w: head requires %d bytes, got %d", start, msgpack.ErrTruncated, 1+size, len(c.data)-c.pos)
+	}
+	if size > 0 {
+		arg = readUint(c.data[c.pos+1 : c.pos+1+size])
+	}
+	c.pos += 1 + size
+	return major, info, arg, nil
+}
+
+// content returns the n bytes of content following the head of a
+// string at the current position.
+func (c *toMsgpack) content(n uint64) ([]byte, error) {
+	if n > uint64(len(c.data)-c.pos) {
+		return nil, fmt.Errorf("offset %d: %w: expected %d bytes, got %d", c.pos, msgpack.ErrTruncated, n, len(c.data)-c.pos)
+	}
+	p := c.data[c.pos : c.pos+int(n)]
+	c.pos += int(n)
+	return p, nil
+}
+
+// value converts the data item at the current position.
+func (c *toMsgpack) value() error {
+	start := c.pos
+	major, info, arg, err := c.head()
+	if err != nil {
+		return err
+	}
+	indefinite := info == infoIndefinite
+
+	switch major {
+	case majorUint:
+		return c.enc.EncodeUint64(arg)
+
+	case majorNegInt:
+		if arg > math.MaxInt64 {
+			return fmt.Errorf("offset %d: %w: -1-%d", start, msgpack.ErrOverflow, arg)
+		}
+		return c.enc.EncodeInt64(-1 - int64(arg))
+
+	case majorBytes, majorText:
+		p, err := c.str(major, indefinite, arg)
+		if err != nil {
+			return err
+		}
+		if major == majorBytes {
+			return c.enc.EncodeBytes(p)
+		}
+		return c.enc.EncodeString(string(p))
+
+	case majorArray, majorMap:
+		if err := c.nest(start); err != nil {
+			return err
+		}
+		defer c.unnest()
+
+		if indefinite {
+			return c.indefinite(start, major)
+		}
+		n := int64(arg)
+		if major == majorArray {
+			err = c.enc.WriteArrayHeader64(n)
+		} else {
+			err = c.enc.WriteMapHeader64(n)
+			n *= 2
+		}
+		if err != nil {
+			return err
+		}
+		for i := int64(0); i < n; i++ {
+			if err := c.value(); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case majorTag:
+		if err := c.nest(start); err != nil {
+			return err
+		}
+		defer c.unnest()
+
+		return c.tag(start, arg)
+
+	default: // majorSimple
+		return c.simple(start, info, arg)
+	}
+}
+
+// str returns the content of a (possibly indefinite length) byte or
+// text string whose head has been read.
+func (c *toMsgpack) str(major byte, indefinite bool, n uint64) ([]byte, error) {
+	if !indefinite {
+		return c.content(n)
+	}
+
+	// an indefinite length string is a sequence of definite length
+	// strings of the same major type, terminated by a break
+	var result []byte
+	for {
+		if c.pos < len(c.data) && c.data[c.pos] == cborBreak {
+			c.pos++
+			return result, nil
+		}
+		start := c.pos
+		m, info, n, err := c.head()
+		if err != nil {
+			return nil, err
+		}
+		if m != major || info == infoIndefinite {
+			return nil, fmt.Errorf("offset %d: %w: invalid chunk in indefinite length string", start, msgpack.ErrInvalidFormat)
+		}
+		p, err := c.content(n)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, p...)
+	}
+}
+
+// indefinite converts an indefinite length array or map whose head (at
+// offset start) has been read.  The data items are converted to a
+// buffer, counting them, until the break; the header is then written
+// followed by the converted items.
+func (c *toMsgpack) indefinite(start int, major byte) error {
+	buf := &bytes.Buffer{}
+	og := c.enc.SetWriter(buf)
+
+	n := int64(0)
+	var err error
+	for err == nil {
+		if c.pos >= len(c.data) {
+			err = fmt.Errorf("offset %d: %w: missing break", c.pos, msgpack.ErrTruncated)
+			break
+		}
+		if c.data[c.pos] == cborBreak {
+			c.pos++
+			break
+		}
+		err = c.value()
+		n++
+	}
+	c.enc.SetWriter(og)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case major == majorArray:
+		err = c.enc.WriteArrayHeader64(n)
+	case n%2 != 0:
+		return fmt.Errorf("offset %d: %w: map has a key with no value", start, msgpack.ErrInvalidFormat)
+	default:
+		err = c.enc.WriteMapHeader64(n / 2)
+	}
+	if err != nil {
+		return err
+	}
+	return c.enc.Raw().Write(buf.Bytes())
+}
+
+// tag converts a tagged data item whose head has been read.
+func (c *toMsgpack) tag(start int, tag uint64) error {
+	switch tag {
+	case tagSelfDescribe:
+		return c.value()
+	case tagDateTime, tagEpoch, tagExtendedTime:
+		t, err := c.time(tag)
+		if err != nil {
+			return fmt.Errorf("offset %d: tag %d: %w", start, tag, err)
+		}
+		return c.enc.EncodeTime(t)
+	default:
+		return fmt.Errorf("offset %d: %w: tag %d", start, msgpack.ErrUnsupportedType, tag)
+	}
+}
+
+// time reads the content of a date/time tag.
+func (c *toMsgpack) time(tag uint64) (time.Time, error) {
+	switch tag {
+	case tagDateTime:
+		major, info, n, err := c.head()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if major != majorText {
+			return time.Time{}, msgpack.ErrTypeMismatch
+		}
+		p, err := c.str(major, info == infoIndefinite, n)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Parse(time.RFC3339Nano, string(p))
+
+	case tagEpoch:
+		if c.pos < len(c.data) && c.data[c.pos]>>5 == majorSimple {
+			f, err := c.float()
+			if err != nil {
+				return time.Time{}, err
+			}
+			// the seconds must be representable as an int64 (this also
+			// excludes infinities; NaN compares false with everything)
+			if math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+				return time.Time{}, fmt.Errorf("%w: %v seconds", msgpack.ErrValueOutOfRange, f)
+			}
+			sec, frac := math.Modf(f)
+			return time.Unix(int64(sec), int64(frac*1e9)), nil
+		}
+		sec, err := c.int()
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(sec, 0), nil
+
+	default: // tagExtendedTime
+		major, info, n, err := c.head()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if major != majorMap || info == infoIndefinite {
+			return time.Time{}, msgpack.ErrTypeMismatch
+		}
+		var sec, nsec int64
+		for i := uint64(0); i < n; i++ {
+			k, err := c.int()
+			if err != nil {
+				return time.Time{}, err
+			}
+			v, err := c.int()
+			if err != nil {
+				return time.Time{}, err
+			}
+			switch k {
+			case keySeconds:
+				sec = v
+			case keyMillis:
+				nsec = v * 1e6
+			case keyMicros:
+				nsec = v * 1e3
+			case keyNanoseconds:
+				nsec = v
+			default:
+				return time.Time{}, fmt.Errorf("%w: extended time key %d", msgpack.ErrUnsupportedType, k)
+			}
+		}
+		return time.Unix(sec, nsec), nil
+	}
+}
+
+// int reads an integer data item.
+func (c *toMsgpack) int() (int64, error) {
+	major, _, arg, err := c.head()
+	switch {
+	case err != nil:
+		return 0, err
+	case major != majorUint && major != majorNegInt:
+		return 0, msgpack.ErrTypeMismatch
+	case arg > math.MaxInt64:
+		return 0, msgpack.ErrOverflow
+	case major == majorNegInt:
+		return -1 - int64(arg), nil
+	default:
+		return int64(arg), nil
+	}
+}
+
+// float reads a floating point data item.
+func (c *toMsgpack) float() (float64, error) {
+	_, info, arg, err := c.head()
+	switch {
+	case err != nil:
+		return 0, err
+	case info == info16bit:
+		return float64(halfToFloat32(uint16(arg))), nil
+	case info == info32bit:
+		return float64(math.Float32frombits(uint32(arg))), nil
+	case info == info64bit:
+		return math.Float64frombits(arg), nil
+	default:
+		return 0, msgpack.ErrTypeMismatch
+	}
+}
+
+// simple converts a simple value or float whose head has been read.
+func (c *toMsgpack) simple(start int, info byte, arg uint64) error {
+	switch info {
+	case cborFalse & 0x1f:
+		return c.enc.EncodeBool(false)
+	case cborTrue & 0x1f:
+		return c.enc.EncodeBool(true)
+	case cborNull & 0x1f, cborUndef & 0x1f:
+		return c.enc.EncodeNil()
+	case info16bit:
+		return c.enc.EncodeFloat32(halfToFloat32(uint16(arg)))
+	case info32bit:
+		return c.enc.EncodeFloat32(math.Float32frombits(uint32(arg)))
+	case info64bit:
+		return c.enc.EncodeFloat64(math.Float64frombits(arg))
+	case infoIndefinite:
+		return fmt.Errorf("offset %d: %w: unexpected break", start, msgpack.ErrInvalidFormat)
+	default:
+		return fmt.Errorf("offset %d: %w: simple value %d", start, msgpack.ErrUnsupportedType, arg)
+	}
+}
+
+// halfToFloat32 converts an IEEE 754 half-precision float to a float32.
+func halfToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1f
+	frac := uint32(h) & 0x3ff
+
+	switch exp {
+	case 0: // zero or subnormal
+		f := float32(frac) / (1 << 24)
+		if sign != 0 {
+			f = -f
+		}
+		return f
+	case 0x1f: // infinity or NaN
+		return math.Float32frombits(sign | 0xff<<23 | frac<<13)
+	default:
+		return math.Float32frombits(sign | (exp+127-15)<<23 | frac<<13)
+	}
+}
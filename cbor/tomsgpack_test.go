@@ -0,0 +1,133 @@
+package cbor
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/blugnu/msgpack"
+)
+
+func TestToMsgpack(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		spec   string
+		cbor   string
+		result string
+		error
+	}{
+		{spec: "empty", cbor: "", result: ""},
+		{spec: "null", cbor: "f6", result: "c0"},
+		{spec: "undefined", cbor: "f7", result: "c0"},
+		{spec: "false", cbor: "f4", result: "c2"},
+		{spec: "true", cbor: "f5", result: "c3"},
+		{spec: "0", cbor: "00", result: "00"},
+		{spec: "100", cbor: "1864", result: "64"},
+		{spec: "1000", cbor: "1903e8", result: "cd03e8"},
+		{spec: "1000000000000", cbor: "1b000000e8d4a51000", result: "cf000000e8d4a51000"},
+		{spec: "MaxUint64", cbor: "1bffffffffffffffff", result: "cfffffffffffffffff"},
+		{spec: "-10", cbor: "29", result: "f6"},
+		{spec: "-1000", cbor: "3903e7", result: "d1fc18"},
+		{spec: "MinInt64", cbor: "3b7fffffffffffffff", result: "d38000000000000000"},
+		{spec: "half 1.0", cbor: "f93c00", result: "ca3f800000"},
+		{spec: "half 65504.0", cbor: "f97bff", result: "ca477fe000"},
+		{spec: "half subnormal", cbor: "f90001", result: "ca33800000"},
+		{spec: "half -Inf", cbor: "f9fc00", result: "caff800000"},
+		{spec: "float32", cbor: "fa47c35000", result: "ca47c35000"},
+		{spec: "float64", cbor: "fb3ff199999999999a", result: "cb3ff199999999999a"},
+		{spec: "\"IETF\"", cbor: "6449455446", result: "a449455446"},
+		{spec: "h'01020304'", cbor: "4401020304", result: "c40401020304"},
+		{spec: "(_ h'0102', h'030405')", cbor: "5f42010243030405ff", result: "c4050102030405"},
+		{spec: "(_ \"strea\", \"ming\")", cbor: "7f657374726561646d696e67ff", result: "a973747265616d696e67"},
+		{spec: "[1,[2,3],[4,5]]", cbor: "8301820203820405", result: "9301920203920405"},
+		{spec: "[_ ]", cbor: "9fff", result: "90"},
+		{spec: "[_ 1, [2, 3], [_ 4, 5]]", cbor: "9f018202039f0405ffff", result: "9301920203920405"},
+		{spec: "{_ \"a\": 1, \"b\": [_ 2, 3]}", cbor: "bf61610161629f0203ffff", result: "82a16101a162920203"},
+		{spec: "tag 0", cbor: "c074323031332d30332d32315432303a30343a30305a", result: "d6ff514b67b0"},
+		{spec: "tag 1 (int)", cbor: "c11a514b67b0", result: "d6ff514b67b0"},
+		{spec: "tag 1 (float)", cbor: "c1fb41d452d9ec200000", result: "d7ff77359400514b67b0"},
+		{spec: "tag 1001", cbor: "d903e9a2011a514b67b02801", result: "d7ff00000004514b67b0"},
+		{spec: "self-described", cbor: "d9d9f701", result: "01"},
+		{spec: "-2^64", cbor: "3bffffffffffffffff", result: "", error: msgpack.ErrOverflow},
+		{spec: "tag 1 (NaN)", cbor: "c1f97e00", result: "", error: msgpack.ErrValueOutOfRange},
+		{spec: "tag 1 (+Inf)", cbor: "c1f97c00", result: "", error: msgpack.ErrValueOutOfRange},
+		{spec: "tag 1 (-Inf)", cbor: "c1f9fc00", result: "", error: msgpack.ErrValueOutOfRange},
+		{spec: "tag 1 (1e300)", cbor: "c1fb7e37e43c8800759c", result: "", error: msgpack.ErrValueOutOfRange},
+		{spec: "bignum", cbor: "c249010000000000000000", result: "", error: msgpack.ErrUnsupportedType},
+		{spec: "other tag", cbor: "d8206161", result: "", error: msgpack.ErrUnsupportedType},
+		{spec: "tag in array", cbor: "8201d82000", result: "9201", error: msgpack.ErrUnsupportedType},
+		{spec: "simple(16)", cbor: "f0", result: "", error: msgpack.ErrUnsupportedType},
+		{spec: "break", cbor: "ff", result: "", error: msgpack.ErrInvalidFormat},
+		{spec: "reserved", cbor: "1c", result: "", error: msgpack.ErrInvalidFormat},
+		{spec: "truncated head", cbor: "19", result: "", error: msgpack.ErrTruncated},
+		{spec: "truncated string", cbor: "6461", result: "", error: msgpack.ErrTruncated},
+		{spec: "missing break", cbor: "9f01", result: "", error: msgpack.ErrTruncated},
+		{spec: "invalid chunk", cbor: "5f6161ff", result: "", error: msgpack.ErrInvalidFormat},
+		{spec: "map with odd items", cbor: "bf6161ff", result: "", error: msgpack.ErrInvalidFormat},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			// ACT
+			err := ToMsgpack(buf, unhex(tc.cbor))
+
+			// ASSERT
+			if !errors.Is(err, tc.error) {
+				t.Errorf("\nwanted error %v\ngot          %v", tc.error, err)
+			}
+
+			wanted := unhex(tc.result)
+			got := buf.Bytes()
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %x\ngot    %x", wanted, got)
+			}
+		})
+	}
+	t.Run("nesting", func(t *testing.T) {
+		testcases := []struct {
+			spec string
+			cbor string
+			error
+		}{
+			{spec: "arrays at max depth", cbor: strings.Repeat("81", msgpack.MaxDepth) + "00"},
+			{spec: "arrays exceeding max depth", cbor: strings.Repeat("81", msgpack.MaxDepth+1) + "00", error: msgpack.ErrDepthExceeded},
+			{spec: "indefinite arrays exceeding max depth", cbor: strings.Repeat("9f", msgpack.MaxDepth+1) + strings.Repeat("ff", msgpack.MaxDepth+1), error: msgpack.ErrDepthExceeded},
+			{spec: "tags exceeding max depth", cbor: strings.Repeat("d9d9f7", msgpack.MaxDepth+1) + "00", error: msgpack.ErrDepthExceeded},
+		}
+		for _, tc := range testcases {
+			t.Run(tc.spec, func(t *testing.T) {
+				// ACT
+				err := ToMsgpack(io.Discard, unhex(tc.cbor))
+
+				// ASSERT
+				if !errors.Is(err, tc.error) {
+					t.Errorf("\nwanted error %v\ngot          %v", tc.error, err)
+				}
+			})
+		}
+	})
+}
+
+func TestToMsgpackIgnoresDefaultOptions(t *testing.T) {
+	// ARRANGE
+	msgpack.SetDefaultOptions(msgpack.MaxSize(2), msgpack.OldSpec())
+	defer msgpack.SetDefaultOptions()
+	buf := &bytes.Buffer{}
+
+	// ACT
+	err := ToMsgpack(buf, unhex("4401020304"))
+
+	// ASSERT
+	if err != nil {
+		t.Errorf("\nwanted error <nil>\ngot          %v", err)
+	}
+
+	wanted := unhex("c40401020304")
+	got := buf.Bytes()
+	if !bytes.Equal(wanted, got) {
+		t.Errorf("\nwanted %x\ngot    %x", wanted, got)
+	}
+}
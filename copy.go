@@ -0,0 +1,241 @@
+package msgpack
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// CopyFilter is a function called by CopyFiltered for each entry with a
+// string key in each map copied.  It is called with the path of the
+// entry (e.g. "orders[3].customer.name", identifying the entry in the
+// data being copied; keys that are not strings are identified by their
+// encoded bytes, in hex), the key and the encoded value, and returns the
+// key and encoded value to be copied in their place, or false to drop
+// the entry.
+//
+// The entries of a map are passed to the filters before the entries of
+// any maps nested in their values.
+//
+// A filter that does not modify an entry returns the key and value it
+// was called with.
+type CopyFilter func(path, key string, value []byte) (string, []byte, bool)
+
+// CopyFiltered copies an encoded value to an Encoder, applying filters
+// to the entries of every map in the value (including maps nested in
+// other maps and arrays) to drop, rename or rewrite entries as they are
+// copied, e.g. to sanitize or reduce the size of messages passing
+// through a proxy.  Values are copied without being decoded.
+//
+// Filters are applied to each entry in the order specified; if a filter
+// drops an entry no further filters are applied to it.  The value of an
+// entry returned by the filters is then copied (and filtered) in turn,
+// so entries of a map that replaces a value are also filtered.  Entries
+// with keys that are not strings are not passed to the filters (but
+// their values are copied and filtered).
+//
+// The data to be copied must be a single, complete encoded value, with
+// arrays and maps nested no more deeply than MaxDepth; if it is not,
+// nothing is copied and an error wrapping ErrInvalidFormat (or
+// ErrDepthExceeded) is returned.  An error wrapping ErrInvalidFormat is
+// also returned if a filter returns a value that is not a single encoded
+// value, or ErrDepthExceeded if a value returned by a filter results in
+// maps and arrays nested more deeply than MaxDepth, but in these cases
+// (as for any error writing to the Encoder) a partial value may have
+// been written.
+func CopyFiltered(enc Encoder, data []byte, filters ...CopyFilter) error {
+	src, err := indexValue(data)
+	if err != nil {
+		return fmt.Errorf("CopyFiltered: %w", err)
+	}
+	c := &copier{enc: enc, filters: filters}
+	if err := c.copy(src, 0, nil); err != nil {
+		return fmt.Errorf("CopyFiltered: %w", err)
+	}
+	return nil
+}
+
+// indexValue returns a source for the encoded value in data.  An error
+// wrapping ErrInvalidFormat is returned if data is not a single, complete
+// encoded value, or ErrDepthExceeded if arrays and maps are nested more
+// deeply than MaxDepth.
+func indexValue(data []byte) (source, error) {
+	src, n, err := newSource(data)
+	switch {
+	case errors.Is(err, ErrDepthExceeded):
+		return source{}, err
+	case err != nil:
+		return source{}, fmt.Errorf("%w: value: %v", ErrInvalidFormat, err)
+	case n != len(data):
+		return source{}, fmt.Errorf("%w: value: %d bytes following the value", ErrInvalidFormat, len(data)-n)
+	}
+	return src, nil
+}
+
+// copyPath is the path of a value being copied, identifying the index (or
+// key) of the value in each enclosing array (or map).  The path of the
+// root value is nil.  The string representation of a path is built only
+// if required (by a filter, or to identify an error) and is retained so
+// that it need not be rebuilt for the paths of any nested values.
+type copyPath struct {
+	parent *copyPath
+	index  int64  // the index of an array element; -1 for a map entry
+	key    string // the key of a map entry with a string key
+	raw    []byte // the encoded key of a map entry with a key that is not a string
+	s      string
+	built  bool
+}
+
+// String returns the path, e.g. "orders[3].customer.name".
+func (p *copyPath) String() string {
+	if p == nil {
+		return ""
+	}
+	if !p.built {
+		parent := p.parent.String()
+		switch {
+		case p.index >= 0:
+			p.s = parent + "[" + strconv.FormatInt(p.index, 10) + "]"
+		case p.raw != nil:
+			p.s = fmt.Sprintf("%s[%x]", parent, p.raw)
+		default:
+			p.s = join(parent, p.key)
+		}
+		p.built = true
+	}
+	return p.s
+}
+
+// copier copies a value for CopyFiltered.
+type copier struct {
+	enc     Encoder
+	filters []CopyFilter
+	depth   int // the number of arrays and maps enclosing the value being copied
+}
+
+// copiedEntry is an entry of a map to be written by a copier.
+type copiedEntry struct {
+	path  *copyPath // path of the entry in the data being copied
+	key   string    // the (string) key of the entry, if raw is nil
+	raw   []byte    // the encoded key of the entry, if not a string
+	value source    // the source of the value of the entry
+	at    int       // the offset of the value of the entry in its source
+}
+
+// copy copies the value at offset at in a source to the Encoder,
+// applying filters to the entries of any maps.  path is the path of the
+// value in the data being copied.
+func (c *copier) copy(src source, at int, path *copyPath) error {
+	f, n, hl, _ := ReadHeader(src.data[at:])
+	isArray := f >= FormatFixArray && f <= FormatArray32
+	isMap := f >= FormatFixMap && f <= FormatMap32
+	if !isArray && !isMap {
+		c.enc.track()
+		return c.enc.writeBytes(src.data[at:src.end(at)])
+	}
+
+	if c.depth == MaxDepth {
+		return fmt.Errorf("%s: %w: maximum depth is %d", path.String(), ErrDepthExceeded, MaxDepth)
+	}
+	c.depth++
+	defer func() { c.depth-- }()
+
+	if isArray {
+		if err := c.enc.writeArrayHeader(n); err != nil {
+			return err
+		}
+		pos := at + hl
+		for i := int64(0); i < n; i++ {
+			// only the paths of arrays and maps are required, to
+			// identify the entries of maps
+			var epath *copyPath
+			if b := src.data[pos]; IsArray(b) || IsMap(b) {
+				epath = &copyPath{parent: path, index: i}
+			}
+			if err := c.copy(src, pos, epath); err != nil {
+				return err
+			}
+			pos = src.end(pos)
+		}
+		return nil
+	}
+
+	entries, err := c.filterEntries(src, at+hl, n, path)
+	if err != nil {
+		return err
+	}
+	if err := c.enc.writeMapHeader(int64(len(entries))); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.raw != nil {
+			c.enc.track()
+			err = c.enc.writeBytes(e.raw)
+		} else {
+			err = c.enc.EncodeString(e.key)
+		}
+		if err != nil {
+			return err
+		}
+		if err := c.copy(e.value, e.at, e.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filterEntries applies the filters to the n entries of a map, starting
+// at offset pos in a source, returning the entries to be copied.  path
+// is the path of the map in the data being copied.
+func (c *copier) filterEntries(src source, pos int, n int64, path *copyPath) ([]copiedEntry, error) {
+	result := make([]copiedEntry, 0, n)
+	for i := int64(0); i < n; i++ {
+		kend := src.end(pos)
+		vend := src.end(kend)
+		key, value := src.data[pos:kend], src.data[kend:vend]
+		pos = vend
+
+		k, ok := stringValue(key)
+		if !ok {
+			result = append(result, copiedEntry{path: &copyPath{parent: path, index: -1, raw: key}, raw: key, value: src, at: kend})
+			continue
+		}
+
+		entry := copiedEntry{path: &copyPath{parent: path, index: -1, key: string(k)}, key: string(k), value: src, at: kend}
+		filtered := value
+		keep := true
+		for _, filter := range c.filters {
+			if entry.key, filtered, keep = filter(entry.path.String(), entry.key, filtered); !keep {
+				break
+			}
+		}
+		if !keep {
+			continue
+		}
+
+		if !sameBytes(filtered, value) {
+			fsrc, err := indexValue(filtered)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", entry.path.String(), err)
+			}
+			entry.value, entry.at = fsrc, 0
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+// join returns the path of a map entry with the specified key in a map
+// with the specified path.
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// sameBytes returns true if a and b are the same slice (with the same
+// length and underlying array), in which case a need not be validated.
+func sameBytes(a, b []byte) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
@@ -0,0 +1,126 @@
+package msgpack
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCopyFiltered(t *testing.T) {
+	// ARRANGE
+	data := []byte{
+		0x83,
+		0xa4, 'u', 's', 'e', 'r', 0x82, 0xa2, 'i', 'd', 0x01, 0xa8, 'p', 'a', 's', 's', 'w', 'o', 'r', 'd', 0xa1, 'x',
+		0xa4, 't', 'a', 'g', 's', 0x91, 0x81, 0xa1, 'k', 0xa1, 'v',
+		0x01, 0x81, 0xa1, 'k', 0xc0, // non-string key
+	}
+
+	drop := func(path, key string, value []byte) (string, []byte, bool) {
+		return key, value, key != "password"
+	}
+	rename := func(path, key string, value []byte) (string, []byte, bool) {
+		return strings.ToUpper(key), value, true
+	}
+	paths := []string{}
+	record := func(path, key string, value []byte) (string, []byte, bool) {
+		paths = append(paths, path)
+		return key, value, true
+	}
+
+	testcases := []struct {
+		spec    string
+		data    []byte
+		filters []CopyFilter
+		result  []byte
+		error
+	}{
+		{spec: "no filters", data: data, result: data},
+		{spec: "scalar", data: []byte{0x01}, filters: []CopyFilter{drop}, result: []byte{0x01}},
+		{spec: "drop",
+			data:    data,
+			filters: []CopyFilter{drop},
+			result: []byte{
+				0x83,
+				0xa4, 'u', 's', 'e', 'r', 0x81, 0xa2, 'i', 'd', 0x01,
+				0xa4, 't', 'a', 'g', 's', 0x91, 0x81, 0xa1, 'k', 0xa1, 'v',
+				0x01, 0x81, 0xa1, 'k', 0xc0,
+			},
+		},
+		{spec: "rename",
+			data:    []byte{0x81, 0xa1, 'a', 0x91, 0x81, 0xa1, 'b', 0x01},
+			filters: []CopyFilter{rename},
+			result:  []byte{0x81, 0xa1, 'A', 0x91, 0x81, 0xa1, 'B', 0x01},
+		},
+		{spec: "rewrite",
+			data: []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02},
+			filters: []CopyFilter{func(path, key string, value []byte) (string, []byte, bool) {
+				if key == "a" {
+					return key, []byte{0x81, 0xa8, 'p', 'a', 's', 's', 'w', 'o', 'r', 'd', 0xc0}, true
+				}
+				return key, value, true
+			}, drop},
+			result: []byte{0x82, 0xa1, 'a', 0x80, 0xa1, 'b', 0x02},
+		},
+		{spec: "invalid data", data: []byte{0x81, 0xa1, 'a'}, error: ErrInvalidFormat},
+		{spec: "trailing data", data: []byte{0x01, 0x02}, error: ErrInvalidFormat},
+		{spec: "invalid filtered value",
+			data: []byte{0x81, 0xa1, 'a', 0x01},
+			filters: []CopyFilter{func(path, key string, value []byte) (string, []byte, bool) {
+				return key, []byte{0xa2}, true
+			}},
+			result: []byte{},
+			error:  ErrInvalidFormat,
+		},
+		{spec: "nested to maximum depth",
+			data:    append(bytes.Repeat([]byte{0x91}, MaxDepth), 0x00),
+			filters: []CopyFilter{drop},
+			result:  append(bytes.Repeat([]byte{0x91}, MaxDepth), 0x00),
+		},
+		{spec: "nested too deeply",
+			data:    append(bytes.Repeat([]byte{0x91}, MaxDepth+1), 0x00),
+			filters: []CopyFilter{drop},
+			error:   ErrDepthExceeded,
+		},
+		{spec: "filtered value nested too deeply",
+			data: []byte{0x81, 0xa1, 'a', 0x01},
+			filters: []CopyFilter{func(path, key string, value []byte) (string, []byte, bool) {
+				return key, append(bytes.Repeat([]byte{0x91}, MaxDepth), 0x00), true
+			}},
+			result: append([]byte{0x81, 0xa1, 'a'}, bytes.Repeat([]byte{0x91}, MaxDepth-1)...),
+			error:  ErrDepthExceeded,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			enc, buf := NewTestEncoder()
+
+			// ACT
+			err := CopyFiltered(enc, tc.data, tc.filters...)
+
+			// ASSERT
+			testError(t, tc.error, err)
+
+			wanted := tc.result
+			got := buf.Bytes()
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %x\ngot    %x", wanted, got)
+			}
+		})
+	}
+
+	t.Run("paths", func(t *testing.T) {
+		enc, _ := NewTestEncoder()
+
+		// ACT
+		err := CopyFiltered(enc, data, record)
+
+		// ASSERT
+		testError(t, nil, err)
+
+		wanted := "user tags user.id user.password tags[0].k [01].k"
+		got := strings.Join(paths, " ")
+		if wanted != got {
+			t.Errorf("\nwanted %q\ngot    %q", wanted, got)
+		}
+	})
+}
@@ -0,0 +1,231 @@
+package msgpack
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SetPath returns a copy of the encoded document doc with the value at
+// the specified path set to value (an encoded value).  Only the affected
+// region of the document is rewritten; no other value is decoded or
+// re-encoded.
+//
+// The path is a JSON Pointer (RFC 6901), e.g. "/meta/traceId" or
+// "/items/0", identifying map entries by (string) key and array
+// elements by index.  An empty path identifies the document itself.
+//
+// If the final token of the path identifies an entry that is not present
+// in a map, the entry is added to the end of the map.  For an array the
+// final token "-" (or an index equal to the length of the array) appends
+// the value to the array.  The header of a map or array to which a value
+// is added is rewritten with the new length (changing format if
+// necessary, e.g. from fixmap to map16).
+//
+// An error wrapping ErrNotFound is returned if any other token of the
+// path does not identify a value in the document, ErrTypeMismatch if the
+// path traverses a value that is not a map or array, or ErrInvalidFormat
+// if value is not a single encoded value.
+func SetPath(doc []byte, path string, value []byte) ([]byte, error) {
+	if err := checkValue(value); err != nil {
+		return nil, fmt.Errorf("SetPath: %s: %w", path, err)
+	}
+
+	loc, found, err := locatePath(doc, path)
+	if err != nil {
+		return nil, fmt.Errorf("SetPath: %s: %w", path, err)
+	}
+
+	switch {
+	case found:
+		return loc.splice(doc, loc.value, loc.end, value, 0)
+	case loc.isMap:
+		entry := AppendString(nil, loc.key)
+		return loc.splice(doc, loc.end, loc.end, append(entry, value...), 1)
+	default:
+		return loc.splice(doc, loc.end, loc.end, value, 1)
+	}
+}
+
+// DeletePath returns a copy of the encoded document doc with the value
+// at the specified path (a JSON Pointer; see SetPath) removed.  The
+// header of the map or array that contained the value is rewritten with
+// the new length.
+//
+// An error wrapping ErrNotFound is returned if the path does not identify
+// a value in the document or identifies the document itself.
+func DeletePath(doc []byte, path string) ([]byte, error) {
+	loc, found, err := locatePath(doc, path)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("DeletePath: %s: %w", path, err)
+	case !found || loc.parent < 0:
+		return nil, fmt.Errorf("DeletePath: %s: %w", path, ErrNotFound)
+	}
+	return loc.splice(doc, loc.entry, loc.end, nil, -1)
+}
+
+// location identifies a value (or, if not found, the position at which
+// a value would be added) within an encoded document.
+type location struct {
+	parent int    // offset of the header of the map or array containing the value (-1 for the document itself)
+	isMap  bool   // true if the parent is a map
+	count  int64  // the number of entries (or elements) of the parent
+	key    string // the key of the value (if the parent is a map)
+	index  int64  // the index of the value (if the parent is an array)
+	entry  int    // offset of the entry (the key, for a map entry)
+	value  int    // offset of the value
+	end    int    // offset following the value
+}
+
+// checkValue returns an error wrapping ErrInvalidFormat if p is not a
+// single, complete encoded value.
+func checkValue(p []byte) error {
+	n, err := valueLen(p)
+	switch {
+	case err != nil:
+		return fmt.Errorf("%w: value: %v", ErrInvalidFormat, err)
+	case n != len(p):
+		return fmt.Errorf("%w: value: %d bytes following the value", ErrInvalidFormat, len(p)-n)
+	}
+	return nil
+}
+
+// unescapeToken unescapes the '/' and '~' characters of a JSON Pointer
+// token.
+var unescapeToken = strings.NewReplacer("~1", "/", "~0", "~")
+
+// parsePointer returns the (unescaped) tokens of a JSON Pointer.
+func parsePointer(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if path[0] != '/' {
+		return nil, fmt.Errorf("%w: path must be empty or start with '/'", ErrInvalidFormat)
+	}
+
+	tokens := strings.Split(path[1:], "/")
+	for i, tok := range tokens {
+		tokens[i] = unescapeToken.Replace(tok)
+	}
+	return tokens, nil
+}
+
+// parseIndex returns the array index identified by a token: a decimal
+// number with no leading zeros, or "-" identifying the element following
+// the last element of an array of n elements.
+func parseIndex(tok string, n int64) (int64, error) {
+	if tok == "-" {
+		return n, nil
+	}
+	i, err := strconv.ParseInt(tok, 10, 64)
+	if err != nil || i < 0 || (len(tok) > 1 && tok[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index: %q", ErrNotFound, tok)
+	}
+	return i, nil
+}
+
+// locatePath returns the location of the value identified by a JSON
+// Pointer in an encoded document.  If the final token of the path does
+// not identify a value the location of the end of the containing map
+// or array is returned, with found false.
+func locatePath(doc []byte, path string) (loc location, found bool, err error) {
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return loc, false, err
+	}
+	return locate(doc, tokens)
+}
+
+// locate returns the location of the value identified by the specified
+// tokens in an encoded document (see locatePath).
+func locate(doc []byte, tokens []string) (location, bool, error) {
+	n, err := valueLen(doc)
+	if err != nil {
+		return location{}, false, err
+	}
+	loc := location{parent: -1, end: n}
+
+	for i, tok := range tokens {
+		f, count, hl, _ := ReadHeader(doc[loc.value:])
+		isArray := f >= FormatFixArray && f <= FormatArray32
+		isMap := f >= FormatFixMap && f <= FormatMap32
+		if !isArray && !isMap {
+			return loc, false, fmt.Errorf("%w: %s is not a map or array", ErrTypeMismatch, f)
+		}
+
+		next := location{parent: loc.value, isMap: isMap, count: count, key: tok}
+		pos := loc.value + hl
+		found := false
+
+		if isArray {
+			if next.index, err = parseIndex(tok, count); err != nil {
+				return loc, false, err
+			}
+			for j := int64(0); j < count && !found; j++ {
+				n, _ := valueLen(doc[pos:])
+				if found = j == next.index; found {
+					next.entry, next.value, next.end = pos, pos, pos+n
+				} else {
+					pos += n
+				}
+			}
+		} else {
+			for j := int64(0); j < count && !found; j++ {
+				kn, _ := valueLen(doc[pos:])
+				vn, _ := valueLen(doc[pos+kn:])
+				if found = keyEquals(doc[pos:pos+kn], tok); found {
+					next.entry, next.value, next.end = pos, pos+kn, pos+kn+vn
+				} else {
+					pos += kn + vn
+				}
+			}
+		}
+
+		if !found {
+			if i < len(tokens)-1 || (isArray && next.index != count) {
+				return loc, false, fmt.Errorf("%w: %q", ErrNotFound, tok)
+			}
+			next.entry, next.value, next.end = pos, pos, pos
+			return next, false, nil
+		}
+		loc = next
+	}
+	return loc, true, nil
+}
+
+// keyEquals returns true if the encoded map key p is a string equal
+// to s.
+func keyEquals(p []byte, s string) bool {
+	f, _, hl, _ := ReadHeader(p)
+	return f >= FormatFixStr && f <= FormatStr32 && string(p[hl:]) == s
+}
+
+// splice returns a copy of doc with the bytes from start to end replaced
+// by p and the length of the map (or array) containing the location
+// adjusted by delta.
+func (loc location) splice(doc []byte, start, end int, p []byte, delta int64) ([]byte, error) {
+	if loc.parent < 0 || delta == 0 {
+		result := make([]byte, 0, len(doc)-(end-start)+len(p))
+		result = append(result, doc[:start]...)
+		result = append(result, p...)
+		return append(result, doc[end:]...), nil
+	}
+
+	count := loc.count + delta
+	if count > maxLength {
+		return nil, fmt.Errorf("%w: %d entries (max %d)", ErrTooLarge, count, maxLength)
+	}
+
+	_, _, hl, _ := ReadHeader(doc[loc.parent:])
+	result := make([]byte, 0, len(doc)-(end-start)+len(p)+4)
+	result = append(result, doc[:loc.parent]...)
+	if loc.isMap {
+		result = appendMapHeader(result, count)
+	} else {
+		result = appendArrayHeader(result, count)
+	}
+	result = append(result, doc[loc.parent+hl:start]...)
+	result = append(result, p...)
+	return append(result, doc[end:]...), nil
+}
@@ -0,0 +1,130 @@
+package msgpack
+
+import (
+	"bytes"
+	"testing"
+)
+
+// testDoc returns the encoding of {"a": 1, "b": [true, false], "c/d": {"e": "x"}}.
+func testDoc() []byte {
+	return []byte{
+		0x83,
+		0xa1, 'a', 0x01,
+		0xa1, 'b', 0x92, 0xc3, 0xc2,
+		0xa3, 'c', '/', 'd', 0x81, 0xa1, 'e', 0xa1, 'x',
+	}
+}
+
+func TestSetPath(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		spec   string
+		doc    []byte
+		path   string
+		value  []byte
+		result []byte
+		error
+	}{
+		{spec: "document", doc: testDoc(), path: "", value: []byte{0xc0}, result: []byte{0xc0}},
+		{spec: "replace entry",
+			doc:    testDoc(),
+			path:   "/a",
+			value:  String("one"),
+			result: []byte{0x83, 0xa1, 'a', 0xa3, 'o', 'n', 'e', 0xa1, 'b', 0x92, 0xc3, 0xc2, 0xa3, 'c', '/', 'd', 0x81, 0xa1, 'e', 0xa1, 'x'},
+		},
+		{spec: "replace element",
+			doc:    testDoc(),
+			path:   "/b/1",
+			value:  []byte{0xc3},
+			result: []byte{0x83, 0xa1, 'a', 0x01, 0xa1, 'b', 0x92, 0xc3, 0xc3, 0xa3, 'c', '/', 'd', 0x81, 0xa1, 'e', 0xa1, 'x'},
+		},
+		{spec: "append element",
+			doc:    testDoc(),
+			path:   "/b/-",
+			value:  []byte{0xc0},
+			result: []byte{0x83, 0xa1, 'a', 0x01, 0xa1, 'b', 0x93, 0xc3, 0xc2, 0xc0, 0xa3, 'c', '/', 'd', 0x81, 0xa1, 'e', 0xa1, 'x'},
+		},
+		{spec: "append element (index)",
+			doc:    []byte{0x90},
+			path:   "/0",
+			value:  []byte{0x01},
+			result: []byte{0x91, 0x01},
+		},
+		{spec: "add entry (escaped key)",
+			doc:    testDoc(),
+			path:   "/c~1d/f~0",
+			value:  []byte{0x02},
+			result: []byte{0x83, 0xa1, 'a', 0x01, 0xa1, 'b', 0x92, 0xc3, 0xc2, 0xa3, 'c', '/', 'd', 0x82, 0xa1, 'e', 0xa1, 'x', 0xa2, 'f', '~', 0x02},
+		},
+		{spec: "add entry (header grows)",
+			doc:    append([]byte{0x8f}, bytes.Repeat([]byte{0x01, 0x01}, 15)...),
+			path:   "/k",
+			value:  []byte{0x02},
+			result: append(append([]byte{0xde, 0x00, 0x10}, bytes.Repeat([]byte{0x01, 0x01}, 15)...), 0xa1, 'k', 0x02),
+		},
+		{spec: "missing parent", doc: testDoc(), path: "/x/y", value: []byte{0xc0}, error: ErrNotFound},
+		{spec: "index out of range", doc: testDoc(), path: "/b/3", value: []byte{0xc0}, error: ErrNotFound},
+		{spec: "invalid index", doc: testDoc(), path: "/b/01", value: []byte{0xc0}, error: ErrNotFound},
+		{spec: "not a container", doc: testDoc(), path: "/a/b", value: []byte{0xc0}, error: ErrTypeMismatch},
+		{spec: "invalid path", doc: testDoc(), path: "a", value: []byte{0xc0}, error: ErrInvalidFormat},
+		{spec: "invalid value", doc: testDoc(), path: "/a", value: []byte{0x01, 0x02}, error: ErrInvalidFormat},
+		{spec: "truncated value", doc: testDoc(), path: "/a", value: []byte{0xa2, 'x'}, error: ErrInvalidFormat},
+		{spec: "truncated document", doc: testDoc()[:10], path: "/a", value: []byte{0xc0}, error: ErrTruncated},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			// ACT
+			result, err := SetPath(tc.doc, tc.path, tc.value)
+
+			// ASSERT
+			testError(t, tc.error, err)
+
+			wanted := tc.result
+			got := result
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %x\ngot    %x", wanted, got)
+			}
+		})
+	}
+}
+
+func TestDeletePath(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		spec   string
+		path   string
+		result []byte
+		error
+	}{
+		{spec: "entry",
+			path:   "/a",
+			result: []byte{0x82, 0xa1, 'b', 0x92, 0xc3, 0xc2, 0xa3, 'c', '/', 'd', 0x81, 0xa1, 'e', 0xa1, 'x'},
+		},
+		{spec: "element",
+			path:   "/b/0",
+			result: []byte{0x83, 0xa1, 'a', 0x01, 0xa1, 'b', 0x91, 0xc2, 0xa3, 'c', '/', 'd', 0x81, 0xa1, 'e', 0xa1, 'x'},
+		},
+		{spec: "nested entry",
+			path:   "/c~1d/e",
+			result: []byte{0x83, 0xa1, 'a', 0x01, 0xa1, 'b', 0x92, 0xc3, 0xc2, 0xa3, 'c', '/', 'd', 0x80},
+		},
+		{spec: "document", path: "", error: ErrNotFound},
+		{spec: "missing entry", path: "/x", error: ErrNotFound},
+		{spec: "missing element", path: "/b/-", error: ErrNotFound},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			// ACT
+			result, err := DeletePath(testDoc(), tc.path)
+
+			// ASSERT
+			testError(t, tc.error, err)
+
+			wanted := tc.result
+			got := result
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %x\ngot    %x", wanted, got)
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package msgpack
 
+import "context"
+
 // EncodeArray encodes an array to the current writer.
 //
 // A function may be provided to encode each element of the array.
@@ -7,8 +9,35 @@ package msgpack
 // each element using the Encoder.Encode method.
 //
 // If an error is returned from the function, encoding will stop and
-// the error will be returned to the caller.
+// the error will be returned to the caller, wrapped in an EncodeError
+// identifying the element that could not be encoded.  A panic when
+// encoding an element (e.g. ErrUnsupportedType) is similarly annotated.
 func EncodeArray[T any](enc Encoder, s []T, fn func(Encoder, T) error) error {
+	return encodeArray(nil, enc, s, fn)
+}
+
+// EncodeArrayCtx encodes an array to the current writer, in the same
+// way as EncodeArray, checking the specified context periodically (and
+// before writing the array header).  If the context is cancelled or its
+// deadline expires, encoding stops and the context error is returned.
+//
+// This enables encoding of very large arrays to be interrupted, e.g.
+// when a request times out.  As with any other error, if encoding
+// is stopped after the array header has been written the output
+// will be invalid.
+func EncodeArrayCtx[T any](ctx context.Context, enc Encoder, s []T, fn func(Encoder, T) error) error {
+	return encodeArray(ctx.Err, enc, s, fn)
+}
+
+// encodeArray implements EncodeArray and EncodeArrayCtx.  If cancelled is
+// nil no context checks are made.
+func encodeArray[T any](cancelled func() error, enc Encoder, s []T, fn func(Encoder, T) error) error {
+	if cancelled != nil {
+		if err := cancelled(); err != nil {
+			return err
+		}
+	}
+
 	if err := enc.WriteArrayHeader(len(s)); err != nil {
 		return err
 	}
@@ -19,12 +48,19 @@ func EncodeArray[T any](enc Encoder, s []T, fn func(Encoder, T) error) error {
 		}
 	}
 
-	for _, v := range s {
-		if enc.err != nil {
-			break
+	i := 0
+	defer annotatePanic(func(err error) error { return atIndex(i, err) })
+
+	for ; i < len(s); i++ {
+		if cancelled != nil && i%ctxCheckInterval == ctxCheckInterval-1 {
+			if err := cancelled(); err != nil {
+				return err
+			}
+		}
+		if err := fn(enc, s[i]); err != nil {
+			return atIndex(i, err)
 		}
-		enc.err = fn(enc, v)
 	}
 
-	return enc.err
+	return nil
 }
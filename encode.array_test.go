@@ -2,6 +2,7 @@ package msgpack
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"testing"
@@ -18,7 +19,7 @@ func TestEncodeArray(t *testing.T) {
 	}
 	testcases := []struct {
 		errorState bool
-		n          int
+		n          int64
 		expect
 		skip bool
 	}{
@@ -49,7 +50,7 @@ func TestEncodeArray(t *testing.T) {
 
 			// ARRANGE
 			if tc.errorState {
-				enc.err = encerr
+				*enc.err = encerr
 			}
 			// we test using a slice of zero-value int's which will pack as single
 			// bytes (fixed positive integer 0-127) enabling the written values to
@@ -75,7 +76,7 @@ func TestEncodeArray(t *testing.T) {
 				if tc.errorState {
 					wanted = 0
 				}
-				got := buf.Len() - len(tc.header)
+				got := int64(buf.Len() - len(tc.header))
 				if wanted != got {
 					t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
 				}
@@ -85,7 +86,7 @@ func TestEncodeArray(t *testing.T) {
 
 	t.Run("when error occurs writing items", func(t *testing.T) {
 		// ARRANGE
-		enc.err = nil
+		*enc.err = nil
 		buf.Reset()
 
 		// ACT
@@ -113,4 +114,103 @@ func TestEncodeArray(t *testing.T) {
 			}
 		})
 	})
+
+	t.Run("when an element is unsupported", func(t *testing.T) {
+		// ARRANGE
+		*enc.err = nil
+		buf.Reset()
+
+		// ASSERT
+		defer func() {
+			r := recover()
+			err, _ := r.(error)
+			testError(t, ErrUnsupportedType, err)
+
+			wanted := "[1]"
+			got := ""
+			var ee *EncodeError
+			if errors.As(err, &ee) {
+				got = ee.Path
+			}
+			if wanted != got {
+				t.Errorf("\nwanted %q\ngot    %q", wanted, got)
+			}
+		}()
+
+		// ACT
+		_ = EncodeArray(enc, []any{1, struct{}{}}, nil)
+	})
+}
+
+func TestEncodeArrayCtx(t *testing.T) {
+	// ARRANGE
+	enc, buf := NewTestEncoder()
+
+	t.Run("when context is already cancelled", func(t *testing.T) {
+		// ARRANGE
+		defer buf.Reset()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// ACT
+		err := EncodeArrayCtx(ctx, enc, []int{1, 2, 3}, nil)
+
+		// ASSERT
+		testError(t, context.Canceled, err)
+
+		t.Run("writes nothing", func(t *testing.T) {
+			wanted := 0
+			got := buf.Len()
+			if wanted != got {
+				t.Errorf("\nwanted %d\ngot    %d", wanted, got)
+			}
+		})
+	})
+
+	t.Run("when context is cancelled while encoding", func(t *testing.T) {
+		// ARRANGE
+		defer buf.Reset()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		s := make([]int, ctxCheckInterval*2)
+		n := 0
+
+		// ACT
+		err := EncodeArrayCtx(ctx, enc, s, func(enc Encoder, v int) error {
+			n++
+			if n == 10 {
+				cancel()
+			}
+			return enc.EncodeInt(v)
+		})
+
+		// ASSERT
+		testError(t, context.Canceled, err)
+
+		t.Run("stops at next check", func(t *testing.T) {
+			wanted := ctxCheckInterval - 1
+			got := n
+			if wanted != got {
+				t.Errorf("\nwanted %d\ngot    %d", wanted, got)
+			}
+		})
+	})
+
+	t.Run("when context is not cancelled", func(t *testing.T) {
+		// ARRANGE
+		defer buf.Reset()
+
+		// ACT
+		err := EncodeArrayCtx(context.Background(), enc, []int{1, 2}, nil)
+
+		// ASSERT
+		testError(t, nil, err)
+
+		wanted := []byte{maskFixArray | 2, 0x01, 0x02}
+		got := buf.Bytes()
+		if !bytes.Equal(wanted, got) {
+			t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+		}
+	})
 }
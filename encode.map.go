@@ -1,5 +1,11 @@
 package msgpack
 
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
 // EncodeMap encodes a map to the current writer.
 //
 // A function may be provided to encode the key and value of each
@@ -7,25 +13,158 @@ package msgpack
 // to encode the key and value using the Encoder.Encode method.
 //
 // If an error is returned from the function, encoding will stop and
-// the error will be returned to the caller.
+// the error will be returned to the caller, wrapped in an EncodeError
+// identifying the entry that could not be encoded.  A panic when
+// encoding an entry (e.g. ErrUnsupportedType) is similarly annotated.
 func EncodeMap[K comparable, V any](enc Encoder, m map[K]V, fn MapEncoder[K, V]) error {
-	if err := enc.WriteMapHeader(len(m)); err != nil {
+	return encodeMap(nil, enc, m, nil, fn)
+}
+
+// EncodeMapCtx encodes a map to the current writer, in the same way
+// as EncodeMap, checking the specified context periodically (and before
+// writing the map header).  If the context is cancelled or its deadline
+// expires, encoding stops and the context error is returned.
+//
+// This enables encoding of very large maps to be interrupted, e.g.
+// when a request times out.  As with any other error, if encoding
+// is stopped after the map header has been written the output
+// will be invalid.
+func EncodeMapCtx[K comparable, V any](ctx context.Context, enc Encoder, m map[K]V, fn MapEncoder[K, V]) error {
+	return encodeMap(ctx.Err, enc, m, nil, fn)
+}
+
+// EncodeMapSorted encodes a map to the current writer in the same way as
+// EncodeMap, except that entries are encoded in ascending key order,
+// giving deterministic output.
+//
+// Sorting the keys requires an additional allocation and is slower
+// than EncodeMap; it should be used only where deterministic output is
+// required (e.g. when the output is hashed or compared).
+func EncodeMapSorted[K Ordered, V any](enc Encoder, m map[K]V, fn MapEncoder[K, V]) error {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	return encodeMap(nil, enc, m, keys, fn)
+}
+
+// encodeMap implements EncodeMap, EncodeMapCtx and EncodeMapSorted.  If
+// cancelled is nil no context checks are made.  If keys is not nil the
+// entries are encoded in the order of the keys specified, otherwise in
+// the (random) order of iteration of the map.
+func encodeMap[K comparable, V any](cancelled func() error, enc Encoder, m map[K]V, keys []K, fn MapEncoder[K, V]) error {
+	if cancelled != nil {
+		if err := cancelled(); err != nil {
+			return err
+		}
+	}
+
+	// when omitting nil values, the keys of entries with a non-nil value
+	// are identified up-front, so that each value is checked only once
+	n := len(m)
+	if enc.omitNil && canBeNil[V]() {
+		if keys == nil {
+			keys = make([]K, 0, len(m))
+			for k, v := range m {
+				if !isNil(v) {
+					keys = append(keys, k)
+				}
+			}
+		} else {
+			nonNil := keys[:0]
+			for _, k := range keys {
+				if !isNil(m[k]) {
+					nonNil = append(nonNil, k)
+				}
+			}
+			keys = nonNil
+		}
+		n = len(keys)
+	}
+
+	if err := enc.WriteMapHeader(n); err != nil {
 		return err
 	}
 
 	if fn == nil {
 		fn = func(enc Encoder, k K, v V) error {
-			_ = enc.Encode(k)
-			return enc.Encode(v)
+			return enc.encodeKeyValue(k, v)
+		}
+	}
+
+	var key K
+	defer annotatePanic(func(err error) error { return atKey(key, err) })
+
+	i := 0
+	entry := func(k K, v V) error {
+		if cancelled != nil && i%ctxCheckInterval == ctxCheckInterval-1 {
+			if err := cancelled(); err != nil {
+				return err
+			}
+		}
+		i++
+
+		key = k
+		if err := fn(enc, k, v); err != nil {
+			return atKey(k, err)
 		}
+		return nil
 	}
 
-	for k, v := range m {
-		if enc.err != nil {
-			return enc.err
+	if keys == nil {
+		for k, v := range m {
+			if err := entry(k, v); err != nil {
+				return err
+			}
 		}
-		enc.err = fn(enc, k, v)
+		return nil
 	}
 
-	return enc.err
+	for _, k := range keys {
+		if err := entry(k, m[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isNil returns true if a value is nil: a nil interface, a nil pointer,
+// map, channel or function, or a nil []byte, []int, []string, []any or
+// MapSlice.  Nil slices of other types are not identified.
+//
+// The data word of an interface (see dataWord) is nil only for a nil
+// value of a type stored directly in the interface: a pointer, map,
+// channel or function, but also a struct or array consisting only of
+// one such value, which is not nil.  A value with a nil data word is
+// therefore identified as nil only if it is also formatted by %p (which
+// is valid only for pointer, map, channel, function and slice types) as
+// a nil pointer; values with a non-nil data word (almost all values) are
+// not formatted.
+func isNil(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case []byte:
+		return v == nil
+	case []int:
+		return v == nil
+	case []string:
+		return v == nil
+	case []any:
+		return v == nil
+	case MapSlice:
+		return v == nil
+	default:
+		return dataWord(v) == nil && fmt.Sprintf("%p", v) == "0x0"
+	}
+}
+
+// canBeNil returns true if values of type V may be nil (as identified by
+// isNil), i.e. if the zero value of V is nil.  Values of other types
+// need not be checked (or converted to an interface to be checked).
+func canBeNil[V any]() bool {
+	var zero V
+	return isNil(zero)
 }
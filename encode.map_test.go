@@ -2,8 +2,10 @@ package msgpack
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -19,7 +21,7 @@ func TestEncodeMap(t *testing.T) {
 
 	testcases := []struct {
 		errorState bool
-		n          int
+		n          int64
 		expect
 		skip bool
 	}{
@@ -50,10 +52,10 @@ func TestEncodeMap(t *testing.T) {
 
 			// ARRANGE
 			if tc.errorState {
-				enc.err = encerr
+				*enc.err = encerr
 			}
 			m := make(map[string]int, tc.n)
-			for i := 0; i < tc.n; i++ {
+			for i := int64(0); i < tc.n; i++ {
 				m[fmt.Sprintf("%.12d", i)] = 0
 			}
 
@@ -76,7 +78,7 @@ func TestEncodeMap(t *testing.T) {
 				if tc.errorState {
 					wanted = 0
 				}
-				got := (buf.Len() - len(tc.header)) / 14 // 14 bytes per entry: 13 for each string key (1 byte header, 12 bytes character data) and 1 byte for a zero-value int value
+				got := int64(buf.Len()-len(tc.header)) / 14 // 14 bytes per entry: 13 for each string key (1 byte header, 12 bytes character data) and 1 byte for a zero-value int value
 				if wanted != got {
 					t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
 				}
@@ -86,7 +88,7 @@ func TestEncodeMap(t *testing.T) {
 
 	t.Run("when error occurs writing items", func(t *testing.T) {
 		// ARRANGE
-		enc.err = nil
+		*enc.err = nil
 		buf.Reset()
 
 		// map ranging order is not guaranteed so in this test we record the first key encoded
@@ -122,4 +124,236 @@ func TestEncodeMap(t *testing.T) {
 		})
 	})
 
+	t.Run("when error occurs writing nested items", func(t *testing.T) {
+		// ARRANGE
+		*enc.err = nil
+		buf.Reset()
+
+		m := map[string][]int{"orders": {1, 2, 3}}
+
+		// ACT
+		err := EncodeMap(enc, m, func(enc Encoder, k string, v []int) error {
+			_ = enc.EncodeString(k)
+			return EncodeArray(enc, v, func(enc Encoder, v int) error {
+				if v == 3 {
+					return encerr
+				}
+				return enc.EncodeInt(v)
+			})
+		})
+
+		// ASSERT
+		testError(t, encerr, err)
+
+		t.Run("identifies path", func(t *testing.T) {
+			wanted := "orders[2]"
+			got := ""
+			var ee *EncodeError
+			if errors.As(err, &ee) {
+				got = ee.Path
+			}
+			if wanted != got {
+				t.Errorf("\nwanted %q\ngot    %q", wanted, got)
+			}
+		})
+	})
+
+	t.Run("when a nested error is wrapped", func(t *testing.T) {
+		// ARRANGE
+		*enc.err = nil
+		buf.Reset()
+
+		m := map[string]map[string]int{"order": {"qty": 1}}
+		var inner error
+
+		// ACT
+		err := EncodeMap(enc, m, func(enc Encoder, k string, v map[string]int) error {
+			_ = enc.EncodeString(k)
+			inner = EncodeMap(enc, v, func(enc Encoder, k string, v int) error { return encerr })
+			return fmt.Errorf("validating order: %w", inner)
+		})
+
+		// ASSERT
+		testError(t, encerr, err)
+
+		t.Run("identifies path", func(t *testing.T) {
+			wanted := "order.qty"
+			got := ""
+			var ee *EncodeError
+			if errors.As(err, &ee) {
+				got = ee.Path
+			}
+			if wanted != got {
+				t.Errorf("\nwanted %q\ngot    %q", wanted, got)
+			}
+		})
+
+		t.Run("retains context", func(t *testing.T) {
+			if !strings.Contains(err.Error(), "validating order") {
+				t.Errorf("\nwanted error including %q\ngot    %q", "validating order", err)
+			}
+		})
+
+		t.Run("does not modify nested error", func(t *testing.T) {
+			wanted := "qty: " + encerr.Error()
+			got := inner.Error()
+			if wanted != got {
+				t.Errorf("\nwanted %q\ngot    %q", wanted, got)
+			}
+		})
+	})
+
+	t.Run("when a nested value is unsupported", func(t *testing.T) {
+		// ARRANGE
+		*enc.err = nil
+		buf.Reset()
+
+		m := map[int]map[string]any{42: {"customer": struct{}{}}}
+
+		// ASSERT
+		defer func() {
+			r := recover()
+			err, _ := r.(error)
+			testError(t, ErrUnsupportedType, err)
+
+			wanted := "[42].customer"
+			got := ""
+			var ee *EncodeError
+			if errors.As(err, &ee) {
+				got = ee.Path
+			}
+			if wanted != got {
+				t.Errorf("\nwanted %q\ngot    %q", wanted, got)
+			}
+		}()
+
+		// ACT
+		_ = EncodeMap(enc, m, func(enc Encoder, k int, v map[string]any) error {
+			_ = enc.EncodeInt(k)
+			return EncodeMap(enc, v, nil)
+		})
+	})
+}
+
+func TestEncodeMapCtx(t *testing.T) {
+	// ARRANGE
+	enc, buf := NewTestEncoder()
+
+	t.Run("when context is already cancelled", func(t *testing.T) {
+		// ARRANGE
+		defer buf.Reset()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// ACT
+		err := EncodeMapCtx(ctx, enc, map[int]int{1: 1}, nil)
+
+		// ASSERT
+		testError(t, context.Canceled, err)
+
+		t.Run("writes nothing", func(t *testing.T) {
+			wanted := 0
+			got := buf.Len()
+			if wanted != got {
+				t.Errorf("\nwanted %d\ngot    %d", wanted, got)
+			}
+		})
+	})
+
+	t.Run("when context is cancelled while encoding", func(t *testing.T) {
+		// ARRANGE
+		defer buf.Reset()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		m := make(map[int]int, ctxCheckInterval*2)
+		for i := 0; i < ctxCheckInterval*2; i++ {
+			m[i] = i
+		}
+		n := 0
+
+		// ACT
+		err := EncodeMapCtx(ctx, enc, m, func(enc Encoder, k, v int) error {
+			n++
+			if n == 10 {
+				cancel()
+			}
+			_ = enc.EncodeInt(k)
+			return enc.EncodeInt(v)
+		})
+
+		// ASSERT
+		testError(t, context.Canceled, err)
+
+		t.Run("stops at next check", func(t *testing.T) {
+			wanted := ctxCheckInterval - 1
+			got := n
+			if wanted != got {
+				t.Errorf("\nwanted %d\ngot    %d", wanted, got)
+			}
+		})
+	})
+
+	t.Run("when context is not cancelled", func(t *testing.T) {
+		// ARRANGE
+		defer buf.Reset()
+
+		// ACT
+		err := EncodeMapCtx(context.Background(), enc, map[int]int{1: 2}, nil)
+
+		// ASSERT
+		testError(t, nil, err)
+
+		wanted := []byte{maskFixMap | 1, 0x01, 0x02}
+		got := buf.Bytes()
+		if !bytes.Equal(wanted, got) {
+			t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+		}
+	})
+}
+
+func TestEncodeMapSorted(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		spec   string
+		fn     func(Encoder) error
+		result []byte
+	}{
+		{spec: "string keys",
+			fn: func(enc Encoder) error {
+				return EncodeMapSorted(enc, map[string]int{"c": 3, "a": 1, "b": 2}, nil)
+			},
+			result: []byte{maskFixMap | 3, maskFixString | 1, 'a', 0x01, maskFixString | 1, 'b', 0x02, maskFixString | 1, 'c', 0x03},
+		},
+		{spec: "int keys",
+			fn: func(enc Encoder) error {
+				return EncodeMapSorted(enc, map[int]bool{2: true, -1: false}, func(enc Encoder, k int, v bool) error {
+					_ = enc.EncodeInt(k)
+					return enc.EncodeBool(v)
+				})
+			},
+			result: []byte{maskFixMap | 2, 0xff, atomFalse, 0x02, atomTrue},
+		},
+		{spec: "empty map",
+			fn:     func(enc Encoder) error { return EncodeMapSorted[string, int](enc, nil, nil) },
+			result: []byte{atomEmptyMap},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			enc, buf := NewTestEncoder()
+
+			// ACT
+			err := tc.fn(enc)
+
+			// ASSERT
+			testError(t, nil, err)
+
+			wanted := tc.result
+			got := buf.Bytes()
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+			}
+		})
+	}
 }
@@ -0,0 +1,126 @@
+package msgpack
+
+// Pair is a key/value pair, an entry of Pairs.
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// Pairs is an ordered map: a slice of key/value pairs encoded as a
+// msgpack map with entries in the order of the slice, for protocols in
+// which the order of map entries is significant (e.g. column ordering).
+//
+// Pairs may be appended to directly, or using Set to replace the value
+// of an existing key.  Keys are not required to be unique, but most
+// consumers will treat a map with duplicate keys as invalid.
+type Pairs[K comparable, V any] []Pair[K, V]
+
+// Get returns the value of the first pair with the specified key, and
+// true, or the zero value and false if there is no pair with that key.
+func (p Pairs[K, V]) Get(k K) (V, bool) {
+	for _, e := range p {
+		if e.Key == k {
+			return e.Value, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// Set sets the value of the first pair with the specified key or, if
+// there is no pair with that key, appends a new pair.
+func (p *Pairs[K, V]) Set(k K, v V) {
+	for i := range *p {
+		if (*p)[i].Key == k {
+			(*p)[i].Value = v
+			return
+		}
+	}
+	*p = append(*p, Pair[K, V]{Key: k, Value: v})
+}
+
+// encodeTo encodes the pairs to an Encoder using EncodePairs, enabling
+// Pairs to be encoded by Encoder.Encode.
+func (p Pairs[K, V]) encodeTo(enc Encoder) error {
+	return EncodePairs(enc, p, nil)
+}
+
+// EncodePairs encodes Pairs to the current writer as a map, with entries
+// in the order of the pairs.
+//
+// A function may be provided to encode the key and value of each pair,
+// as for EncodeMap; if no function is provided (nil) the key and value
+// are encoded using the Encoder.Encode method.  Errors (and panics) are
+// annotated with the key of the entry that could not be encoded, and
+// pairs with a nil value are omitted if the Encoder was created with
+// the OmitNilValues option.
+func EncodePairs[K comparable, V any](enc Encoder, p Pairs[K, V], fn MapEncoder[K, V]) error {
+	return encodeOrdered(enc, len(p), func(i int) (K, V) { return p[i].Key, p[i].Value }, fn)
+}
+
+// MapItem is an entry of a MapSlice.
+type MapItem struct {
+	Key   any
+	Value any
+}
+
+// MapSlice is an ordered map with keys and values of any type supported
+// by Encoder.Encode, in the style of yaml.MapSlice.  It is encoded by
+// Encode as a map with entries in the order of the slice:
+//
+//	enc.Encode(msgpack.MapSlice{{Key: "id", Value: 1}, {Key: "name", Value: "blugnu"}})
+//
+// As for Pairs, entries with a nil value are omitted if the Encoder was
+// created with the OmitNilValues option.
+type MapSlice []MapItem
+
+// encodeTo encodes the MapSlice to an Encoder, enabling a MapSlice to be
+// encoded by Encoder.Encode.
+func (ms MapSlice) encodeTo(enc Encoder) error {
+	return encodeOrdered[any, any](enc, len(ms), func(i int) (any, any) { return ms[i].Key, ms[i].Value }, nil)
+}
+
+// encodeOrdered encodes n entries as a map, in order, obtaining the key
+// and value of each entry from the entry function.  It implements
+// EncodePairs and the encoding of a MapSlice.
+func encodeOrdered[K any, V any](enc Encoder, n int, entry func(int) (K, V), fn func(Encoder, K, V) error) error {
+	// when omitting nil values, the entries with a nil value are
+	// identified up-front, so that each value is checked only once
+	count := n
+	var omit []bool
+	if enc.omitNil && canBeNil[V]() {
+		omit = make([]bool, n)
+		for i := range omit {
+			if _, v := entry(i); isNil(v) {
+				omit[i] = true
+				count--
+			}
+		}
+	}
+
+	if err := enc.WriteMapHeader(count); err != nil {
+		return err
+	}
+
+	if fn == nil {
+		fn = func(enc Encoder, k K, v V) error {
+			return enc.encodeKeyValue(k, v)
+		}
+	}
+
+	var key K
+	defer annotatePanic(func(err error) error { return atKey(key, err) })
+
+	for i := 0; i < n; i++ {
+		if omit != nil && omit[i] {
+			continue
+		}
+		k, v := entry(i)
+
+		key = k
+		if err := fn(enc, k, v); err != nil {
+			return atKey(k, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package msgpack
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestPairs(t *testing.T) {
+	// ARRANGE
+	p := Pairs[string, int]{{Key: "b", Value: 1}}
+
+	// ACT
+	p.Set("a", 2)
+	p.Set("b", 3)
+
+	// ASSERT
+	t.Run("set", func(t *testing.T) {
+		wanted := Pairs[string, int]{{Key: "b", Value: 3}, {Key: "a", Value: 2}}
+		got := p
+		if len(wanted) != len(got) || wanted[0] != got[0] || wanted[1] != got[1] {
+			t.Errorf("\nwanted %v\ngot    %v", wanted, got)
+		}
+	})
+
+	t.Run("get", func(t *testing.T) {
+		if v, ok := p.Get("a"); v != 2 || !ok {
+			t.Errorf("\nwanted 2, true\ngot    %v, %v", v, ok)
+		}
+		if v, ok := p.Get("c"); v != 0 || ok {
+			t.Errorf("\nwanted 0, false\ngot    %v, %v", v, ok)
+		}
+	})
+}
+
+func TestEncodePairs(t *testing.T) {
+	// ARRANGE
+	encerr := errors.New("encoder error")
+	testcases := []struct {
+		spec   string
+		fn     func(Encoder) error
+		result []byte
+		error
+	}{
+		{spec: "empty",
+			fn:     func(enc Encoder) error { return EncodePairs[string, int](enc, nil, nil) },
+			result: []byte{atomEmptyMap},
+		},
+		{spec: "insertion order",
+			fn: func(enc Encoder) error {
+				return EncodePairs(enc, Pairs[string, int]{{"z", 1}, {"a", 2}}, nil)
+			},
+			result: []byte{maskFixMap | 2, maskFixString | 1, 'z', 0x01, maskFixString | 1, 'a', 0x02},
+		},
+		{spec: "using Encode",
+			fn: func(enc Encoder) error {
+				return enc.Encode(Pairs[int, any]{{2, nil}, {1, "x"}})
+			},
+			result: []byte{maskFixMap | 2, 0x02, atomNil, 0x01, maskFixString | 1, 'x'},
+		},
+		{spec: "omit nil values",
+			fn: func(enc Encoder) error {
+				enc.omitNil = true
+				return EncodePairs(enc, Pairs[int, any]{{2, nil}, {1, "x"}}, nil)
+			},
+			result: []byte{maskFixMap | 1, 0x01, maskFixString | 1, 'x'},
+		},
+		{spec: "error",
+			fn: func(enc Encoder) error {
+				return EncodePairs(enc, Pairs[string, int]{{"a", 1}}, func(Encoder, string, int) error { return encerr })
+			},
+			result: []byte{maskFixMap | 1},
+			error:  encerr,
+		},
+		{spec: "unsupported type",
+			fn: func(enc Encoder) (err error) {
+				defer recoverError(&err)
+				return EncodePairs(enc, Pairs[string, any]{{"a", struct{}{}}}, nil)
+			},
+			result: []byte{maskFixMap | 1, maskFixString | 1, 'a'},
+			error:  ErrUnsupportedType,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			enc, buf := NewTestEncoder()
+
+			// ACT
+			err := tc.fn(enc)
+
+			// ASSERT
+			testError(t, tc.error, err)
+
+			var ee *EncodeError
+			if tc.error != nil && (!errors.As(err, &ee) || ee.Path != "a") {
+				t.Errorf("\nwanted EncodeError with path %q\ngot    %#v", "a", err)
+			}
+
+			wanted := tc.result
+			got := buf.Bytes()
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+			}
+		})
+	}
+}
+
+func TestEncodeMapSlice(t *testing.T) {
+	// ARRANGE
+	enc, buf := NewTestEncoder()
+	ms := MapSlice{
+		{Key: "b", Value: 1},
+		{Key: 2, Value: MapSlice{{Key: "x", Value: true}}},
+	}
+
+	// ACT
+	err := enc.Encode(ms)
+
+	// ASSERT
+	testError(t, nil, err)
+
+	wanted := []byte{maskFixMap | 2, maskFixString | 1, 'b', 0x01, 0x02, maskFixMap | 1, maskFixString | 1, 'x', atomTrue}
+	got := buf.Bytes()
+	if !bytes.Equal(wanted, got) {
+		t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+	}
+}
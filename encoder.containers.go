@@ -0,0 +1,193 @@
+package msgpack
+
+import (
+	"fmt"
+	"strings"
+)
+
+// container records the number of values declared and written for an
+// array or map when container tracking is enabled.  For a map, the
+// number of values is twice the number of entries (a key and a value
+// for each entry).
+type container struct {
+	isMap    bool
+	declared int64
+	written  int64
+	scoped   bool // true if the container was opened by WithArray/WithMap
+}
+
+// containers is a stack of open containers maintained by an Encoder
+// when container tracking is enabled.
+type containers struct {
+	stack []container
+}
+
+// value records a value written to the current (innermost) container,
+// closing any containers that are complete as a result.
+func (c *containers) value() {
+	if len(c.stack) == 0 {
+		return
+	}
+	c.stack[len(c.stack)-1].written++
+	c.close()
+}
+
+// open records a value written to the current container (the new
+// container is itself a value) and then opens a new container with
+// the specified number of entries.
+func (c *containers) open(n int64, isMap bool, scoped bool) {
+	if len(c.stack) > 0 {
+		c.stack[len(c.stack)-1].written++
+	}
+
+	declared := n
+	if isMap {
+		declared *= 2
+	}
+	c.stack = append(c.stack, container{isMap: isMap, declared: declared, scoped: scoped})
+	c.close()
+}
+
+// close removes any complete containers from the top of the stack.
+// Scoped containers are not removed; these are closed explicitly at
+// the end of the scope.
+func (c *containers) close() {
+	for len(c.stack) > 0 {
+		top := c.stack[len(c.stack)-1]
+		if top.scoped || top.written < top.declared {
+			return
+		}
+		c.stack = c.stack[:len(c.stack)-1]
+	}
+}
+
+// ContainerState describes an open array or map when container
+// tracking is enabled.  Declared and Written are numbers of values; for
+// a map this is twice the number of entries (a key and a value for each
+// entry).
+type ContainerState struct {
+	IsMap    bool
+	Declared int64
+	Written  int64
+}
+
+// String returns a description of the container, e.g. "map[3/4]",
+// giving the number of values written and declared.
+func (c ContainerState) String() string {
+	kind := "array"
+	if c.IsMap {
+		kind = "map"
+	}
+	return fmt.Sprintf("%s[%d/%d]", kind, c.Written, c.Declared)
+}
+
+// snapshot returns the state of the open containers, outermost first.
+func (c *containers) snapshot() []ContainerState {
+	result := make([]ContainerState, len(c.stack))
+	for i, ct := range c.stack {
+		result[i] = ContainerState{IsMap: ct.isMap, Declared: ct.declared, Written: ct.written}
+	}
+	return result
+}
+
+// OpenContainers returns a snapshot of the arrays and maps that are
+// currently open (outermost first), with the number of values declared
+// and written for each, to pinpoint where a hand-written encoder lost
+// count.  If container tracking is not enabled the result is nil.
+func (enc Encoder) OpenContainers() []ContainerState {
+	if enc.containers == nil {
+		return nil
+	}
+	return enc.containers.snapshot()
+}
+
+// ContainerError is returned by WithArray and WithMap when the number
+// of values written to a container does not match the number declared,
+// attaching a snapshot of the open containers at the time the mismatch
+// was detected (outermost first).
+type ContainerError struct {
+	Containers []ContainerState
+	Err        error
+}
+
+// Error implements the error interface.
+func (e *ContainerError) Error() string {
+	s := make([]string, len(e.Containers))
+	for i, c := range e.Containers {
+		s[i] = c.String()
+	}
+	return fmt.Sprintf("%v (open containers: %s)", e.Err, strings.Join(s, " > "))
+}
+
+// Unwrap returns the error describing the mismatch.
+func (e *ContainerError) Unwrap() error {
+	return e.Err
+}
+
+// WithArray writes an array header for n elements and then calls the
+// specified function to write the elements of the array.
+//
+// If container tracking is enabled (see TrackContainers), WithArray
+// verifies that exactly n elements were written by the function,
+// returning ErrContainerMismatch if not.
+func (enc Encoder) WithArray(n int, fn func(Encoder) error) error {
+	return enc.with("WithArray", n, false, fn)
+}
+
+// WithMap writes a map header for n entries and then calls the
+// specified function to write the entries of the map (a key and
+// a value for each entry).
+//
+// If container tracking is enabled (see TrackContainers), WithMap
+// verifies that exactly n entries were written by the function,
+// returning ErrContainerMismatch if not.
+func (enc Encoder) WithMap(n int, fn func(Encoder) error) error {
+	return enc.with("WithMap", n, true, fn)
+}
+
+// with implements WithArray and WithMap.
+func (enc Encoder) with(name string, n int, isMap bool, fn func(Encoder) error) error {
+	// if tracking is enabled the header is written with tracking
+	// suspended; the container is then opened as a scoped container
+	c := enc.containers
+	enc.containers = nil
+
+	var err error
+	if isMap {
+		err = enc.WriteMapHeader(n)
+	} else {
+		err = enc.WriteArrayHeader(n)
+	}
+	enc.containers = c
+
+	switch {
+	case err != nil:
+		return err
+	case c == nil:
+		return fn(enc)
+	}
+
+	depth := len(c.stack)
+	c.open(int64(n), isMap, true)
+
+	if err := fn(enc); err != nil {
+		c.stack = c.stack[:depth]
+		return err
+	}
+
+	switch top := c.stack[len(c.stack)-1]; {
+	case !top.scoped:
+		err = fmt.Errorf("%s: %w: nested container is incomplete (expected %d values, wrote %d)", name, ErrContainerMismatch, top.declared, top.written)
+	case top.written != top.declared:
+		err = fmt.Errorf("%s: %w: expected %d values, wrote %d", name, ErrContainerMismatch, top.declared, top.written)
+	}
+
+	if err != nil {
+		err = &ContainerError{Containers: c.snapshot(), Err: err}
+	}
+
+	c.stack = c.stack[:depth]
+	c.close()
+
+	return err
+}
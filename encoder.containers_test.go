@@ -0,0 +1,227 @@
+package msgpack
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEncoderWithContainers(t *testing.T) {
+	// ARRANGE
+	encerr := errors.New("encoder error")
+
+	type expect struct {
+		result []byte
+		error
+	}
+	testcases := []struct {
+		spec       string // for information only, not part of the test
+		errorState bool   // true if the test case runs with the encoder in an error state
+		tracking   bool   // true if the test case runs with container tracking enabled
+		fn         func(Encoder) error
+		expect
+	}{
+		{spec: "WithArray(2), 2 elements",
+			fn: func(enc Encoder) error {
+				return enc.WithArray(2, func(enc Encoder) error {
+					_ = enc.EncodeInt(1)
+					return enc.EncodeInt(2)
+				})
+			},
+			expect: expect{result: []byte{maskFixArray | 2, 0x01, 0x02}},
+		},
+		{spec: "WithArray(2), 1 element (untracked)",
+			fn: func(enc Encoder) error {
+				return enc.WithArray(2, func(enc Encoder) error { return enc.EncodeInt(1) })
+			},
+			expect: expect{result: []byte{maskFixArray | 2, 0x01}},
+		},
+		{spec: "WithArray(2), 1 element (tracked)", tracking: true,
+			fn: func(enc Encoder) error {
+				return enc.WithArray(2, func(enc Encoder) error { return enc.EncodeInt(1) })
+			},
+			expect: expect{result: []byte{maskFixArray | 2, 0x01}, error: ErrContainerMismatch},
+		},
+		{spec: "WithArray(1), 2 elements (tracked)", tracking: true,
+			fn: func(enc Encoder) error {
+				return enc.WithArray(1, func(enc Encoder) error {
+					_ = enc.EncodeInt(1)
+					return enc.EncodeInt(2)
+				})
+			},
+			expect: expect{result: []byte{maskFixArray | 1, 0x01, 0x02}, error: ErrContainerMismatch},
+		},
+		{spec: "WithArray(0), 0 elements (tracked)", tracking: true,
+			fn: func(enc Encoder) error {
+				return enc.WithArray(0, func(enc Encoder) error { return nil })
+			},
+			expect: expect{result: []byte{atomEmptyArray}},
+		},
+		{spec: "WithMap(2), 2 entries (tracked)", tracking: true,
+			fn: func(enc Encoder) error {
+				return enc.WithMap(2, func(enc Encoder) error {
+					_ = enc.EncodeIntField("a", 1)
+					return enc.EncodeIntField("b", 2)
+				})
+			},
+			expect: expect{result: []byte{maskFixMap | 2, maskFixString | 1, 'a', 0x01, maskFixString | 1, 'b', 0x02}},
+		},
+		{spec: "WithMap(1), key with no value (tracked)", tracking: true,
+			fn: func(enc Encoder) error {
+				return enc.WithMap(1, func(enc Encoder) error { return enc.EncodeString("a") })
+			},
+			expect: expect{result: []byte{maskFixMap | 1, maskFixString | 1, 'a'}, error: ErrContainerMismatch},
+		},
+		{spec: "WithMap(1), nested WithArray(2) (tracked)", tracking: true,
+			fn: func(enc Encoder) error {
+				return enc.WithMap(1, func(enc Encoder) error {
+					_ = enc.EncodeString("a")
+					return enc.WithArray(2, func(enc Encoder) error {
+						_ = enc.EncodeInt(1)
+						return enc.EncodeInt(2)
+					})
+				})
+			},
+			expect: expect{result: []byte{maskFixMap | 1, maskFixString | 1, 'a', maskFixArray | 2, 0x01, 0x02}},
+		},
+		{spec: "WithMap(1), nested EncodeArray (tracked)", tracking: true,
+			fn: func(enc Encoder) error {
+				return enc.WithMap(1, func(enc Encoder) error {
+					_ = enc.EncodeString("a")
+					return EncodeArray(enc, []int{1, 2}, nil)
+				})
+			},
+			expect: expect{result: []byte{maskFixMap | 1, maskFixString | 1, 'a', maskFixArray | 2, 0x01, 0x02}},
+		},
+		{spec: "WithMap(1), nested incomplete array (tracked)", tracking: true,
+			fn: func(enc Encoder) error {
+				return enc.WithMap(1, func(enc Encoder) error {
+					_ = enc.EncodeString("a")
+					_ = enc.WriteArrayHeader(2)
+					return enc.EncodeInt(1)
+				})
+			},
+			expect: expect{result: []byte{maskFixMap | 1, maskFixString | 1, 'a', maskFixArray | 2, 0x01}, error: ErrContainerMismatch},
+		},
+		{spec: "WithMap(1), function error (tracked)", tracking: true,
+			fn: func(enc Encoder) error {
+				return enc.WithMap(1, func(enc Encoder) error { return encerr })
+			},
+			expect: expect{result: []byte{maskFixMap | 1}, error: encerr},
+		},
+		{spec: "WithMap(1) (error)", errorState: true,
+			fn: func(enc Encoder) error {
+				return enc.WithMap(1, func(enc Encoder) error { panic("function called") })
+			},
+			expect: expect{error: encerr},
+		},
+		{spec: "WithMap(1) (tracked, error)", errorState: true, tracking: true,
+			fn: func(enc Encoder) error {
+				return enc.WithMap(1, func(enc Encoder) error { panic("function called") })
+			},
+			expect: expect{error: encerr},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			// ARRANGE
+			buf := &bytes.Buffer{}
+			opts := []EncoderOption{}
+			if tc.tracking {
+				opts = append(opts, TrackContainers())
+			}
+			enc := NewEncoder(buf, opts...)
+			if tc.errorState {
+				*enc.err = encerr
+			}
+
+			// ACT
+			err := tc.fn(enc)
+
+			// ASSERT
+			testError(t, tc.expect.error, err)
+
+			t.Run("result", func(t *testing.T) {
+				wanted := tc.result
+				got := buf.Bytes()
+				if !bytes.Equal(wanted, got) {
+					t.Errorf("\nwanted: %x\ngot:    %x", wanted, got)
+				}
+			})
+
+			if tc.tracking {
+				t.Run("open containers", func(t *testing.T) {
+					wanted := 0
+					got := len(enc.containers.stack)
+					if wanted != got {
+						t.Errorf("\nwanted %d\ngot    %d", wanted, got)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestEncoderOpenContainers(t *testing.T) {
+	t.Run("when tracking is not enabled", func(t *testing.T) {
+		// ARRANGE
+		enc, _ := NewTestEncoder()
+		_ = enc.WriteArrayHeader(2)
+
+		// ACT
+		got := enc.OpenContainers()
+
+		// ASSERT
+		if got != nil {
+			t.Errorf("\nwanted nil\ngot    %#v", got)
+		}
+	})
+
+	t.Run("when tracking is enabled", func(t *testing.T) {
+		// ARRANGE
+		enc := NewEncoder(&bytes.Buffer{}, TrackContainers())
+		_ = enc.WriteMapHeader(2)
+		_ = enc.EncodeString("a")
+		_ = enc.WriteArrayHeader(3)
+		_ = enc.EncodeInt(1)
+
+		// ACT
+		got := enc.OpenContainers()
+
+		// ASSERT
+		wanted := []ContainerState{
+			{IsMap: true, Declared: 4, Written: 2},
+			{Declared: 3, Written: 1},
+		}
+		if !reflect.DeepEqual(wanted, got) {
+			t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+		}
+	})
+
+	t.Run("attached to mismatch errors", func(t *testing.T) {
+		// ARRANGE
+		enc := NewEncoder(&bytes.Buffer{}, TrackContainers())
+		_ = enc.WriteArrayHeader(2)
+
+		// ACT
+		err := enc.WithMap(1, func(enc Encoder) error { return enc.EncodeString("a") })
+
+		// ASSERT
+		testError(t, ErrContainerMismatch, err)
+
+		wanted := "WithMap: container mismatch: expected 2 values, wrote 1 (open containers: array[1/2] > map[1/2])"
+		got := err.Error()
+		if wanted != got {
+			t.Errorf("\nwanted %q\ngot    %q", wanted, got)
+		}
+
+		t.Run("containers are closed", func(t *testing.T) {
+			wanted := []ContainerState{{Declared: 2, Written: 1}}
+			got := enc.OpenContainers()
+			if !reflect.DeepEqual(wanted, got) {
+				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+			}
+		})
+	})
+}
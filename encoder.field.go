@@ -0,0 +1,174 @@
+package msgpack
+
+// EncodeField encodes a map entry with a string key and a value of
+// any type supported by the Encode method.
+//
+// The field helpers are intended for use in hand-written encoders
+// for structs (or other types that are encoded as a map) where each
+// field is typically written as a key followed by a value:
+//
+//	_ = enc.WriteMapHeader(2)
+//	_ = enc.EncodeStringField("name", p.Name)
+//	_ = enc.EncodeIntField("age", p.Age)
+//
+// If the key cannot be written, the value is not written.
+//
+// If the Encoder has a ValueHook, the hook is called with the key and
+// value and the value returned by the hook is encoded.  This applies to
+// the typed field helpers (EncodeStringField etc) as well as EncodeField.
+func (enc Encoder) EncodeField(key string, v any) error {
+	if err := enc.EncodeString(key); err != nil {
+		return err
+	}
+	if enc.hook != nil {
+		v = enc.hook(key, v)
+	}
+	return enc.encode(v)
+}
+
+// encodeKeyValue encodes a map entry with a key and value of any type
+// supported by the Encode method.  Any ValueHook is called with the value
+// (and the key, if it is a string); the key itself is not passed to the
+// hook.
+func (enc Encoder) encodeKeyValue(k, v any) error {
+	if err := enc.encode(k); err != nil {
+		return err
+	}
+	if enc.hook != nil {
+		key, _ := k.(string)
+		v = enc.hook(key, v)
+	}
+	return enc.encode(v)
+}
+
+// EncodeBoolField encodes a map entry with a string key and a bool value.
+func (enc Encoder) EncodeBoolField(key string, b bool) error {
+	if enc.hook != nil {
+		return enc.EncodeField(key, b)
+	}
+	if err := enc.EncodeString(key); err != nil {
+		return err
+	}
+	return enc.EncodeBool(b)
+}
+
+// EncodeBytesField encodes a map entry with a string key and a []byte
+// value encoded as binary data.
+func (enc Encoder) EncodeBytesField(key string, b []byte) error {
+	if enc.hook != nil {
+		return enc.EncodeField(key, b)
+	}
+	if err := enc.EncodeString(key); err != nil {
+		return err
+	}
+	return enc.EncodeBytes(b)
+}
+
+// EncodeFloat32Field encodes a map entry with a string key and a float32 value.
+func (enc Encoder) EncodeFloat32Field(key string, f float32) error {
+	if enc.hook != nil {
+		return enc.EncodeField(key, f)
+	}
+	if err := enc.EncodeString(key); err != nil {
+		return err
+	}
+	return enc.EncodeFloat32(f)
+}
+
+// EncodeFloat64Field encodes a map entry with a string key and a float64 value.
+func (enc Encoder) EncodeFloat64Field(key string, f float64) error {
+	if enc.hook != nil {
+		return enc.EncodeField(key, f)
+	}
+	if err := enc.EncodeString(key); err != nil {
+		return err
+	}
+	return enc.EncodeFloat64(f)
+}
+
+// EncodeIntField encodes a map entry with a string key and an int value.
+func (enc Encoder) EncodeIntField(key string, i int) error {
+	if enc.hook != nil {
+		return enc.EncodeField(key, i)
+	}
+	if err := enc.EncodeString(key); err != nil {
+		return err
+	}
+	return enc.EncodeInt(i)
+}
+
+// EncodeInt64Field encodes a map entry with a string key and an int64 value.
+func (enc Encoder) EncodeInt64Field(key string, i int64) error {
+	if enc.hook != nil {
+		return enc.EncodeField(key, i)
+	}
+	if err := enc.EncodeString(key); err != nil {
+		return err
+	}
+	return enc.EncodeInt64(i)
+}
+
+// EncodeUintField encodes a map entry with a string key and a uint value.
+func (enc Encoder) EncodeUintField(key string, i uint) error {
+	if enc.hook != nil {
+		return enc.EncodeField(key, i)
+	}
+	if err := enc.EncodeString(key); err != nil {
+		return err
+	}
+	return enc.EncodeUint(i)
+}
+
+// EncodeUint64Field encodes a map entry with a string key and a uint64 value.
+func (enc Encoder) EncodeUint64Field(key string, i uint64) error {
+	if enc.hook != nil {
+		return enc.EncodeField(key, i)
+	}
+	if err := enc.EncodeString(key); err != nil {
+		return err
+	}
+	return enc.EncodeUint64(i)
+}
+
+// EncodeStringField encodes a map entry with a string key and a string value.
+func (enc Encoder) EncodeStringField(key string, s string) error {
+	if enc.hook != nil {
+		return enc.EncodeField(key, s)
+	}
+	if err := enc.EncodeString(key); err != nil {
+		return err
+	}
+	return enc.EncodeString(s)
+}
+
+// Field is a named value, returned by the MsgpackFields method of a
+// FieldProvider.
+type Field struct {
+	Name  string
+	Value any
+}
+
+// FieldProvider is implemented by types that provide the fields to be
+// encoded for a value, allowing types with unexported state to be
+// encoded by Encode without exporting that state or writing a
+// hand-written encoder:
+//
+//	func (a account) MsgpackFields() []msgpack.Field {
+//		return []msgpack.Field{{Name: "id", Value: a.id}, {Name: "balance", Value: a.balance}}
+//	}
+//
+// The value is encoded as a map with an entry for each field, in order,
+// each value encoded using the Encode method.  A nil pointer (to a type
+// implementing FieldProvider) is encoded as nil.
+type FieldProvider interface {
+	MsgpackFields() []Field
+}
+
+// encodeFields encodes the fields of a FieldProvider as a map.
+func encodeFields(enc Encoder, fp FieldProvider) error {
+	if isNil(fp) {
+		return enc.EncodeNil()
+	}
+	fields := fp.MsgpackFields()
+	return encodeOrdered[string, any](enc, len(fields), func(i int) (string, any) { return fields[i].Name, fields[i].Value }, nil)
+}
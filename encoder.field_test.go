@@ -0,0 +1,124 @@
+package msgpack
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestEncoderFields(t *testing.T) {
+	// ARRANGE
+	enc, buf := NewTestEncoder()
+	encerr := errors.New("encoder error")
+
+	type expect struct {
+		result []byte
+		error
+	}
+
+	key := []byte{maskFixString | 1, 'k'}
+	testcases := []struct {
+		spec       string // for information only, not part of the test
+		errorState bool   // true if the test case runs with the encoder in an error state
+		fn         func() error
+		expect
+	}{
+		{spec: "EncodeField(k, 1)", fn: func() error { return enc.EncodeField("k", 1) }, expect: expect{result: append(key, 0x01)}},
+		{spec: "EncodeField(k, nil)", fn: func() error { return enc.EncodeField("k", nil) }, expect: expect{result: append(key, atomNil)}},
+		{spec: "EncodeBoolField(k, true)", fn: func() error { return enc.EncodeBoolField("k", true) }, expect: expect{result: append(key, atomTrue)}},
+		{spec: "EncodeBytesField(k, []byte{1})", fn: func() error { return enc.EncodeBytesField("k", []byte{1}) }, expect: expect{result: append(key, typeBin8, 0x01, 0x01)}},
+		{spec: "EncodeFloat32Field(k, 1)", fn: func() error { return enc.EncodeFloat32Field("k", 1) }, expect: expect{result: append(key, typeFloat32, 0x3f, 0x80, 0x00, 0x00)}},
+		{spec: "EncodeFloat64Field(k, 1)", fn: func() error { return enc.EncodeFloat64Field("k", 1) }, expect: expect{result: append(key, typeFloat64, 0x3f, 0xf0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00)}},
+		{spec: "EncodeIntField(k, -1)", fn: func() error { return enc.EncodeIntField("k", -1) }, expect: expect{result: append(key, 0xff)}},
+		{spec: "EncodeInt64Field(k, -33)", fn: func() error { return enc.EncodeInt64Field("k", -33) }, expect: expect{result: append(key, typeInt8, 0xdf)}},
+		{spec: "EncodeUintField(k, 255)", fn: func() error { return enc.EncodeUintField("k", 255) }, expect: expect{result: append(key, typeUint8, 0xff)}},
+		{spec: "EncodeUint64Field(k, 1)", fn: func() error { return enc.EncodeUint64Field("k", 1) }, expect: expect{result: append(key, 0x01)}},
+		{spec: "EncodeStringField(k, v)", fn: func() error { return enc.EncodeStringField("k", "v") }, expect: expect{result: append(key, maskFixString|1, 'v')}},
+		{spec: "EncodeField(k, 1) (error)", errorState: true, fn: func() error { return enc.EncodeField("k", 1) }, expect: expect{error: encerr}},
+		{spec: "EncodeBoolField(k, true) (error)", errorState: true, fn: func() error { return enc.EncodeBoolField("k", true) }, expect: expect{error: encerr}},
+		{spec: "EncodeBytesField(k, []byte{1}) (error)", errorState: true, fn: func() error { return enc.EncodeBytesField("k", []byte{1}) }, expect: expect{error: encerr}},
+		{spec: "EncodeFloat32Field(k, 1) (error)", errorState: true, fn: func() error { return enc.EncodeFloat32Field("k", 1) }, expect: expect{error: encerr}},
+		{spec: "EncodeFloat64Field(k, 1) (error)", errorState: true, fn: func() error { return enc.EncodeFloat64Field("k", 1) }, expect: expect{error: encerr}},
+		{spec: "EncodeIntField(k, -1) (error)", errorState: true, fn: func() error { return enc.EncodeIntField("k", -1) }, expect: expect{error: encerr}},
+		{spec: "EncodeInt64Field(k, -33) (error)", errorState: true, fn: func() error { return enc.EncodeInt64Field("k", -33) }, expect: expect{error: encerr}},
+		{spec: "EncodeUintField(k, 255) (error)", errorState: true, fn: func() error { return enc.EncodeUintField("k", 255) }, expect: expect{error: encerr}},
+		{spec: "EncodeUint64Field(k, 1) (error)", errorState: true, fn: func() error { return enc.EncodeUint64Field("k", 1) }, expect: expect{error: encerr}},
+		{spec: "EncodeStringField(k, v) (error)", errorState: true, fn: func() error { return enc.EncodeStringField("k", "v") }, expect: expect{error: encerr}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			defer buf.Reset()
+			defer func() { _ = enc.ResetError() }()
+
+			// ARRANGE
+			if tc.errorState {
+				*enc.err = encerr
+			}
+
+			// ACT
+			err := tc.fn()
+
+			// ASSERT
+			testError(t, tc.expect.error, err)
+
+			t.Run("result", func(t *testing.T) {
+				wanted := tc.result
+				got := buf.Bytes()
+				if !bytes.Equal(wanted, got) {
+					t.Errorf("\nwanted: %x\ngot:    %x", wanted, got)
+				}
+			})
+		})
+	}
+}
+
+// account is a type with unexported state, implementing FieldProvider.
+type account struct {
+	id      int
+	balance any
+}
+
+func (a *account) MsgpackFields() []Field {
+	return []Field{{Name: "id", Value: a.id}, {Name: "balance", Value: a.balance}}
+}
+
+func TestEncodeFieldProvider(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		spec   string
+		value  any
+		result []byte
+		error
+	}{
+		{spec: "fields",
+			value:  &account{id: 1, balance: 2.5},
+			result: []byte{maskFixMap | 2, maskFixString | 2, 'i', 'd', 0x01, maskFixString | 7, 'b', 'a', 'l', 'a', 'n', 'c', 'e', typeFloat64, 0x40, 0x04, 0, 0, 0, 0, 0, 0},
+		},
+		{spec: "nil pointer", value: (*account)(nil), result: []byte{atomNil}},
+		{spec: "unsupported field",
+			value:  &account{id: 1, balance: struct{}{}},
+			result: []byte{maskFixMap | 2, maskFixString | 2, 'i', 'd', 0x01, maskFixString | 7, 'b', 'a', 'l', 'a', 'n', 'c', 'e'},
+			error:  ErrUnsupportedType,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			enc, buf := NewTestEncoder()
+
+			// ACT
+			err := func() (err error) {
+				defer recoverError(&err)
+				return enc.Encode(tc.value)
+			}()
+
+			// ASSERT
+			testError(t, tc.error, err)
+
+			wanted := tc.result
+			got := buf.Bytes()
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+			}
+		})
+	}
+}
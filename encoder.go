@@ -1,25 +1,72 @@
 package msgpack
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"math"
+	"time"
 )
 
 // Encoder provides an api for streaming msgpack data.  To obtain an
 // Encoder use NewEncoder, specifying an initial io.Writer.  The
 // Writer can be changed at any time using SetWriter.
 //
+// The zero value of an Encoder, with no options, is ready to use once an
+// io.Writer has been set using SetWriter; until then any attempt to
+// write fails with an error wrapping ErrNilWriter.
+//
 // The Encoder type is not safe for concurrent use.
 type Encoder struct {
-	out io.Writer
-	err error
+	out        io.Writer
+	err        *error // the error state (see ResetError), shared by copies of the Encoder; nil only for a zero value Encoder with no io.Writer
+	count      *counters
+	containers *containers
+	stats      *stats
+	trace      *tracer
+	scratch    *[16]byte                   // buffer for values written by Write, avoiding an allocation per value
+	oldSpec    bool                        // true if only formats in the old msgpack spec may be used
+	omitNil    bool                        // true if map entries with a nil value are omitted by EncodeMap
+	unsafeStr  bool                        // true if strings are written without copying (see UnsafeStrings)
+	hook       func(key string, v any) any // called with each value encoded by Encode (see ValueHook)
+
+	spoolThreshold int64 // size above which WithSequence spools to a temporary file (0 = never)
+}
+
+// valueEncoder is implemented by types provided by the package that
+// are encoded by Encode (e.g. Pairs).
+type valueEncoder interface {
+	encodeTo(Encoder) error
 }
 
-// NewEncoder returns a neenc Encoder that writes to the specified
-// io.Writer.
-func NewEncoder(out io.Writer) Encoder {
-	return Encoder{out: out}
+// counters records the number of bytes written by an Encoder.
+type counters struct {
+	value    int   // bytes written for the value currently being encoded
+	total    int64 // bytes written since the Encoder was created (or ResetSize was called)
+	limit    int64 // maximum number of bytes that may be written (0 = no limit)
+	exceeded bool  // true if an attempt was made to exceed the limit
+}
+
+// NewEncoder returns a new Encoder that writes to the specified
+// io.Writer, configured with any options specified.
+//
+// Any default options (see SetDefaultOptions) are applied before the
+// options specified.
+func NewEncoder(out io.Writer, opts ...EncoderOption) Encoder {
+	enc := newEncoder(out)
+	for _, opt := range DefaultOptions() {
+		opt(&enc)
+	}
+	for _, opt := range opts {
+		opt(&enc)
+	}
+	return enc
+}
+
+// newEncoder returns a new Encoder that writes to the specified
+// io.Writer, with no options applied.
+func newEncoder(out io.Writer) Encoder {
+	return Encoder{out: out, err: new(error), count: &counters{}, scratch: &[16]byte{}}
 }
 
 // WriteArrayHeader writes the msgpack type and length of an array to the
@@ -32,20 +79,43 @@ func NewEncoder(out io.Writer) Encoder {
 // the array elements.
 //
 // The EncodeArray method is usually more appropriate for encoding an array.
+//
+// Nothing is written if the length is negative (ErrValueOutOfRange) or
+// exceeds the msgpack limit of 2^32-1 elements (ErrTooLarge).
 func (enc Encoder) WriteArrayHeader(len int) error {
+	if err := checkLength("WriteArrayHeader", int64(len)); err != nil {
+		return err
+	}
+	return enc.writeArrayHeader(int64(len))
+}
+
+// WriteArrayHeader64 writes the msgpack type and length of an array to
+// the current writer, as for WriteArrayHeader, accepting a 64-bit length
+// (for 32-bit platforms, where an int is limited to 2^31-1).
+//
+// Nothing is written if the length is negative (ErrValueOutOfRange) or
+// exceeds the msgpack limit of 2^32-1 elements (ErrTooLarge).
+func (enc Encoder) WriteArrayHeader64(n int64) error {
+	if err := checkLength("WriteArrayHeader64", n); err != nil {
+		return err
+	}
+	return enc.writeArrayHeader(n)
+}
+
+// writeArrayHeader writes the header of an array with n elements.
+func (enc Encoder) writeArrayHeader(n int64) error {
+	enc.open(n, false)
+
 	switch {
-	case len == 0:
-		_ = enc.Write(atomEmptyArray)
-	case len < 16:
-		_ = enc.Write(maskFixArray | byte(len))
-	case len < 65536:
-		_ = enc.Write(typeArray16)
-		_ = enc.Write(uint16(len))
+	case n == 0:
+		return enc.Write(atomEmptyArray)
+	case n < 16:
+		return enc.Write(maskFixArray | byte(n))
+	case n < 65536:
+		return enc.writeType16(typeArray16, uint16(n))
 	default:
-		_ = enc.Write(typeArray32)
-		_ = enc.Write(uint32(len))
+		return enc.writeType32(typeArray32, uint32(n))
 	}
-	return enc.err
 }
 
 // WriteMapHeader writes the msgpack type and length of a map to the
@@ -58,20 +128,43 @@ func (enc Encoder) WriteArrayHeader(len int) error {
 // the map entries.
 //
 // The EncodeMap method is usually more appropriate for encoding a map.
+//
+// Nothing is written if the length is negative (ErrValueOutOfRange) or
+// exceeds the msgpack limit of 2^32-1 entries (ErrTooLarge).
 func (enc Encoder) WriteMapHeader(n int) error {
+	if err := checkLength("WriteMapHeader", int64(n)); err != nil {
+		return err
+	}
+	return enc.writeMapHeader(int64(n))
+}
+
+// WriteMapHeader64 writes the msgpack type and length of a map to the
+// current writer, as for WriteMapHeader, accepting a 64-bit length (for
+// 32-bit platforms, where an int is limited to 2^31-1).
+//
+// Nothing is written if the length is negative (ErrValueOutOfRange) or
+// exceeds the msgpack limit of 2^32-1 entries (ErrTooLarge).
+func (enc Encoder) WriteMapHeader64(n int64) error {
+	if err := checkLength("WriteMapHeader64", n); err != nil {
+		return err
+	}
+	return enc.writeMapHeader(n)
+}
+
+// writeMapHeader writes the header of a map with n entries.
+func (enc Encoder) writeMapHeader(n int64) error {
+	enc.open(n, true)
+
 	switch {
 	case n == 0:
-		_ = enc.Write(atomEmptyMap)
+		return enc.Write(atomEmptyMap)
 	case n < 16:
-		_ = enc.Write(maskFixMap | byte(n))
+		return enc.Write(maskFixMap | byte(n))
 	case n < 65536:
-		_ = enc.Write(typeMap16)
-		_ = enc.Write(uint16(n))
+		return enc.writeType16(typeMap16, uint16(n))
 	default:
-		_ = enc.Write(typeMap32)
-		_ = enc.Write(uint32(n))
+		return enc.writeType32(typeMap32, uint32(n))
 	}
-	return enc.err
 }
 
 // WriteStringHeader writes the msgpack type and length of a string to the
@@ -86,21 +179,57 @@ func (enc Encoder) WriteMapHeader(n int) error {
 // the bytes corresponding to the string content.
 //
 // The EncodeString method is usually more appropriate for encoding a string.
+//
+// Nothing is written if the length is negative (ErrValueOutOfRange) or
+// exceeds the msgpack limit of 2^32-1 bytes (ErrTooLarge).
 func (enc Encoder) WriteStringHeader(len int) error {
+	if err := checkLength("WriteStringHeader", int64(len)); err != nil {
+		return err
+	}
+	return enc.writeStringHeader(int64(len))
+}
+
+// WriteStringHeader64 writes the msgpack type and length of a string to
+// the current writer, as for WriteStringHeader, accepting a 64-bit length
+// (for 32-bit platforms, where an int is limited to 2^31-1).
+//
+// Nothing is written if the length is negative (ErrValueOutOfRange) or
+// exceeds the msgpack limit of 2^32-1 bytes (ErrTooLarge).
+func (enc Encoder) WriteStringHeader64(n int64) error {
+	if err := checkLength("WriteStringHeader64", n); err != nil {
+		return err
+	}
+	return enc.writeStringHeader(n)
+}
+
+// writeStringHeader writes the header of a string of n bytes.
+func (enc Encoder) writeStringHeader(n int64) error {
+	enc.track()
+
+	p := enc.buffer()
 	switch {
-	case len < 32:
-		_ = enc.Write(maskFixString | byte(len))
-	case len < 256:
-		_ = enc.Write(typeString8)
-		_ = enc.Write(byte(len))
-	case len < 65536:
-		_ = enc.Write(typeString16)
-		_ = enc.Write(uint16(len))
+	case n < 32:
+		p = append(p, maskFixString|byte(n))
+	case n < 256 && !enc.oldSpec: // the old spec has no str8 format
+		p = append(p, typeString8, byte(n))
+	case n < 65536:
+		p = append(p, typeString16, byte(n>>8), byte(n))
 	default:
-		_ = enc.Write(typeString32)
-		_ = enc.Write(uint32(len))
+		p = append(p, typeString32, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return enc.writeDataHeader(p, n)
+}
+
+// checkLength returns an error if the specified length is negative or
+// exceeds the maximum length of a msgpack string, binary, array or map.
+func checkLength(fn string, n int64) error {
+	switch {
+	case n < 0:
+		return fmt.Errorf("%s: %d: %w: length must not be negative", fn, n, ErrValueOutOfRange)
+	case n > maxLength:
+		return fmt.Errorf("%s: %d: %w: maximum length is %d", fn, n, ErrTooLarge, maxLength)
 	}
-	return enc.err
+	return nil
 }
 
 // Encode writes a msgpack encoded value to the writer. The value
@@ -110,19 +239,40 @@ func (enc Encoder) WriteStringHeader(len int) error {
 //
 //   - bool
 //   - int family (int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64)
+//   - float family (float32, float64)
+//   - []byte
+//   - []int
 //   - string
+//   - time.Time
+//   - Pairs and MapSlice
+//   - FieldProvider (encoded as a map of its fields)
+//
+// Any other type (or any of the above) may be encoded by registering
+// an encoding function for the type using RegisterEncoder.
+//
+// If the Encoder has a ValueHook, the hook is called with the value
+// and the value returned by the hook is encoded.
 func (enc Encoder) Encode(v any) error {
+	if enc.hook != nil {
+		v = enc.hook("", v)
+	}
+	return enc.encode(v)
+}
+
+// encode implements Encode, encoding a value without calling any hook.
+func (enc Encoder) encode(v any) error {
+	if fn := registeredEncoder(v); fn != nil {
+		return fn(enc, v)
+	}
+
 	switch v := v.(type) {
 	// nil
 	case nil:
-		return enc.Write(atomNil)
+		return enc.EncodeNil()
 
 	// bool
 	case bool:
-		if v {
-			return enc.Write(atomTrue)
-		}
-		return enc.Write(atomFalse)
+		return enc.EncodeBool(v)
 
 	// int family
 	case int:
@@ -162,6 +312,16 @@ func (enc Encoder) Encode(v any) error {
 	case string:
 		return enc.EncodeString(v)
 
+	// time
+	case time.Time:
+		return enc.EncodeTime(v)
+
+	// types encoding themselves (e.g. Pairs)
+	case valueEncoder:
+		return v.encodeTo(enc)
+	case FieldProvider:
+		return encodeFields(enc, v)
+
 	default:
 		panic(fmt.Errorf("Encode: %w: %T", ErrUnsupportedType, v))
 	}
@@ -169,6 +329,8 @@ func (enc Encoder) Encode(v any) error {
 
 // EncodeBool encodes a boolean value to the current Writer.
 func (enc Encoder) EncodeBool(b bool) error {
+	enc.track()
+
 	if b {
 		return enc.Write(atomTrue)
 	}
@@ -177,47 +339,160 @@ func (enc Encoder) EncodeBool(b bool) error {
 
 // EncodeBytes encodes a []byte value to the current Writer
 // as binary data.
+//
+// Nothing is written if the length of the data exceeds the msgpack
+// limit of 2^32-1 bytes (ErrTooLarge).
 func (enc Encoder) EncodeBytes(b []byte) error {
 	if b == nil {
-		return enc.Write(atomNil)
+		return enc.EncodeNil()
+	}
+	if err := checkLength("EncodeBytes", int64(len(b))); err != nil {
+		return err
+	}
+	if enc.oldSpec {
+		// the old spec has no bin formats; binary data is a (raw) string
+		if err := enc.writeStringHeader(int64(len(b))); err != nil {
+			return err
+		}
+		return enc.writeBytes(b)
+	}
+	if err := enc.writeBinHeader(int64(len(b))); err != nil {
+		return err
+	}
+	return enc.writeBytes(b)
+}
+
+// EncodeBytesFrom encodes n bytes read from r to the current Writer as
+// binary data, streaming a large blob (e.g. from a file) without
+// holding it in memory.
+//
+// The bytes are copied using io.CopyN so that, where the current Writer
+// implements io.ReaderFrom, the copy is made by the Writer; e.g. when
+// copying from an *os.File to a *net.TCPConn the data may be copied by
+// the kernel without passing through user space.  This is not possible
+// if the Encoder was created with the Trace option, when the bytes are
+// copied through the Encoder to be traced.
+//
+// Nothing is written if n is negative (ErrValueOutOfRange) or exceeds
+// the msgpack limit of 2^32-1 bytes (ErrTooLarge).  If r ends before n
+// bytes have been read an error wrapping ErrTruncated is captured; as
+// for any error writing to the Writer, the output is then incomplete.
+func (enc Encoder) EncodeBytesFrom(r io.Reader, n int64) error {
+	if err := checkLength("EncodeBytesFrom", n); err != nil {
+		return err
 	}
+	var err error
+	if enc.oldSpec {
+		// the old spec has no bin formats; binary data is a (raw) string
+		err = enc.writeStringHeader(n)
+	} else {
+		err = enc.writeBinHeader(n)
+	}
+	if err != nil {
+		return err
+	}
+	return enc.copyFrom(r, n)
+}
 
-	switch {
-	case len(b) < 256:
-		_ = enc.Write(typeBin8)
-		_ = enc.Write(byte(len(b)))
-		return enc.Write(b)
+// EncodeBytesFromString encodes a string to the current Writer as
+// binary data, avoiding the conversion (and copy) of the string to a
+// []byte.  With the UnsafeStrings option the bytes of the string are
+// written without being copied even if the Writer does not implement
+// io.StringWriter.
+//
+// Nothing is written if the length of the string exceeds the msgpack
+// limit of 2^32-1 bytes (ErrTooLarge).
+func (enc Encoder) EncodeBytesFromString(s string) error {
+	if err := checkLength("EncodeBytesFromString", int64(len(s))); err != nil {
+		return err
+	}
+	var err error
+	if enc.oldSpec {
+		// the old spec has no bin formats; binary data is a (raw) string
+		err = enc.writeStringHeader(int64(len(s)))
+	} else {
+		err = enc.writeBinHeader(int64(len(s)))
+	}
+	if err != nil {
+		return err
+	}
+	return enc.writeString(s)
+}
 
-	case len(b) < 65536:
-		_ = enc.Write(typeBin16)
-		_ = enc.Write(uint16(len(b)))
-		return enc.Write(b)
+// writeBinHeader writes the header of binary data of n bytes.
+func (enc Encoder) writeBinHeader(n int64) error {
+	enc.track()
 
+	p := enc.buffer()
+	switch {
+	case n < 256:
+		p = append(p, typeBin8, byte(n))
+	case n < 65536:
+		p = append(p, typeBin16, byte(n>>8), byte(n))
 	default:
-		_ = enc.Write(typeBin32)
-		_ = enc.Write(uint32(len(b)))
-		return enc.Write(b)
+		p = append(p, typeBin32, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
 	}
+	return enc.writeDataHeader(p, n)
+}
+
+// writeDataHeader writes the header of a string, binary or extension
+// value with n bytes of data following the header.  Nothing is written
+// if the header and data would exceed any limit on the size of the
+// output, so that no part of a value is written that cannot be written
+// in full.
+func (enc *Encoder) writeDataHeader(p []byte, n int64) error {
+	if err := enc.state(); err != nil {
+		return err
+	}
+	if err := enc.reserve(int64(len(p)) + n); err != nil {
+		return enc.wrote(0, err)
+	}
+	return enc.write(p)
+}
+
+// EncodeNil encodes a nil value to the current Writer.
+func (enc Encoder) EncodeNil() error {
+	enc.track()
+	return enc.Write(atomNil)
 }
 
 // EncodeFloat32 encodes a float32 value to the current Writer.
 func (enc Encoder) EncodeFloat32(f float32) error {
-	_ = enc.Write(typeFloat32)
-	return enc.Write(f)
+	enc.track()
+	return enc.writeType32(typeFloat32, math.Float32bits(f))
 }
 
 // EncodeFloat64 encodes a float64 value to the current Writer.
 func (enc Encoder) EncodeFloat64(f float64) error {
-	_ = enc.Write(typeFloat64)
-	return enc.Write(f)
+	enc.track()
+	return enc.writeType64(typeFloat64, math.Float64bits(f))
 }
 
 // EncodeString encodes a string to the current writer.
+//
+// Nothing is written if the length of the string exceeds the msgpack
+// limit of 2^32-1 bytes (ErrTooLarge).
 func (enc Encoder) EncodeString(s string) error {
-	if err := enc.WriteStringHeader(len(s)); err == nil {
-		_, enc.err = io.WriteString(enc.out, s)
+	if err := enc.WriteStringHeader(len(s)); err != nil {
+		return err
 	}
-	return enc.err
+	return enc.writeString(s)
+}
+
+// EncodeStringBytes encodes a []byte value to the current writer as a
+// string, avoiding the conversion (and copy) of bytes already held by
+// the caller (e.g. a value sliced from a buffer) to a string.  The bytes
+// are written as-is; they should be valid utf-8.
+//
+// A nil slice is encoded as an empty string.
+//
+// Nothing is written if the length of the bytes exceeds the msgpack
+// limit of 2^32-1 bytes (ErrTooLarge).
+func (enc Encoder) EncodeStringBytes(b []byte) error {
+	if err := enc.WriteStringHeader(len(b)); err != nil {
+		return err
+	}
+	return enc.writeBytes(b)
 }
 
 // Reset returns any error on the encoder and clears the error state.
@@ -247,94 +522,341 @@ func (enc Encoder) EncodeString(s string) error {
 //	  return err
 //	}
 func (e *Encoder) ResetError() (err error) {
-	err = e.err
-	e.err = nil
+	if e.err == nil {
+		return nil
+	}
+	err = *e.err
+	*e.err = nil
 	return
 }
 
-// SetWriter changes the current io.Writer of the Encoder.
-func (enc *Encoder) SetWriter(out io.Writer) {
+// Size returns the number of bytes written by the Encoder since it was
+// created or since ResetSize was last called.
+func (enc Encoder) Size() int64 {
+	if enc.count == nil {
+		return 0
+	}
+	return enc.count.total
+}
+
+// ResetSize resets the number of bytes written by the Encoder to zero.
+//
+// If the size of the output of the Encoder is limited (see MaxSize)
+// this allows the Encoder to be re-used for a new message once any
+// ErrTooLarge error has been cleared (see ResetError).
+func (enc Encoder) ResetSize() {
+	if enc.count != nil {
+		enc.count.total = 0
+		enc.count.exceeded = false
+	}
+}
+
+// SetWriter changes the current io.Writer of the Encoder, returning
+// the io.Writer that was previously current.  This enables output to
+// be temporarily redirected without using a closure, e.g:
+//
+//	og := enc.SetWriter(buf)
+//	defer enc.SetWriter(og)
+//
+// The function will panic with ErrNilWriter if the specified
+// io.Writer is nil.
+func (enc *Encoder) SetWriter(out io.Writer) io.Writer {
+	if out == nil {
+		panic(fmt.Errorf("SetWriter: %w", ErrNilWriter))
+	}
+
+	// a zero value Encoder has no error state until an io.Writer is set
+	if enc.err == nil {
+		enc.err = new(error)
+	}
+
+	og := enc.out
 	enc.out = out
+	return og
+}
+
+// Tee adds an additional io.Writer to the Encoder such that all
+// subsequent output is written to both the current io.Writer and the
+// specified io.Writer, without encoding values more than once.
+//
+// The io.Writer that was current before the call is returned; to stop
+// writing to the additional io.Writer, restore the returned io.Writer
+// using SetWriter:
+//
+//	og := enc.Tee(spool)
+//	defer enc.SetWriter(og)
+//
+// An error writing to either io.Writer places the Encoder in an error
+// state.  The function will panic with ErrNilWriter if the specified
+// io.Writer is nil.
+func (enc *Encoder) Tee(w io.Writer) io.Writer {
+	if w == nil {
+		panic(fmt.Errorf("Tee: %w", ErrNilWriter))
+	}
+	return enc.SetWriter(io.MultiWriter(enc.out, w))
 }
 
 // Using temporarily changes the io.Writer destination for the Encoder
 // while the specified function is executed.  The original io.Writer
-// destination is restored after the function returns.
+// destination is restored after the function returns (or panics).
+//
+// If the Encoder is already in an error state the function is not
+// called and the existing error is returned.
+//
+// Otherwise any error returned by the function is captured by the
+// Encoder.  If a different error was also captured by the Encoder while
+// the function was executing, the error captured combines the two (each
+// may be tested using errors.Is), e.g:
+//
+//	writer error; callback: callback error
+//
+// If the function returns the error captured by the Encoder (or an
+// error wrapping it), that error alone remains captured.
+//
+// Calls to Using may be nested; each call restores the io.Writer that
+// was current when it was called.
 func (enc *Encoder) Using(dest io.Writer, fn func() error) error {
+	if enc.err == nil {
+		enc.err = new(error)
+	}
+	if *enc.err != nil {
+		return *enc.err
+	}
+
 	og := enc.out
 	defer func() { enc.out = og }()
 
 	enc.out = dest
-	enc.err = fn()
-	return enc.err
+	if err := fn(); err != nil {
+		switch {
+		case *enc.err == nil:
+			*enc.err = err
+		case !errors.Is(err, *enc.err):
+			*enc.err = &callbackError{err: *enc.err, callback: err}
+		}
+	}
+	return *enc.err
 }
 
 // Write writes a value to the writer as big-endian raw bytes,
 // with no msgpack type indicator or other encoding.
 //
-// This method is provided as a more efficient alternative to
-// binary.Write(), optimised for handling the limited types that
-// a msgpack encoder is required to write.
-//
-// If an error is returned when attempting to write to the Writer,
-// the error is retained and returned on subsequent calls to Write
-// unless/until the error is cleared by calling Reset.
-//
-// Write supports only a limited number of types, being intended
-// for use by other Encoder functions and in specialised streaming
-// scenarios. It is not intended for general use.
-//
-// The types supported are:
-//
-//   - []byte
-//   - byte / uint8
-//   - int8 / int16 / int32 / int64
-//   - uint16 / uint32 / uint64
-//   - float32 / float64
-//
-// The function will panic if a value of any other type is specified.
-//
-// To encode a []byte as msgpack encoded binary data, use EncodeBytes.
+// Deprecated: use Raw().Write; see RawWriter.
 func (enc Encoder) Write(b any) error {
-	if enc.err != nil {
-		return enc.err
+	return RawWriter{enc}.Write(b)
+}
+
+// writeBytes writes a []byte to the current writer.
+func (enc *Encoder) writeBytes(p []byte) error {
+	return enc.write(p)
+}
+
+// writeUint16 writes a 16-bit value (big-endian) to the current writer.
+func (enc *Encoder) writeUint16(v uint16) error {
+	return enc.write(append(enc.buffer(), byte(v>>8), byte(v)))
+}
+
+// writeUint32 writes a 32-bit value (big-endian) to the current writer.
+func (enc *Encoder) writeUint32(v uint32) error {
+	return enc.write(append(enc.buffer(), byte(v>>24), byte(v>>16), byte(v>>8), byte(v)))
+}
+
+// writeUint64 writes a 64-bit value (big-endian) to the current writer.
+func (enc *Encoder) writeUint64(v uint64) error {
+	return enc.write(append(enc.buffer(), byte(v>>56), byte(v>>48), byte(v>>40), byte(v>>32), byte(v>>24), byte(v>>16), byte(v>>8), byte(v)))
+}
+
+// writeType8 writes a msgpack type followed by an 8-bit value to the
+// current writer.  The type and value are written together, so that
+// nothing is written if the value cannot be.
+func (enc *Encoder) writeType8(t, v byte) error {
+	return enc.write(append(enc.buffer(), t, v))
+}
+
+// writeType16 writes a msgpack type followed by a 16-bit value
+// (big-endian) to the current writer, as for writeType8.
+func (enc *Encoder) writeType16(t byte, v uint16) error {
+	return enc.write(append(enc.buffer(), t, byte(v>>8), byte(v)))
+}
+
+// writeType32 writes a msgpack type followed by a 32-bit value
+// (big-endian) to the current writer, as for writeType8.
+func (enc *Encoder) writeType32(t byte, v uint32) error {
+	return enc.write(append(enc.buffer(), t, byte(v>>24), byte(v>>16), byte(v>>8), byte(v)))
+}
+
+// writeType64 writes a msgpack type followed by a 64-bit value
+// (big-endian) to the current writer, as for writeType8.
+func (enc *Encoder) writeType64(t byte, v uint64) error {
+	return enc.write(append(enc.buffer(), t, byte(v>>56), byte(v>>48), byte(v>>40), byte(v>>32), byte(v>>24), byte(v>>16), byte(v>>8), byte(v)))
+}
+
+// buffer returns an empty slice of the scratch buffer of the Encoder,
+// to which a value of up to 16 bytes may be appended before it is written
+// without allocating.
+func (enc Encoder) buffer() []byte {
+	if enc.scratch == nil {
+		return nil
 	}
+	return enc.scratch[:0]
+}
 
-	switch v := b.(type) {
-	// byte family
-	case uint8: // a.k.a byte
-		_, enc.err = enc.out.Write([]byte{v})
-	case []byte:
-		_, enc.err = enc.out.Write(v)
+// write writes bytes to the current writer, subject to any limit on
+// the size of the output.  Nothing is written if the Encoder is in an
+// error state.
+func (enc *Encoder) write(p []byte) error {
+	if err := enc.state(); err != nil {
+		return err
+	}
+	if enc.out == nil {
+		return enc.wrote(0, ErrNilWriter)
+	}
+	if err := enc.reserve(int64(len(p))); err != nil {
+		return enc.wrote(0, err)
+	}
+	if enc.stats != nil && enc.count.value == 0 && len(p) > 0 {
+		enc.stats.value(p[0])
+	}
+	n, err := enc.out.Write(p)
+	if enc.trace != nil {
+		enc.trace.write(p[:n])
+	}
+	return enc.wrote(n, err)
+}
 
-	// int family
-	case int8:
-		_, enc.err = enc.out.Write([]byte{byte(v)})
-	case int16:
-		_, enc.err = enc.out.Write([]byte{byte(v >> 8), byte(v)})
-	case uint16:
-		_, enc.err = enc.out.Write([]byte{byte(v >> 8), byte(v)})
-	case int32:
-		_, enc.err = enc.out.Write([]byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)})
-	case uint32:
-		_, enc.err = enc.out.Write([]byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)})
-	case int64:
-		_, enc.err = enc.out.Write([]byte{byte(v >> 56), byte(v >> 48), byte(v >> 40), byte(v >> 32), byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)})
-	case uint64:
-		_, enc.err = enc.out.Write([]byte{byte(v >> 56), byte(v >> 48), byte(v >> 40), byte(v >> 32), byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)})
+// writeString writes a string to the current writer, subject to any
+// limit on the size of the output.
+func (enc *Encoder) writeString(s string) error {
+	if err := enc.state(); err != nil {
+		return err
+	}
+	if enc.out == nil {
+		return enc.wrote(0, ErrNilWriter)
+	}
+	if err := enc.reserve(int64(len(s))); err != nil {
+		return enc.wrote(0, err)
+	}
+	var n int
+	var err error
+	if enc.unsafeStr {
+		n, err = enc.out.Write(unsafeBytes(s))
+	} else {
+		n, err = io.WriteString(enc.out, s)
+	}
+	if enc.trace != nil {
+		enc.trace.write([]byte(s[:n]))
+	}
+	return enc.wrote(n, err)
+}
 
-	// float family
-	case float32:
-		b := math.Float32bits(v)
-		_, enc.err = enc.out.Write([]byte{byte(b >> 24), byte(b >> 16), byte(b >> 8), byte(b)})
-	case float64:
-		b := math.Float64bits(v)
-		_, enc.err = enc.out.Write([]byte{byte(b >> 56), byte(b >> 48), byte(b >> 40), byte(b >> 32), byte(b >> 24), byte(b >> 16), byte(b >> 8), byte(b)})
+// copyFrom copies n bytes from r to the current writer, subject to any
+// limit on the size of the output.
+func (enc *Encoder) copyFrom(r io.Reader, n int64) error {
+	if err := enc.state(); err != nil {
+		return err
+	}
+	if enc.out == nil {
+		return enc.wrote(0, ErrNilWriter)
+	}
+	if err := enc.reserve(n); err != nil {
+		return enc.wrote(0, err)
+	}
 
-	// unsupported
-	default:
-		panic(fmt.Errorf("Write: %w: %T", ErrUnsupportedType, v))
+	var w io.Writer = enc.out
+	if enc.trace != nil {
+		w = traceWriter{enc.out, enc.trace}
+	}
+	copied, err := io.CopyN(w, r, n)
+	if err == io.EOF {
+		err = fmt.Errorf("%w: wanted %d bytes, got %d", ErrTruncated, n, copied)
 	}
+	return enc.wrote(int(copied), err)
+}
 
-	return enc.err
+// traceWriter is an io.Writer writing to an io.Writer and a tracer.
+type traceWriter struct {
+	out   io.Writer
+	trace *tracer
+}
+
+// Write implements io.Writer.
+func (w traceWriter) Write(p []byte) (int, error) {
+	n, err := w.out.Write(p)
+	w.trace.write(p[:n])
+	return n, err
+}
+
+// reserve returns ErrTooLarge if writing n bytes would exceed any limit
+// on the size of the output.  Once the limit has been exceeded, any
+// further attempt to write will also fail (until ResetSize is called).
+func (enc *Encoder) reserve(n int64) error {
+	c := enc.count
+	if c == nil || c.limit == 0 {
+		return nil
+	}
+	if c.exceeded || c.total+n > c.limit {
+		c.exceeded = true
+		return fmt.Errorf("%w: output is limited to %d bytes", ErrTooLarge, c.limit)
+	}
+	return nil
+}
+
+// wrote records the number of bytes written to the current writer and
+// any error that occurred, returning the error state of the Encoder.
+//
+// An error is captured as a WriteError, recording the number of bytes
+// of the current value that were written before the error occurred.
+func (enc *Encoder) wrote(n int, err error) error {
+	if enc.count != nil {
+		enc.count.value += n
+		enc.count.total += int64(n)
+	}
+	if enc.stats != nil {
+		enc.stats.wrote(n, err)
+	}
+	if err != nil {
+		written := n
+		if enc.count != nil {
+			written = enc.count.value
+		}
+		err = &WriteError{Written: written, Err: err}
+		if enc.err == nil {
+			return err
+		}
+		*enc.err = err
+	}
+	return enc.state()
+}
+
+// state returns the error state of the Encoder (nil for a zero value
+// Encoder with no io.Writer).
+func (enc Encoder) state() error {
+	if enc.err == nil {
+		return nil
+	}
+	return *enc.err
+}
+
+// track is called by Encoder methods before encoding a value, to
+// reset the count of bytes written for the value and to record the
+// value in any open container (when container tracking is enabled).
+func (enc Encoder) track() {
+	if enc.count != nil {
+		enc.count.value = 0
+	}
+	if enc.containers != nil {
+		enc.containers.value()
+	}
+}
+
+// open is called by Encoder methods before writing the header for an
+// array or map, to reset the count of bytes written for the value and
+// to open a new container (when container tracking is enabled).
+func (enc Encoder) open(n int64, isMap bool) {
+	if enc.count != nil {
+		enc.count.value = 0
+	}
+	if enc.containers != nil {
+		enc.containers.open(n, isMap, false)
+	}
 }
@@ -0,0 +1,125 @@
+package msgpack
+
+import "fmt"
+
+// WriteHeader writes the header of a value of the specified format with
+// length n to the current writer, for generated encoders and tooling
+// that determine the format of each value themselves.  Unlike
+// WriteArrayHeader (etc) the format written is exactly that specified,
+// even where a more efficient format could be used.
+//
+// The length is interpreted as for ReadHeader:
+//
+//   - str, bin and ext: the number of bytes of data following the header
+//   - array: the number of elements
+//   - map: the number of entries (key/value pairs)
+//
+// The header of an ext format does not include the extension type,
+// which must be written (as an int8) immediately following the header.
+// For FormatTimestamp the header written is that of the fixext4, fixext8
+// or ext8 format for a timestamp of n bytes (4, 8 or 12), including the
+// timestamp extension type.
+//
+// If the size of the output of the Encoder is limited (see MaxSize), the
+// limit is checked for the header and the data of a str, bin or ext
+// value, so that nothing is written if the value would exceed the limit.
+//
+// Nothing is written and an error is returned if the length is negative
+// or cannot be represented by the format (ErrValueOutOfRange), or if
+// the format has no length or is not supported by the old spec when
+// the Encoder was created with the OldSpec option (ErrUnsupportedType).
+func (enc Encoder) WriteHeader(f Format, n int64) error {
+	h, ok := headers[f]
+	if !ok || (enc.oldSpec && h.newSpec) {
+		return fmt.Errorf("WriteHeader: %s: %w", f, ErrUnsupportedType)
+	}
+
+	if f == FormatTimestamp {
+		switch n {
+		case 4:
+			h = header{lead: typeFixExt4, min: 4, max: 4}
+		case 8:
+			h = header{lead: typeFixExt8, min: 8, max: 8}
+		case 12:
+			h = header{lead: typeExt8, size: 1, min: 12, max: 12}
+		default:
+			return fmt.Errorf("WriteHeader: %s: %d: %w: length must be 4, 8 or 12", f, n, ErrValueOutOfRange)
+		}
+	}
+	if n < h.min || n > h.max {
+		return fmt.Errorf("WriteHeader: %s: %d: %w: %d..%d", f, n, ErrValueOutOfRange, h.min, h.max)
+	}
+
+	switch {
+	case f >= FormatFixArray && f <= FormatArray32:
+		enc.open(n, false)
+	case f >= FormatFixMap && f <= FormatMap32:
+		enc.open(n, true)
+	default:
+		enc.track()
+	}
+
+	// the header is written with a single write, so that nothing is
+	// written if the header cannot be written in full
+	p := append(enc.buffer(), h.lead)
+	switch h.size {
+	case 0:
+		if h.min != h.max { // fixstr, fixarray or fixmap: the length is encoded in the leading byte
+			p[0] |= byte(n)
+		}
+	case 1:
+		p = append(p, byte(n))
+	case 2:
+		p = append(p, byte(n>>8), byte(n))
+	case 4:
+		p = append(p, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	switch {
+	case f >= FormatFixArray && f <= FormatMap32:
+		return enc.writeBytes(p)
+	case f == FormatTimestamp:
+		return enc.writeDataHeader(append(p, extTimestampByte), n)
+	case f >= FormatFixExt1 && f <= FormatExt32:
+		return enc.writeDataHeader(p, 1+n) // the extension type follows the header
+	default:
+		return enc.writeDataHeader(p, n)
+	}
+}
+
+// header describes the header of a format written by WriteHeader: the
+// leading byte (or mask, for a fix format), the size of the length
+// following the leading byte (0 for a fix format, with the length
+// encoded in the leading byte or implied by the format) and the range
+// of lengths that may be represented.
+type header struct {
+	lead     byte
+	size     int
+	min, max int64
+	newSpec  bool // true if the format is not supported by the old spec
+}
+
+// headers holds the header of each format supported by WriteHeader.
+var headers = map[Format]header{
+	FormatFixStr:    {lead: maskFixString, max: 31},
+	FormatStr8:      {lead: typeString8, size: 1, max: 1<<8 - 1, newSpec: true},
+	FormatStr16:     {lead: typeString16, size: 2, max: 1<<16 - 1},
+	FormatStr32:     {lead: typeString32, size: 4, max: maxLength},
+	FormatBin8:      {lead: typeBin8, size: 1, max: 1<<8 - 1, newSpec: true},
+	FormatBin16:     {lead: typeBin16, size: 2, max: 1<<16 - 1, newSpec: true},
+	FormatBin32:     {lead: typeBin32, size: 4, max: maxLength, newSpec: true},
+	FormatFixArray:  {lead: maskFixArray, max: 15},
+	FormatArray16:   {lead: typeArray16, size: 2, max: 1<<16 - 1},
+	FormatArray32:   {lead: typeArray32, size: 4, max: maxLength},
+	FormatFixMap:    {lead: maskFixMap, max: 15},
+	FormatMap16:     {lead: typeMap16, size: 2, max: 1<<16 - 1},
+	FormatMap32:     {lead: typeMap32, size: 4, max: maxLength},
+	FormatFixExt1:   {lead: typeFixExt1, min: 1, max: 1, newSpec: true},
+	FormatFixExt2:   {lead: typeFixExt2, min: 2, max: 2, newSpec: true},
+	FormatFixExt4:   {lead: typeFixExt4, min: 4, max: 4, newSpec: true},
+	FormatFixExt8:   {lead: typeFixExt8, min: 8, max: 8, newSpec: true},
+	FormatFixExt16:  {lead: typeFixExt16, min: 16, max: 16, newSpec: true},
+	FormatExt8:      {lead: typeExt8, size: 1, max: 1<<8 - 1, newSpec: true},
+	FormatExt16:     {lead: typeExt16, size: 2, max: 1<<16 - 1, newSpec: true},
+	FormatExt32:     {lead: typeExt32, size: 4, max: maxLength, newSpec: true},
+	FormatTimestamp: {newSpec: true},
+}
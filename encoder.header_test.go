@@ -0,0 +1,91 @@
+package msgpack
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestWriteHeader(t *testing.T) {
+	// ARRANGE
+	type expect struct {
+		result []byte
+		error
+	}
+	testcases := []struct {
+		spec    string // for information only, not part of the test
+		oldSpec bool   // true if the test case runs with an Encoder created with the OldSpec option
+		format  Format
+		n       int64
+		expect
+	}{
+		{spec: "fixstr", format: FormatFixStr, n: 5, expect: expect{result: []byte{0xa5}}},
+		{spec: "fixstr/too long", format: FormatFixStr, n: 32, expect: expect{error: ErrValueOutOfRange}},
+		{spec: "str8", format: FormatStr8, n: 3, expect: expect{result: []byte{typeString8, 0x03}}},
+		{spec: "str8/old spec", oldSpec: true, format: FormatStr8, n: 3, expect: expect{error: ErrUnsupportedType}},
+		{spec: "str16", format: FormatStr16, n: 256, expect: expect{result: []byte{typeString16, 0x01, 0x00}}},
+		{spec: "str32", format: FormatStr32, n: 1, expect: expect{result: []byte{typeString32, 0x00, 0x00, 0x00, 0x01}}},
+		{spec: "str32/negative", format: FormatStr32, n: -1, expect: expect{error: ErrValueOutOfRange}},
+		{spec: "str32/too long", format: FormatStr32, n: maxLength + 1, expect: expect{error: ErrValueOutOfRange}},
+		{spec: "bin8", format: FormatBin8, n: 255, expect: expect{result: []byte{typeBin8, 0xff}}},
+		{spec: "bin8/too long", format: FormatBin8, n: 256, expect: expect{error: ErrValueOutOfRange}},
+		{spec: "bin16/old spec", oldSpec: true, format: FormatBin16, n: 1, expect: expect{error: ErrUnsupportedType}},
+		{spec: "fixarray", format: FormatFixArray, n: 0, expect: expect{result: []byte{atomEmptyArray}}},
+		{spec: "array16", format: FormatArray16, n: 2, expect: expect{result: []byte{typeArray16, 0x00, 0x02}}},
+		{spec: "fixmap", format: FormatFixMap, n: 15, expect: expect{result: []byte{0x8f}}},
+		{spec: "map32", format: FormatMap32, n: 2, expect: expect{result: []byte{typeMap32, 0x00, 0x00, 0x00, 0x02}}},
+		{spec: "map32/old spec", oldSpec: true, format: FormatMap32, n: 2, expect: expect{result: []byte{typeMap32, 0x00, 0x00, 0x00, 0x02}}},
+		{spec: "fixext1", format: FormatFixExt1, n: 1, expect: expect{result: []byte{typeFixExt1}}},
+		{spec: "fixext16", format: FormatFixExt16, n: 16, expect: expect{result: []byte{typeFixExt16}}},
+		{spec: "fixext16/wrong length", format: FormatFixExt16, n: 15, expect: expect{error: ErrValueOutOfRange}},
+		{spec: "ext8", format: FormatExt8, n: 3, expect: expect{result: []byte{typeExt8, 0x03}}},
+		{spec: "ext32", format: FormatExt32, n: 3, expect: expect{result: []byte{typeExt32, 0x00, 0x00, 0x00, 0x03}}},
+		{spec: "timestamp 32", format: FormatTimestamp, n: 4, expect: expect{result: []byte{typeFixExt4, 0xff}}},
+		{spec: "timestamp 64", format: FormatTimestamp, n: 8, expect: expect{result: []byte{typeFixExt8, 0xff}}},
+		{spec: "timestamp 96", format: FormatTimestamp, n: 12, expect: expect{result: []byte{typeExt8, 0x0c, 0xff}}},
+		{spec: "timestamp/wrong length", format: FormatTimestamp, n: 5, expect: expect{error: ErrValueOutOfRange}},
+		{spec: "timestamp/old spec", oldSpec: true, format: FormatTimestamp, n: 4, expect: expect{error: ErrUnsupportedType}},
+		{spec: "nil", format: FormatNil, expect: expect{error: ErrUnsupportedType}},
+		{spec: "int32", format: FormatInt32, n: 4, expect: expect{error: ErrUnsupportedType}},
+		{spec: "invalid", format: FormatInvalid, expect: expect{error: ErrUnsupportedType}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			// ARRANGE
+			buf := &bytes.Buffer{}
+			enc := NewEncoder(buf)
+			if tc.oldSpec {
+				enc = NewEncoder(buf, OldSpec())
+			}
+
+			// ACT
+			err := enc.WriteHeader(tc.format, tc.n)
+
+			// ASSERT
+			testError(t, tc.expect.error, err)
+
+			wanted := tc.expect.result
+			got := buf.Bytes()
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %x\ngot    %x", wanted, got)
+			}
+		})
+	}
+
+	t.Run("tracked containers", func(t *testing.T) {
+		// ARRANGE
+		buf := &bytes.Buffer{}
+		enc := NewEncoder(buf, TrackContainers())
+
+		// ACT
+		_ = enc.WriteHeader(FormatArray16, 2)
+		_ = enc.EncodeNil()
+
+		// ASSERT
+		wanted := "[array[1/2]]"
+		got := fmt.Sprintf("%v", enc.OpenContainers())
+		if wanted != got {
+			t.Errorf("\nwanted %s\ngot    %s", wanted, got)
+		}
+	})
+}
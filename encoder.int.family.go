@@ -6,7 +6,7 @@ import (
 )
 
 // EncodeFixedInt writes a fixed int to the current writer. The
-// function will panic with ErrOutOfRange if the value is
+// function will panic with ErrValueOutOfRange if the value is
 // out of range for a msgpack fixed int encoding.
 //
 // The valid range for EncodeFixedInt is -32..127 (incl.)
@@ -15,15 +15,84 @@ import (
 // appropriate to the type you wish to encode or EncodeInt; these
 // functions all select the most efficient packing for the
 // value involved.
+//
+// To return an out of range value as an error rather than panic,
+// use TryEncodeFixedInt.
 func (enc Encoder) EncodeFixedInt(i int) error {
-	switch {
-	case i < int(minFixedInt),
-		i > int(maxFixedInt):
-		panic(fmt.Errorf("EncodeFixedInt: %d: %w: -%d..%d", i, ErrValueOutOfRange, minFixedInt, maxFixedInt))
+	if err := checkFixedInt("EncodeFixedInt", i); err != nil {
+		panic(err)
+	}
 
-	default:
-		return enc.Write(byte(i))
+	enc.track()
+
+	return enc.Write(byte(i))
+}
+
+// TryEncodeFixedInt writes a fixed int to the current writer.  If the
+// value is out of range for a msgpack fixed int encoding (-32..127 incl.)
+// nothing is written and ErrValueOutOfRange is returned.
+//
+// This is equivalent to EncodeFixedInt except that an out of range
+// value is returned as an error, rather than causing a panic; it should
+// be used when the value to be encoded is not known to be in range
+// (e.g. when it is derived from user data).
+func (enc Encoder) TryEncodeFixedInt(i int) error {
+	if err := checkFixedInt("TryEncodeFixedInt", i); err != nil {
+		return err
+	}
+
+	enc.track()
+
+	return enc.Write(byte(i))
+}
+
+// EncodeNegFixInt writes a negative fixint to the current writer. The
+// function will panic with ErrValueOutOfRange if the value is out of
+// range for a msgpack negative fixint encoding (-32..-1 incl.)
+//
+// This is intended for generated code requiring exact control of the
+// format of an encoded value; EncodeInt will select the negative
+// fixint format for any value in this range.
+func (enc Encoder) EncodeNegFixInt(i int) error {
+	if err := checkRange("EncodeNegFixInt", i, int(minFixedInt), -1); err != nil {
+		panic(err)
+	}
+
+	enc.track()
+
+	return enc.Write(byte(i))
+}
+
+// EncodeFixedUint writes a positive fixint to the current writer. The
+// function will panic with ErrValueOutOfRange if the value is out of
+// range for a msgpack positive fixint encoding (0..127 incl.)
+//
+// This is intended for generated code requiring exact control of the
+// format of an encoded value; EncodeUint will select the positive
+// fixint format for any value in this range.
+func (enc Encoder) EncodeFixedUint(u uint8) error {
+	if err := checkRange("EncodeFixedUint", int(u), int(minFixedUint), int(maxFixedUint)); err != nil {
+		panic(err)
 	}
+
+	enc.track()
+
+	return enc.Write(u)
+}
+
+// checkFixedInt returns ErrValueOutOfRange if the specified value is
+// out of range for a fixed int encoding.
+func checkFixedInt(fn string, i int) error {
+	return checkRange(fn, i, int(minFixedInt), int(maxFixedInt))
+}
+
+// checkRange returns ErrValueOutOfRange if the specified value is
+// outside the range min..max (incl.)
+func checkRange(fn string, i, min, max int) error {
+	if i < min || i > max {
+		return fmt.Errorf("%s: %d: %w: %d..%d", fn, i, ErrValueOutOfRange, min, max)
+	}
+	return nil
 }
 
 // EncodeInt8 encodes a signed 8-bit integer to the current writer.
@@ -31,10 +100,11 @@ func (enc Encoder) EncodeFixedInt(i int) error {
 // The encoder will use the most efficient format for the value
 // being encoded, which may be a fixed int.
 func (enc Encoder) EncodeInt8(i int8) error {
+	enc.track()
+
 	switch {
 	case i < minFixedInt:
-		_ = enc.Write(typeInt8)
-		return enc.Write(i)
+		return enc.writeType8(typeInt8, byte(i))
 
 	default: // all int8 are <= maxFixedInt:
 		return enc.Write(byte(i)) // bypass the range check in EncodeFixedInt
@@ -46,25 +116,23 @@ func (enc Encoder) EncodeInt8(i int8) error {
 // The encoder will use the most efficient format for the value
 // being encoded, which may not be int16.
 func (enc Encoder) EncodeInt16(i int16) error {
+	enc.track()
+
 	switch {
 	case i < int16(math.MinInt8):
-		_ = enc.Write(typeInt16)
-		return enc.Write(int16(i))
+		return enc.writeType16(typeInt16, uint16(i))
 
 	case i < int16(minFixedInt):
-		_ = enc.Write(typeInt8)
-		return enc.Write(int8(i))
+		return enc.writeType8(typeInt8, byte(i))
 
 	case i <= int16(maxFixedInt):
 		return enc.Write(byte(i)) // bypass the range check in EncodeFixedInt
 
 	case i <= math.MaxUint8:
-		_ = enc.Write(typeUint8)
-		return enc.Write(uint8(i))
+		return enc.writeType8(typeUint8, byte(i))
 
 	default:
-		_ = enc.Write(typeInt16)
-		return enc.Write(i)
+		return enc.writeType16(typeInt16, uint16(i))
 	}
 }
 
@@ -73,33 +141,29 @@ func (enc Encoder) EncodeInt16(i int16) error {
 // The encoder will use the most efficient format for the value
 // being encoded, which may not be int32.
 func (enc Encoder) EncodeInt32(i int32) error {
+	enc.track()
+
 	switch {
 	case i < int32(math.MinInt16):
-		_ = enc.Write(typeInt32)
-		return enc.Write(int32(i))
+		return enc.writeType32(typeInt32, uint32(i))
 
 	case i < int32(math.MinInt8):
-		_ = enc.Write(typeInt16)
-		return enc.Write(int16(i))
+		return enc.writeType16(typeInt16, uint16(i))
 
 	case i < int32(minFixedInt):
-		_ = enc.Write(typeInt8)
-		return enc.Write(int8(i))
+		return enc.writeType8(typeInt8, byte(i))
 
 	case i <= int32(maxFixedInt):
 		return enc.Write(byte(i)) // bypass the range check in EncodeFixedInt
 
 	case i <= math.MaxUint8:
-		_ = enc.Write(typeUint8)
-		return enc.Write(uint8(i))
+		return enc.writeType8(typeUint8, byte(i))
 
 	case i <= math.MaxUint16:
-		_ = enc.Write(typeUint16)
-		return enc.Write(uint16(i))
+		return enc.writeType16(typeUint16, uint16(i))
 
 	default:
-		_ = enc.Write(typeInt32)
-		return enc.Write(i)
+		return enc.writeType32(typeInt32, uint32(i))
 	}
 }
 
@@ -108,41 +172,35 @@ func (enc Encoder) EncodeInt32(i int32) error {
 // The encoder will use the most efficient format for the value
 // being encoded, which may not be int64.
 func (enc Encoder) EncodeInt64(i int64) error {
+	enc.track()
+
 	switch {
 	case i < math.MinInt32:
-		_ = enc.Write(typeInt64)
-		return enc.Write(i)
+		return enc.writeType64(typeInt64, uint64(i))
 
 	case i < math.MinInt16:
-		_ = enc.Write(typeInt32)
-		return enc.Write(int32(i))
+		return enc.writeType32(typeInt32, uint32(i))
 
 	case i < math.MinInt8:
-		_ = enc.Write(typeInt16)
-		return enc.Write(int16(i))
+		return enc.writeType16(typeInt16, uint16(i))
 
 	case i < int64(minFixedInt):
-		_ = enc.Write(typeInt8)
-		return enc.Write(int8(i))
+		return enc.writeType8(typeInt8, byte(i))
 
 	case i <= int64(maxFixedInt):
 		return enc.Write(byte(i)) // bypass the range check in EncodeFixedInt
 
 	case i <= math.MaxUint8:
-		_ = enc.Write(typeUint8)
-		return enc.Write(uint8(i))
+		return enc.writeType8(typeUint8, byte(i))
 
 	case i <= math.MaxUint16:
-		_ = enc.Write(typeUint16)
-		return enc.Write(uint16(i))
+		return enc.writeType16(typeUint16, uint16(i))
 
 	case i <= math.MaxUint32:
-		_ = enc.Write(typeUint32)
-		return enc.Write(uint32(i))
+		return enc.writeType32(typeUint32, uint32(i))
 
 	default:
-		_ = enc.Write(typeUint64) // keeps sonarcloud happy by not duplicating the case for < MinInt32 (positive int64/uint64 are identical)
-		return enc.Write(i)
+		return enc.writeType64(typeUint64, uint64(i)) // keeps sonarcloud happy by not duplicating the case for < MinInt32 (positive int64/uint64 are identical)
 	}
 }
 
@@ -151,13 +209,14 @@ func (enc Encoder) EncodeInt64(i int64) error {
 // The encoder will use the most efficient format for the value
 // being encoded: fixed int or uint8.
 func (enc Encoder) EncodeUint8(i uint8) error {
+	enc.track()
+
 	switch {
 	case i <= maxFixedUint:
 		return enc.Write(byte(i)) // bypass the range check in EncodeFixedInt
 
 	default:
-		_ = enc.Write(typeUint8)
-		return enc.Write(i)
+		return enc.writeType8(typeUint8, byte(i))
 	}
 }
 
@@ -166,17 +225,17 @@ func (enc Encoder) EncodeUint8(i uint8) error {
 // The encoder will use the most efficient format for the value
 // being encoded: fixed int, uint8 or uint16.
 func (enc Encoder) EncodeUint16(i uint16) error {
+	enc.track()
+
 	switch {
 	case i <= uint16(maxFixedUint):
 		return enc.Write(byte(i)) // bypass the range check in EncodeFixedInt``
 
 	case i <= math.MaxUint8:
-		_ = enc.Write(typeUint8)
-		return enc.Write(uint8(i))
+		return enc.writeType8(typeUint8, byte(i))
 
 	default:
-		_ = enc.Write(typeUint16)
-		return enc.Write(i)
+		return enc.writeType16(typeUint16, i)
 	}
 }
 
@@ -185,21 +244,20 @@ func (enc Encoder) EncodeUint16(i uint16) error {
 // The encoder will use the most efficient format for the value
 // being encoded: fixed int, uint8, uint16 or uint32.
 func (enc Encoder) EncodeUint32(i uint32) error {
+	enc.track()
+
 	switch {
 	case i <= uint32(maxFixedUint):
 		return enc.Write(byte(i)) // bypass the range check in EncodeFixedInt
 
 	case i <= math.MaxUint8:
-		_ = enc.Write(typeUint8)
-		return enc.Write(uint8(i))
+		return enc.writeType8(typeUint8, byte(i))
 
 	case i <= math.MaxUint16:
-		_ = enc.Write(typeUint16)
-		return enc.Write(uint16(i))
+		return enc.writeType16(typeUint16, uint16(i))
 
 	default:
-		_ = enc.Write(typeUint32)
-		return enc.Write(i)
+		return enc.writeType32(typeUint32, i)
 	}
 }
 
@@ -208,25 +266,23 @@ func (enc Encoder) EncodeUint32(i uint32) error {
 // The encoder will use the most efficient format for the value
 // being encoded: fixed int, uint8, uint16, uint32 or uint64.
 func (enc Encoder) EncodeUint64(i uint64) error {
+	enc.track()
+
 	switch {
 	case i <= uint64(maxFixedUint):
 		return enc.Write(byte(i)) // bypass the range check in EncodeFixedInt
 
 	case i <= math.MaxUint8:
-		_ = enc.Write(typeUint8)
-		return enc.Write(uint8(i))
+		return enc.writeType8(typeUint8, byte(i))
 
 	case i <= math.MaxUint16:
-		_ = enc.Write(typeUint16)
-		return enc.Write(uint16(i))
+		return enc.writeType16(typeUint16, uint16(i))
 
 	case i <= math.MaxUint32:
-		_ = enc.Write(typeUint32)
-		return enc.Write(uint32(i))
+		return enc.writeType32(typeUint32, uint32(i))
 
 	default:
-		_ = enc.Write(typeUint64)
-		return enc.Write(i)
+		return enc.writeType64(typeUint64, i)
 	}
 }
 
@@ -238,41 +294,35 @@ func (enc Encoder) EncodeUint64(i uint64) error {
 // To write values that exceed the MaxInt/MinInt range on a 32-bit
 // platform you must explicitly use WriteInt64/WriteUint64.
 func (enc Encoder) EncodeInt(i int) error {
+	enc.track()
+
 	switch {
-	case i < math.MinInt32:
-		_ = enc.Write(typeInt64)
-		return enc.Write(int64(i))
+	case int64(i) < math.MinInt32:
+		return enc.writeType64(typeInt64, uint64(i))
 
 	case i < math.MinInt16:
-		_ = enc.Write(typeInt32)
-		return enc.Write(int32(i))
+		return enc.writeType32(typeInt32, uint32(i))
 
 	case i < math.MinInt8:
-		_ = enc.Write(typeInt16)
-		return enc.Write(int16(i))
+		return enc.writeType16(typeInt16, uint16(i))
 
 	case i < int(minFixedInt):
-		_ = enc.Write(typeInt8)
-		return enc.Write(int8(i))
+		return enc.writeType8(typeInt8, byte(i))
 
 	case i <= int(maxFixedInt):
 		return enc.Write(byte(i)) // bypass the range check in EncodeFixedInt
 
 	case i <= math.MaxUint8:
-		_ = enc.Write(typeUint8)
-		return enc.Write(uint8(i))
+		return enc.writeType8(typeUint8, byte(i))
 
 	case i <= math.MaxUint16:
-		_ = enc.Write(typeUint16)
-		return enc.Write(uint16(i))
+		return enc.writeType16(typeUint16, uint16(i))
 
-	case i <= math.MaxUint32:
-		_ = enc.Write(typeUint32)
-		return enc.Write(uint32(i))
+	case int64(i) <= math.MaxUint32:
+		return enc.writeType32(typeUint32, uint32(i))
 
 	default:
-		_ = enc.Write(typeUint64) // keeps sonarcloud happy by not duplicating the case for < MinInt32 (positive int64/uint64 are identical)
-		return enc.Write(int64(i))
+		return enc.writeType64(typeUint64, uint64(i)) // keeps sonarcloud happy by not duplicating the case for < MinInt32 (positive int64/uint64 are identical)
 	}
 }
 
@@ -281,21 +331,19 @@ func (enc Encoder) EncodeInt(i int) error {
 // The encoder packs using the smallest possible integer
 // type for the value involved.
 func (enc Encoder) EncodeUint(i uint) error {
+	enc.track()
+
 	switch {
 	case i <= uint(maxFixedUint):
 		return enc.Write(byte(i)) // bypass the range check in EncodeFixedInt
 	case i <= math.MaxUint8:
-		_ = enc.Write(typeUint8)
-		return enc.Write(uint8(i))
+		return enc.writeType8(typeUint8, byte(i))
 	case i <= math.MaxUint16:
-		_ = enc.Write(typeUint16)
-		return enc.Write(uint16(i))
-	case i <= math.MaxUint32:
-		_ = enc.Write(typeUint32)
-		return enc.Write(uint32(i))
+		return enc.writeType16(typeUint16, uint16(i))
+	case uint64(i) <= math.MaxUint32:
+		return enc.writeType32(typeUint32, uint32(i))
 	default:
-		_ = enc.Write(typeUint64)
-		return enc.Write(uint64(i))
+		return enc.writeType64(typeUint64, uint64(i))
 	}
 
 }
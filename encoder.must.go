@@ -0,0 +1,89 @@
+package msgpack
+
+import (
+	"fmt"
+	"time"
+)
+
+// must panics if the specified error is not nil, wrapping the error
+// with the name of the function from which it was called.
+func must(fn string, err error) {
+	if err != nil {
+		panic(fmt.Errorf("%s: %w", fn, err))
+	}
+}
+
+// The Must* methods are equivalent to the corresponding Encode/Write
+// methods except that they panic if an error occurs, rather than
+// returning the error.
+//
+// They are intended for use when encoding to a writer that cannot
+// fail (e.g. a bytes.Buffer) and in generated code, where checking
+// errors that cannot occur only harms readability.
+
+// MustEncode is equivalent to Encode but panics if an error occurs.
+func (enc Encoder) MustEncode(v any) {
+	must("MustEncode", enc.Encode(v))
+}
+
+// MustEncodeBool is equivalent to EncodeBool but panics if an error occurs.
+func (enc Encoder) MustEncodeBool(b bool) {
+	must("MustEncodeBool", enc.EncodeBool(b))
+}
+
+// MustEncodeBytes is equivalent to EncodeBytes but panics if an error occurs.
+func (enc Encoder) MustEncodeBytes(b []byte) {
+	must("MustEncodeBytes", enc.EncodeBytes(b))
+}
+
+// MustEncodeFloat32 is equivalent to EncodeFloat32 but panics if an error occurs.
+func (enc Encoder) MustEncodeFloat32(f float32) {
+	must("MustEncodeFloat32", enc.EncodeFloat32(f))
+}
+
+// MustEncodeFloat64 is equivalent to EncodeFloat64 but panics if an error occurs.
+func (enc Encoder) MustEncodeFloat64(f float64) {
+	must("MustEncodeFloat64", enc.EncodeFloat64(f))
+}
+
+// MustEncodeInt is equivalent to EncodeInt but panics if an error occurs.
+func (enc Encoder) MustEncodeInt(i int) {
+	must("MustEncodeInt", enc.EncodeInt(i))
+}
+
+// MustEncodeInt64 is equivalent to EncodeInt64 but panics if an error occurs.
+func (enc Encoder) MustEncodeInt64(i int64) {
+	must("MustEncodeInt64", enc.EncodeInt64(i))
+}
+
+// MustEncodeUint is equivalent to EncodeUint but panics if an error occurs.
+func (enc Encoder) MustEncodeUint(i uint) {
+	must("MustEncodeUint", enc.EncodeUint(i))
+}
+
+// MustEncodeUint64 is equivalent to EncodeUint64 but panics if an error occurs.
+func (enc Encoder) MustEncodeUint64(i uint64) {
+	must("MustEncodeUint64", enc.EncodeUint64(i))
+}
+
+// MustEncodeString is equivalent to EncodeString but panics if an error occurs.
+func (enc Encoder) MustEncodeString(s string) {
+	must("MustEncodeString", enc.EncodeString(s))
+}
+
+// MustEncodeTime is equivalent to EncodeTime but panics if an error occurs.
+func (enc Encoder) MustEncodeTime(t time.Time) {
+	must("MustEncodeTime", enc.EncodeTime(t))
+}
+
+// MustWriteArrayHeader is equivalent to WriteArrayHeader but panics if an
+// error occurs.
+func (enc Encoder) MustWriteArrayHeader(n int) {
+	must("MustWriteArrayHeader", enc.WriteArrayHeader(n))
+}
+
+// MustWriteMapHeader is equivalent to WriteMapHeader but panics if an
+// error occurs.
+func (enc Encoder) MustWriteMapHeader(n int) {
+	must("MustWriteMapHeader", enc.WriteMapHeader(n))
+}
@@ -0,0 +1,74 @@
+package msgpack
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEncoderMust(t *testing.T) {
+	// ARRANGE
+	enc, buf := NewTestEncoder()
+	encerr := errors.New("encoder error")
+
+	type expect struct {
+		result []byte
+		panic  error
+	}
+	testcases := []struct {
+		spec       string // for information only, not part of the test
+		errorState bool   // true if the test case runs with the encoder in an error state
+		fn         func()
+		expect
+	}{
+		{spec: "MustEncode(1)", fn: func() { enc.MustEncode(1) }, expect: expect{result: []byte{0x01}}},
+		{spec: "MustEncodeBool(true)", fn: func() { enc.MustEncodeBool(true) }, expect: expect{result: []byte{atomTrue}}},
+		{spec: "MustEncodeBytes([]byte{1})", fn: func() { enc.MustEncodeBytes([]byte{1}) }, expect: expect{result: []byte{typeBin8, 0x01, 0x01}}},
+		{spec: "MustEncodeFloat32(1)", fn: func() { enc.MustEncodeFloat32(1) }, expect: expect{result: []byte{typeFloat32, 0x3f, 0x80, 0x00, 0x00}}},
+		{spec: "MustEncodeFloat64(1)", fn: func() { enc.MustEncodeFloat64(1) }, expect: expect{result: []byte{typeFloat64, 0x3f, 0xf0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}}},
+		{spec: "MustEncodeInt(-1)", fn: func() { enc.MustEncodeInt(-1) }, expect: expect{result: []byte{0xff}}},
+		{spec: "MustEncodeInt64(-1)", fn: func() { enc.MustEncodeInt64(-1) }, expect: expect{result: []byte{0xff}}},
+		{spec: "MustEncodeUint(1)", fn: func() { enc.MustEncodeUint(1) }, expect: expect{result: []byte{0x01}}},
+		{spec: "MustEncodeUint64(1)", fn: func() { enc.MustEncodeUint64(1) }, expect: expect{result: []byte{0x01}}},
+		{spec: "MustEncodeString(s)", fn: func() { enc.MustEncodeString("s") }, expect: expect{result: []byte{maskFixString | 1, 's'}}},
+		{spec: "MustEncodeTime(epoch)", fn: func() { enc.MustEncodeTime(time.Unix(0, 0)) }, expect: expect{result: []byte{typeFixExt4, 0xff, 0x00, 0x00, 0x00, 0x00}}},
+		{spec: "MustWriteArrayHeader(1)", fn: func() { enc.MustWriteArrayHeader(1) }, expect: expect{result: []byte{maskFixArray | 1}}},
+		{spec: "MustWriteMapHeader(1)", fn: func() { enc.MustWriteMapHeader(1) }, expect: expect{result: []byte{maskFixMap | 1}}},
+		{spec: "MustEncode(1) (error)", errorState: true, fn: func() { enc.MustEncode(1) }, expect: expect{panic: encerr}},
+		{spec: "MustEncodeBool(true) (error)", errorState: true, fn: func() { enc.MustEncodeBool(true) }, expect: expect{panic: encerr}},
+		{spec: "MustEncodeBytes([]byte{1}) (error)", errorState: true, fn: func() { enc.MustEncodeBytes([]byte{1}) }, expect: expect{panic: encerr}},
+		{spec: "MustEncodeFloat32(1) (error)", errorState: true, fn: func() { enc.MustEncodeFloat32(1) }, expect: expect{panic: encerr}},
+		{spec: "MustEncodeFloat64(1) (error)", errorState: true, fn: func() { enc.MustEncodeFloat64(1) }, expect: expect{panic: encerr}},
+		{spec: "MustEncodeInt(-1) (error)", errorState: true, fn: func() { enc.MustEncodeInt(-1) }, expect: expect{panic: encerr}},
+		{spec: "MustEncodeInt64(-1) (error)", errorState: true, fn: func() { enc.MustEncodeInt64(-1) }, expect: expect{panic: encerr}},
+		{spec: "MustEncodeUint(1) (error)", errorState: true, fn: func() { enc.MustEncodeUint(1) }, expect: expect{panic: encerr}},
+		{spec: "MustEncodeUint64(1) (error)", errorState: true, fn: func() { enc.MustEncodeUint64(1) }, expect: expect{panic: encerr}},
+		{spec: "MustEncodeString(s) (error)", errorState: true, fn: func() { enc.MustEncodeString("s") }, expect: expect{panic: encerr}},
+		{spec: "MustEncodeTime(epoch) (error)", errorState: true, fn: func() { enc.MustEncodeTime(time.Unix(0, 0)) }, expect: expect{panic: encerr}},
+		{spec: "MustWriteArrayHeader(1) (error)", errorState: true, fn: func() { enc.MustWriteArrayHeader(1) }, expect: expect{panic: encerr}},
+		{spec: "MustWriteMapHeader(1) (error)", errorState: true, fn: func() { enc.MustWriteMapHeader(1) }, expect: expect{panic: encerr}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			defer buf.Reset()
+			defer func() { _ = enc.ResetError() }()
+
+			// ARRANGE
+			if tc.errorState {
+				*enc.err = encerr
+			}
+			defer testPanic(t, tc.expect.panic)
+
+			// ACT
+			tc.fn()
+
+			// ASSERT
+			wanted := tc.result
+			got := buf.Bytes()
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted: %x\ngot:    %x", wanted, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package msgpack
+
+import "time"
+
+// EncodeBoolPtr encodes a *bool to the current writer.  A nil pointer
+// is encoded as nil, otherwise the value referenced is encoded.
+//
+// The pointer helpers allow optional fields to be encoded without
+// a nil check at the call site.
+func (enc Encoder) EncodeBoolPtr(b *bool) error {
+	if b == nil {
+		return enc.EncodeNil()
+	}
+	return enc.EncodeBool(*b)
+}
+
+// EncodeFloat32Ptr encodes a *float32 to the current writer.  A nil
+// pointer is encoded as nil, otherwise the value referenced is encoded.
+func (enc Encoder) EncodeFloat32Ptr(f *float32) error {
+	if f == nil {
+		return enc.EncodeNil()
+	}
+	return enc.EncodeFloat32(*f)
+}
+
+// EncodeFloat64Ptr encodes a *float64 to the current writer.  A nil
+// pointer is encoded as nil, otherwise the value referenced is encoded.
+func (enc Encoder) EncodeFloat64Ptr(f *float64) error {
+	if f == nil {
+		return enc.EncodeNil()
+	}
+	return enc.EncodeFloat64(*f)
+}
+
+// EncodeIntPtr encodes an *int to the current writer.  A nil pointer
+// is encoded as nil, otherwise the value referenced is encoded.
+func (enc Encoder) EncodeIntPtr(i *int) error {
+	if i == nil {
+		return enc.EncodeNil()
+	}
+	return enc.EncodeInt(*i)
+}
+
+// EncodeInt8Ptr encodes an *int8 to the current writer.  A nil pointer
+// is encoded as nil, otherwise the value referenced is encoded.
+func (enc Encoder) EncodeInt8Ptr(i *int8) error {
+	if i == nil {
+		return enc.EncodeNil()
+	}
+	return enc.EncodeInt8(*i)
+}
+
+// EncodeInt16Ptr encodes an *int16 to the current writer.  A nil pointer
+// is encoded as nil, otherwise the value referenced is encoded.
+func (enc Encoder) EncodeInt16Ptr(i *int16) error {
+	if i == nil {
+		return enc.EncodeNil()
+	}
+	return enc.EncodeInt16(*i)
+}
+
+// EncodeInt32Ptr encodes an *int32 to the current writer.  A nil pointer
+// is encoded as nil, otherwise the value referenced is encoded.
+func (enc Encoder) EncodeInt32Ptr(i *int32) error {
+	if i == nil {
+		return enc.EncodeNil()
+	}
+	return enc.EncodeInt32(*i)
+}
+
+// EncodeInt64Ptr encodes an *int64 to the current writer.  A nil pointer
+// is encoded as nil, otherwise the value referenced is encoded.
+func (enc Encoder) EncodeInt64Ptr(i *int64) error {
+	if i == nil {
+		return enc.EncodeNil()
+	}
+	return enc.EncodeInt64(*i)
+}
+
+// EncodeUintPtr encodes a *uint to the current writer.  A nil pointer
+// is encoded as nil, otherwise the value referenced is encoded.
+func (enc Encoder) EncodeUintPtr(i *uint) error {
+	if i == nil {
+		return enc.EncodeNil()
+	}
+	return enc.EncodeUint(*i)
+}
+
+// EncodeUint8Ptr encodes a *uint8 to the current writer.  A nil pointer
+// is encoded as nil, otherwise the value referenced is encoded.
+func (enc Encoder) EncodeUint8Ptr(i *uint8) error {
+	if i == nil {
+		return enc.EncodeNil()
+	}
+	return enc.EncodeUint8(*i)
+}
+
+// EncodeUint16Ptr encodes a *uint16 to the current writer.  A nil pointer
+// is encoded as nil, otherwise the value referenced is encoded.
+func (enc Encoder) EncodeUint16Ptr(i *uint16) error {
+	if i == nil {
+		return enc.EncodeNil()
+	}
+	return enc.EncodeUint16(*i)
+}
+
+// EncodeUint32Ptr encodes a *uint32 to the current writer.  A nil pointer
+// is encoded as nil, otherwise the value referenced is encoded.
+func (enc Encoder) EncodeUint32Ptr(i *uint32) error {
+	if i == nil {
+		return enc.EncodeNil()
+	}
+	return enc.EncodeUint32(*i)
+}
+
+// EncodeUint64Ptr encodes a *uint64 to the current writer.  A nil pointer
+// is encoded as nil, otherwise the value referenced is encoded.
+func (enc Encoder) EncodeUint64Ptr(i *uint64) error {
+	if i == nil {
+		return enc.EncodeNil()
+	}
+	return enc.EncodeUint64(*i)
+}
+
+// EncodeStringPtr encodes a *string to the current writer.  A nil pointer
+// is encoded as nil, otherwise the string referenced is encoded.
+func (enc Encoder) EncodeStringPtr(s *string) error {
+	if s == nil {
+		return enc.EncodeNil()
+	}
+	return enc.EncodeString(*s)
+}
+
+// EncodeTimePtr encodes a *time.Time to the current writer.  A nil
+// pointer is encoded as nil, otherwise the time referenced is encoded
+// using the msgpack timestamp extension type.
+func (enc Encoder) EncodeTimePtr(t *time.Time) error {
+	if t == nil {
+		return enc.EncodeNil()
+	}
+	return enc.EncodeTime(*t)
+}
@@ -0,0 +1,82 @@
+package msgpack
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestEncoderPtrs(t *testing.T) {
+	// ARRANGE
+	enc, buf := NewTestEncoder()
+
+	b := true
+	f32 := float32(1)
+	f64 := float64(1)
+	i := 1
+	i8 := int8(-33)
+	i16 := int16(-129)
+	i32 := int32(-32769)
+	i64 := int64(-1)
+	u := uint(128)
+	u8 := uint8(255)
+	u16 := uint16(256)
+	u32 := uint32(1)
+	u64 := uint64(1)
+	s := "s"
+	tm := time.Unix(0, 0)
+
+	testcases := []struct {
+		spec   string // for information only, not part of the test
+		fn     func() error
+		result []byte
+	}{
+		{spec: "EncodeBoolPtr(nil)", fn: func() error { return enc.EncodeBoolPtr(nil) }, result: []byte{atomNil}},
+		{spec: "EncodeBoolPtr(&true)", fn: func() error { return enc.EncodeBoolPtr(&b) }, result: []byte{atomTrue}},
+		{spec: "EncodeFloat32Ptr(nil)", fn: func() error { return enc.EncodeFloat32Ptr(nil) }, result: []byte{atomNil}},
+		{spec: "EncodeFloat32Ptr(&1)", fn: func() error { return enc.EncodeFloat32Ptr(&f32) }, result: []byte{typeFloat32, 0x3f, 0x80, 0x00, 0x00}},
+		{spec: "EncodeFloat64Ptr(nil)", fn: func() error { return enc.EncodeFloat64Ptr(nil) }, result: []byte{atomNil}},
+		{spec: "EncodeFloat64Ptr(&1)", fn: func() error { return enc.EncodeFloat64Ptr(&f64) }, result: []byte{typeFloat64, 0x3f, 0xf0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{spec: "EncodeIntPtr(nil)", fn: func() error { return enc.EncodeIntPtr(nil) }, result: []byte{atomNil}},
+		{spec: "EncodeIntPtr(&1)", fn: func() error { return enc.EncodeIntPtr(&i) }, result: []byte{0x01}},
+		{spec: "EncodeInt8Ptr(nil)", fn: func() error { return enc.EncodeInt8Ptr(nil) }, result: []byte{atomNil}},
+		{spec: "EncodeInt8Ptr(&-33)", fn: func() error { return enc.EncodeInt8Ptr(&i8) }, result: []byte{typeInt8, 0xdf}},
+		{spec: "EncodeInt16Ptr(nil)", fn: func() error { return enc.EncodeInt16Ptr(nil) }, result: []byte{atomNil}},
+		{spec: "EncodeInt16Ptr(&-129)", fn: func() error { return enc.EncodeInt16Ptr(&i16) }, result: []byte{typeInt16, 0xff, 0x7f}},
+		{spec: "EncodeInt32Ptr(nil)", fn: func() error { return enc.EncodeInt32Ptr(nil) }, result: []byte{atomNil}},
+		{spec: "EncodeInt32Ptr(&-32769)", fn: func() error { return enc.EncodeInt32Ptr(&i32) }, result: []byte{typeInt32, 0xff, 0xff, 0x7f, 0xff}},
+		{spec: "EncodeInt64Ptr(nil)", fn: func() error { return enc.EncodeInt64Ptr(nil) }, result: []byte{atomNil}},
+		{spec: "EncodeInt64Ptr(&-1)", fn: func() error { return enc.EncodeInt64Ptr(&i64) }, result: []byte{0xff}},
+		{spec: "EncodeUintPtr(nil)", fn: func() error { return enc.EncodeUintPtr(nil) }, result: []byte{atomNil}},
+		{spec: "EncodeUintPtr(&128)", fn: func() error { return enc.EncodeUintPtr(&u) }, result: []byte{typeUint8, 0x80}},
+		{spec: "EncodeUint8Ptr(nil)", fn: func() error { return enc.EncodeUint8Ptr(nil) }, result: []byte{atomNil}},
+		{spec: "EncodeUint8Ptr(&255)", fn: func() error { return enc.EncodeUint8Ptr(&u8) }, result: []byte{typeUint8, 0xff}},
+		{spec: "EncodeUint16Ptr(nil)", fn: func() error { return enc.EncodeUint16Ptr(nil) }, result: []byte{atomNil}},
+		{spec: "EncodeUint16Ptr(&256)", fn: func() error { return enc.EncodeUint16Ptr(&u16) }, result: []byte{typeUint16, 0x01, 0x00}},
+		{spec: "EncodeUint32Ptr(nil)", fn: func() error { return enc.EncodeUint32Ptr(nil) }, result: []byte{atomNil}},
+		{spec: "EncodeUint32Ptr(&1)", fn: func() error { return enc.EncodeUint32Ptr(&u32) }, result: []byte{0x01}},
+		{spec: "EncodeUint64Ptr(nil)", fn: func() error { return enc.EncodeUint64Ptr(nil) }, result: []byte{atomNil}},
+		{spec: "EncodeUint64Ptr(&1)", fn: func() error { return enc.EncodeUint64Ptr(&u64) }, result: []byte{0x01}},
+		{spec: "EncodeStringPtr(nil)", fn: func() error { return enc.EncodeStringPtr(nil) }, result: []byte{atomNil}},
+		{spec: "EncodeStringPtr(&s)", fn: func() error { return enc.EncodeStringPtr(&s) }, result: []byte{maskFixString | 1, 's'}},
+		{spec: "EncodeTimePtr(nil)", fn: func() error { return enc.EncodeTimePtr(nil) }, result: []byte{atomNil}},
+		{spec: "EncodeTimePtr(&epoch)", fn: func() error { return enc.EncodeTimePtr(&tm) }, result: []byte{typeFixExt4, 0xff, 0x00, 0x00, 0x00, 0x00}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			defer buf.Reset()
+
+			// ACT
+			err := tc.fn()
+
+			// ASSERT
+			testError(t, nil, err)
+
+			wanted := tc.result
+			got := buf.Bytes()
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted: %x\ngot:    %x", wanted, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package msgpack
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// WithSequence encodes a sequence of values of unknown length as an
+// array, for producers such as database cursors that cannot determine
+// the number of values in advance.
+//
+// The function is called repeatedly; each call should either encode a
+// single value and return true, or encode nothing and return false to
+// indicate the end of the sequence.  The values are spooled to a
+// (pooled) buffer until the end of the sequence, when the array header
+// is written followed by the spooled values.  If the Encoder was created
+// with the SpoolThreshold option, values are spooled to a temporary file
+// once the size of the buffer would exceed the threshold.
+//
+// If the function returns an error, or an error is captured encoding a
+// value (by the Encoder passed to the function), nothing is written and
+// the error is returned.  Any limit on the size of the output (MaxSize)
+// applies to the values as they are spooled; if the limit is exceeded
+// the error is also captured by the Encoder.  Statistics (CollectStats)
+// are recorded for the values only once the sequence has been written.
+func (enc Encoder) WithSequence(fn func(Encoder) (bool, error)) error {
+	if err := enc.state(); err != nil {
+		return err
+	}
+
+	pe := getEncoder()
+	defer putEncoder(pe)
+
+	sp := &spool{buf: pe.out.(*bytes.Buffer), threshold: enc.spoolThreshold}
+	defer sp.close()
+
+	// values are encoded to the spool with a copy of the encoder; the
+	// copy has its own error state, counters and statistics (recorded
+	// only if the sequence is written) and is not traced (the spooled
+	// values are traced when copied to the output) and any container
+	// tracking applies only to containers within each value
+	el := enc
+	el.out = sp
+	el.err = new(error)
+	el.count = &counters{}
+	el.trace = nil
+	el.containers = nil
+	if enc.stats != nil {
+		el.stats = &stats{}
+	}
+
+	// any limit on the size of the output applies to the spooled values,
+	// so that they cannot exceed the space remaining for the output
+	if c := enc.count; c != nil && c.limit > 0 {
+		if err := enc.reserve(1); err != nil {
+			return enc.wrote(0, err)
+		}
+		el.count.limit = c.limit - c.total
+	}
+
+	n := int64(0)
+	for {
+		more, err := fn(el)
+		if err == nil {
+			err = el.state()
+		}
+		if err != nil {
+			if el.count.exceeded {
+				enc.count.exceeded = true
+				*enc.err = err
+			}
+			return err
+		}
+		if !more {
+			break
+		}
+		n++
+	}
+	if sp.err != nil {
+		return fmt.Errorf("WithSequence: %w", sp.err)
+	}
+	if err := checkLength("WithSequence", n); err != nil {
+		return err
+	}
+
+	// the array is a single value in any enclosing container
+	if enc.containers != nil {
+		enc.containers.value()
+	}
+	hdr := enc
+	hdr.containers = nil
+	if err := hdr.writeArrayHeader(n); err != nil {
+		return err
+	}
+
+	// the statistics for the values were recorded as they were spooled
+	body := enc
+	body.stats = nil
+	if err := sp.copyTo(&body); err != nil {
+		return err
+	}
+	if enc.stats != nil {
+		enc.stats.add(el.stats)
+	}
+	return nil
+}
+
+// spool is an io.Writer that buffers the values of a sequence in memory
+// or, once the buffered bytes would exceed a threshold, a temporary file.
+type spool struct {
+	buf       *bytes.Buffer
+	file      *os.File
+	threshold int64 // the maximum size of the buffer (0 = no maximum)
+	err       error // any error creating or writing the temporary file
+}
+
+// Write implements io.Writer.
+func (s *spool) Write(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+
+	if s.file == nil && s.threshold > 0 && int64(s.buf.Len()+len(p)) > s.threshold {
+		if s.file, s.err = os.CreateTemp("", "msgpack-spool-*"); s.err != nil {
+			return 0, s.err
+		}
+		if _, s.err = s.buf.WriteTo(s.file); s.err != nil {
+			return 0, s.err
+		}
+	}
+
+	if s.file == nil {
+		return s.buf.Write(p)
+	}
+
+	n, err := s.file.Write(p)
+	if err != nil {
+		s.err = err
+	}
+	return n, err
+}
+
+// copyTo writes the spooled bytes to an Encoder.
+func (s *spool) copyTo(enc *Encoder) error {
+	if s.file == nil {
+		return enc.writeBytes(s.buf.Bytes())
+	}
+
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("WithSequence: %w", err)
+	}
+
+	chunk := make([]byte, 32*1024)
+	for {
+		n, err := s.file.Read(chunk)
+		if n > 0 {
+			if err := enc.writeBytes(chunk[:n]); err != nil {
+				return err
+			}
+		}
+		switch {
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return fmt.Errorf("WithSequence: %w", err)
+		}
+	}
+}
+
+// close removes any temporary file.
+func (s *spool) close() {
+	if s.file != nil {
+		_ = s.file.Close()
+		_ = os.Remove(s.file.Name())
+	}
+}
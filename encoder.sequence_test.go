@@ -0,0 +1,212 @@
+package msgpack
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestEncoderWithSequence(t *testing.T) {
+	// sequence returns a function encoding the specified values as a
+	// sequence
+	sequence := func(values ...int) func(Encoder) (bool, error) {
+		return func(enc Encoder) (bool, error) {
+			if len(values) == 0 {
+				return false, nil
+			}
+			err := enc.EncodeInt(values[0])
+			values = values[1:]
+			return true, err
+		}
+	}
+
+	t.Run("empty sequence", func(t *testing.T) {
+		// ARRANGE
+		enc, buf := NewTestEncoder()
+
+		// ACT
+		err := enc.WithSequence(sequence())
+
+		// ASSERT
+		testError(t, nil, err)
+
+		wanted := []byte{atomEmptyArray}
+		got := buf.Bytes()
+		if !bytes.Equal(wanted, got) {
+			t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+		}
+	})
+
+	t.Run("sequence of values", func(t *testing.T) {
+		// ARRANGE
+		enc, buf := NewTestEncoder()
+
+		// ACT
+		err := enc.WithSequence(sequence(1, 2, 1024))
+
+		// ASSERT
+		testError(t, nil, err)
+
+		wanted := []byte{maskFixArray | 3, 0x01, 0x02, typeUint16, 0x04, 0x00}
+		got := buf.Bytes()
+		if !bytes.Equal(wanted, got) {
+			t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+		}
+	})
+
+	t.Run("when the function returns an error", func(t *testing.T) {
+		// ARRANGE
+		fnerr := errors.New("function error")
+		enc, buf := NewTestEncoder()
+
+		// ACT
+		err := enc.WithSequence(func(enc Encoder) (bool, error) {
+			_ = enc.EncodeInt(1)
+			return false, fnerr
+		})
+
+		// ASSERT
+		testError(t, fnerr, err)
+
+		wanted := []byte{}
+		got := buf.Bytes()
+		if !bytes.Equal(wanted, got) {
+			t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+		}
+	})
+
+	t.Run("when an error is captured encoding a value", func(t *testing.T) {
+		// ARRANGE
+		encerr := errors.New("encoder error")
+		enc, buf := NewTestEncoder()
+
+		// ACT
+		err := enc.WithSequence(func(enc Encoder) (bool, error) {
+			_ = enc.Using(&limitWriter{err: encerr}, func() error {
+				_ = enc.EncodeInt(1)
+				return nil
+			})
+			return false, nil
+		})
+
+		// ASSERT
+		testError(t, encerr, err)
+
+		if buf.Len() != 0 {
+			t.Errorf("\nwanted no output\ngot    %x", buf.Bytes())
+		}
+	})
+
+	t.Run("when the size of the output is limited", func(t *testing.T) {
+		// ARRANGE
+		buf := &bytes.Buffer{}
+		enc := NewEncoder(buf, MaxSize(8))
+		calls := 0
+
+		// ACT
+		err := enc.WithSequence(func(enc Encoder) (bool, error) {
+			calls++
+			return true, enc.EncodeInt(1)
+		})
+
+		// ASSERT
+		testError(t, ErrTooLarge, err)
+		testError(t, ErrTooLarge, enc.ResetError())
+
+		t.Run("stops spooling at the limit", func(t *testing.T) {
+			wanted := 9
+			got := calls
+			if wanted != got {
+				t.Errorf("\nwanted %d\ngot    %d", wanted, got)
+			}
+		})
+
+		t.Run("writes nothing", func(t *testing.T) {
+			if buf.Len() != 0 {
+				t.Errorf("\nwanted no output\ngot    %x", buf.Bytes())
+			}
+		})
+	})
+
+	t.Run("statistics", func(t *testing.T) {
+		// ARRANGE
+		enc := NewEncoder(&bytes.Buffer{}, CollectStats())
+
+		// ACT
+		_ = enc.WithSequence(func(enc Encoder) (bool, error) {
+			_ = enc.EncodeInt(1)
+			return false, errors.New("function error")
+		})
+		_ = enc.WithSequence(sequence(1, 2))
+
+		// ASSERT
+		wanted := EncoderStats{Int: 2, Array: 1, Bytes: 3}
+		got := enc.Stats()
+		if wanted != got {
+			t.Errorf("\nwanted %+v\ngot    %+v", wanted, got)
+		}
+	})
+
+	t.Run("when the encoder is in an error state", func(t *testing.T) {
+		// ARRANGE
+		encerr := errors.New("encoder error")
+		enc, _ := NewTestEncoder()
+		*enc.err = encerr
+		defer func() { _ = enc.ResetError() }()
+
+		// ACT
+		err := enc.WithSequence(sequence(1))
+
+		// ASSERT
+		testError(t, encerr, err)
+	})
+
+	t.Run("spooled to a temporary file", func(t *testing.T) {
+		// ARRANGE
+		dir := t.TempDir()
+		t.Setenv("TMPDIR", dir)
+
+		values := make([]int, 1000)
+		for i := range values {
+			values[i] = i
+		}
+		buf := &bytes.Buffer{}
+		enc := NewEncoder(buf, SpoolThreshold(16))
+
+		// ACT
+		err := enc.WithSequence(sequence(values...))
+
+		// ASSERT
+		testError(t, nil, err)
+
+		wanted := &bytes.Buffer{}
+		_ = EncodeArray(NewEncoder(wanted), values, nil)
+		if !bytes.Equal(wanted.Bytes(), buf.Bytes()) {
+			t.Errorf("\nwanted %#v\ngot    %#v", wanted.Bytes(), buf.Bytes())
+		}
+
+		t.Run("removes the temporary file", func(t *testing.T) {
+			files, _ := os.ReadDir(dir)
+			if len(files) != 0 {
+				t.Errorf("\nwanted no files\ngot    %v", files)
+			}
+		})
+	})
+
+	t.Run("in a tracked container", func(t *testing.T) {
+		// ARRANGE
+		enc := NewEncoder(&bytes.Buffer{}, TrackContainers())
+
+		// ACT
+		err := enc.WithArray(2, func(enc Encoder) error {
+			_ = enc.WithSequence(func(enc Encoder) (bool, error) {
+				return false, nil
+			})
+			return enc.WithSequence(sequence(1, 2))
+		})
+
+		// ASSERT
+		testError(t, nil, err)
+	})
+}
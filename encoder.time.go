@@ -0,0 +1,62 @@
+package msgpack
+
+import (
+	"fmt"
+	"time"
+)
+
+// EncodeTime encodes a time.Time value to the current writer using
+// the msgpack timestamp extension type (-1).
+//
+// The encoder will use the most efficient timestamp format for the
+// value being encoded:
+//
+//   - timestamp 32: whole seconds in the range 0..2^32-1
+//   - timestamp 64: seconds in the range 0..2^34-1 with nanoseconds
+//   - timestamp 96: any other time
+//
+// The location of the time is not encoded.
+//
+// The old msgpack spec has no extension types; if the Encoder was
+// created with the OldSpec option, nothing is written and an error
+// wrapping ErrUnsupportedType is returned.
+func (enc Encoder) EncodeTime(t time.Time) error {
+	if enc.oldSpec {
+		return fmt.Errorf("EncodeTime: %w: timestamps are not supported by the old spec", ErrUnsupportedType)
+	}
+	enc.track()
+
+	sec := t.Unix()
+	nsec := int64(t.Nanosecond())
+
+	// the header and data are written with a single write, so that
+	// nothing is written if the value cannot be written in full
+	p := enc.buffer()
+	switch {
+	case sec >= 0 && sec < (1<<32) && nsec == 0:
+		p = append(p, typeFixExt4, extTimestampByte)
+		p = appendUint32(p, uint32(sec))
+
+	case sec >= 0 && sec < (1<<34):
+		p = append(p, typeFixExt8, extTimestampByte)
+		p = appendUint64(p, uint64(nsec)<<34|uint64(sec))
+
+	default:
+		p = append(p, typeExt8, 12, extTimestampByte)
+		p = appendUint32(p, uint32(nsec))
+		p = appendUint64(p, uint64(sec))
+	}
+	return enc.writeBytes(p)
+}
+
+// appendUint32 appends a 32-bit value (big-endian) to p, returning the
+// extended slice.
+func appendUint32(p []byte, v uint32) []byte {
+	return append(p, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// appendUint64 appends a 64-bit value (big-endian) to p, returning the
+// extended slice.
+func appendUint64(p []byte, v uint64) []byte {
+	return append(p, byte(v>>56), byte(v>>48), byte(v>>40), byte(v>>32), byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
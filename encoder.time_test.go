@@ -0,0 +1,60 @@
+package msgpack
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEncodeTime(t *testing.T) {
+	// ARRANGE
+	enc, buf := NewTestEncoder()
+	encerr := errors.New("encoder error")
+
+	type expect struct {
+		result []byte
+		error
+	}
+	testcases := []struct {
+		spec       string // for information only, not part of the test
+		errorState bool   // true if the test case runs with the encoder in an error state
+		time.Time
+		expect
+	}{
+		{spec: "timestamp 32 (epoch)", Time: time.Unix(0, 0), expect: expect{result: []byte{typeFixExt4, 0xff, 0x00, 0x00, 0x00, 0x00}}},
+		{spec: "timestamp 32 (max)", Time: time.Unix(1<<32-1, 0), expect: expect{result: []byte{typeFixExt4, 0xff, 0xff, 0xff, 0xff, 0xff}}},
+		{spec: "timestamp 64 (1s + 1ns)", Time: time.Unix(1, 1), expect: expect{result: []byte{typeFixExt8, 0xff, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x01}}},
+		{spec: "timestamp 64 (2^32s)", Time: time.Unix(1<<32, 0), expect: expect{result: []byte{typeFixExt8, 0xff, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}}},
+		{spec: "timestamp 96 (-1s)", Time: time.Unix(-1, 0), expect: expect{result: []byte{typeExt8, 12, 0xff, 0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}}},
+		{spec: "timestamp 96 (2^34s + 1ns)", Time: time.Unix(1<<34, 1), expect: expect{result: []byte{typeExt8, 12, 0xff, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x00}}},
+		{spec: "timestamp 32 (error)", errorState: true, Time: time.Unix(0, 0), expect: expect{error: encerr}},
+		{spec: "timestamp 64 (error)", errorState: true, Time: time.Unix(1, 1), expect: expect{error: encerr}},
+		{spec: "timestamp 96 (error)", errorState: true, Time: time.Unix(-1, 0), expect: expect{error: encerr}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			defer buf.Reset()
+			defer func() { _ = enc.ResetError() }()
+
+			// ARRANGE
+			if tc.errorState {
+				*enc.err = encerr
+			}
+
+			// ACT
+			err := enc.Encode(tc.Time)
+
+			// ASSERT
+			testError(t, tc.expect.error, err)
+
+			t.Run("result", func(t *testing.T) {
+				wanted := tc.result
+				got := buf.Bytes()
+				if !bytes.Equal(wanted, got) {
+					t.Errorf("\nwanted: %x\ngot:    %x", wanted, got)
+				}
+			})
+		})
+	}
+}
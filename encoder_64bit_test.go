@@ -0,0 +1,74 @@
+//go:build !386 && !arm && !mips && !mipsle
+
+package msgpack
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestEncoder64Bit tests encoding int and uint values (and lengths) that
+// can only be represented by an int (or uint) on 64-bit platforms.
+func TestEncoder64Bit(t *testing.T) {
+	// ARRANGE
+	enc, buf := NewTestEncoder()
+	encerr := errors.New("encoder error")
+
+	type expect struct {
+		result []byte
+		error
+	}
+
+	testcases := []struct {
+		spec       string // for information only, not part of the test
+		errorState bool   // true if the test case runs with the encoder in an error state
+		fn         func() error
+		expect
+	}{
+		{spec: "Encode(-2147483649)", fn: func() error { return enc.Encode(-2147483649) }, expect: expect{result: []byte{typeInt64, 0xff, 0xff, 0xff, 0xff, 0x7f, 0xff, 0xff, 0xff}}},
+		{spec: "Encode(-9223372036854775808)", fn: func() error { return enc.Encode(-9223372036854775808) }, expect: expect{result: []byte{typeInt64, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}}},
+		{spec: "EncodeInt(-9223372036854775808)", fn: func() error { return enc.EncodeInt(-9223372036854775808) }, expect: expect{result: []byte{typeInt64, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}}},
+		{spec: "EncodeInt(-2147483649)", fn: func() error { return enc.EncodeInt(-2147483649) }, expect: expect{result: []byte{typeInt64, 0xff, 0xff, 0xff, 0xff, 0x7f, 0xff, 0xff, 0xff}}},
+		{spec: "EncodeInt(2147483648)", fn: func() error { return enc.EncodeInt(2147483648) }, expect: expect{result: []byte{typeUint32, 0x80, 0x00, 0x00, 0x00}}},
+		{spec: "EncodeInt(4294967295)", fn: func() error { return enc.EncodeInt(4294967295) }, expect: expect{result: []byte{typeUint32, 0xff, 0xff, 0xff, 0xff}}},
+		{spec: "EncodeInt(4294967296)", fn: func() error { return enc.EncodeInt(4294967296) }, expect: expect{result: []byte{typeUint64, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}}},
+		{spec: "EncodeInt(9223372036854775807)", fn: func() error { return enc.EncodeInt(9223372036854775807) }, expect: expect{result: []byte{typeUint64, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}}},
+		{spec: "EncodeInt(-9223372036854775808) (error)", errorState: true, fn: func() error { return enc.EncodeInt(-9223372036854775808) }, expect: expect{error: encerr}},
+		{spec: "EncodeInt(9223372036854775807) (error)", errorState: true, fn: func() error { return enc.EncodeInt(9223372036854775807) }, expect: expect{error: encerr}},
+		{spec: "EncodeUint(4294967296)", fn: func() error { return enc.EncodeUint(4294967296) }, expect: expect{result: []byte{typeUint64, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}}},
+		{spec: "EncodeUint(18446744073709551615)", fn: func() error { return enc.EncodeUint(18446744073709551615) }, expect: expect{result: []byte{typeUint64, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}}},
+		{spec: "EncodeUint(18446744073709551615) (error)", errorState: true, fn: func() error { return enc.EncodeUint(18446744073709551615) }, expect: expect{error: encerr}},
+		{spec: "WriteArrayHeader(4294967295)", fn: func() error { return enc.WriteArrayHeader(4294967295) }, expect: expect{result: []byte{0xdd, 0xff, 0xff, 0xff, 0xff}}},
+		{spec: "WriteArrayHeader(4294967295) (error)", errorState: true, fn: func() error { return enc.WriteArrayHeader(4294967295) }, expect: expect{error: encerr}},
+		{spec: "WriteMapHeader(4294967295)", fn: func() error { return enc.WriteMapHeader(4294967295) }, expect: expect{result: []byte{0xdf, 0xff, 0xff, 0xff, 0xff}}},
+		{spec: "WriteMapHeader(4294967295) (error)", errorState: true, fn: func() error { return enc.WriteMapHeader(4294967295) }, expect: expect{error: encerr}},
+		{spec: "WriteStringHeader(4294967295)", fn: func() error { return enc.WriteStringHeader(4294967295) }, expect: expect{result: []byte{0xdb, 0b11111111, 0b11111111, 0b11111111, 0b11111111}}},
+		{spec: "WriteStringHeader(4294967295) (error)", errorState: true, fn: func() error { return enc.WriteStringHeader(4294967295) }, expect: expect{error: encerr}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			defer buf.Reset()
+			defer func() { _ = enc.ResetError() }()
+
+			// ARRANGE
+			if tc.errorState {
+				*enc.err = encerr
+			}
+
+			// ACT
+			err := tc.fn()
+
+			// ASSERT
+			testError(t, tc.expect.error, err)
+
+			t.Run("result", func(t *testing.T) {
+				wanted := tc.result
+				got := buf.Bytes()
+				if !bytes.Equal(wanted, got) {
+					t.Errorf("\nwanted: %x\ngot:    %x", wanted, got)
+				}
+			})
+		})
+	}
+}
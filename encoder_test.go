@@ -5,8 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func NewTestEncoder() (Encoder, *bytes.Buffer) {
@@ -53,8 +56,6 @@ func TestEncoder(t *testing.T) {
 		{spec: "Encode(-32768)", fn: func() error { return enc.Encode(-32768) }, expect: expect{result: []byte{typeInt16, 0x80, 0x00}}},
 		{spec: "Encode(-32769)", fn: func() error { return enc.Encode(-32769) }, expect: expect{result: []byte{typeInt32, 0xff, 0xff, 0x7f, 0xff}}},
 		{spec: "Encode(-2147483648)", fn: func() error { return enc.Encode(-2147483648) }, expect: expect{result: []byte{typeInt32, 0x80, 0x00, 0x00, 0x00}}},
-		{spec: "Encode(-2147483649)", fn: func() error { return enc.Encode(-2147483649) }, expect: expect{result: []byte{typeInt64, 0xff, 0xff, 0xff, 0xff, 0x7f, 0xff, 0xff, 0xff}}},
-		{spec: "Encode(-9223372036854775808)", fn: func() error { return enc.Encode(-9223372036854775808) }, expect: expect{result: []byte{typeInt64, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}}},
 		{spec: "Encode(float32(3.1415927))", fn: func() error { return enc.Encode(float32(3.1415927)) }, expect: expect{result: []byte{typeFloat32, 0x40, 0x49, 0x0F, 0xDB}}},
 		{spec: "Encode(3.1415927)", fn: func() error { return enc.Encode(3.1415927) }, expect: expect{result: []byte{typeFloat64, 0x40, 0x09, 0x21, 0xfb, 0x5a, 0x7e, 0xd1, 0x97}}},
 		{spec: "Encode([]int{1,2})", fn: func() error { return enc.Encode([]int{1, 2}) }, expect: expect{result: []byte{maskFixArray | byte(2), 0x01, 0x02}}},
@@ -75,6 +76,23 @@ func TestEncoder(t *testing.T) {
 		{spec: "EncodeFixedInt(-33) (error)", errorState: true, fn: func() error { return enc.EncodeFixedInt(-33) }, expect: expect{panic: ErrValueOutOfRange}},
 		{spec: "EncodeFixedInt(0) (error)", errorState: true, fn: func() error { return enc.EncodeFixedInt(0) }, expect: expect{error: encerr}},
 		{spec: "EncodeFixedInt(128) (error)", errorState: true, fn: func() error { return enc.EncodeFixedInt(128) }, expect: expect{panic: ErrValueOutOfRange}},
+		{spec: "TryEncodeFixedInt(-33)", fn: func() error { return enc.TryEncodeFixedInt(-33) }, expect: expect{error: ErrValueOutOfRange}},
+		{spec: "TryEncodeFixedInt(-32)", fn: func() error { return enc.TryEncodeFixedInt(-32) }, expect: expect{result: []byte{0xe0}}},
+		{spec: "TryEncodeFixedInt(127)", fn: func() error { return enc.TryEncodeFixedInt(127) }, expect: expect{result: []byte{0x7f}}},
+		{spec: "TryEncodeFixedInt(128)", fn: func() error { return enc.TryEncodeFixedInt(128) }, expect: expect{error: ErrValueOutOfRange}},
+		{spec: "TryEncodeFixedInt(0) (error)", errorState: true, fn: func() error { return enc.TryEncodeFixedInt(0) }, expect: expect{error: encerr}},
+		{spec: "TryEncodeFixedInt(128) (error)", errorState: true, fn: func() error { return enc.TryEncodeFixedInt(128) }, expect: expect{error: ErrValueOutOfRange}},
+		// negative fixint
+		{spec: "EncodeNegFixInt(-33)", fn: func() error { return enc.EncodeNegFixInt(-33) }, expect: expect{panic: ErrValueOutOfRange}},
+		{spec: "EncodeNegFixInt(-32)", fn: func() error { return enc.EncodeNegFixInt(-32) }, expect: expect{result: []byte{0xe0}}},
+		{spec: "EncodeNegFixInt(-1)", fn: func() error { return enc.EncodeNegFixInt(-1) }, expect: expect{result: []byte{0xff}}},
+		{spec: "EncodeNegFixInt(0)", fn: func() error { return enc.EncodeNegFixInt(0) }, expect: expect{panic: ErrValueOutOfRange}},
+		{spec: "EncodeNegFixInt(-1) (error)", errorState: true, fn: func() error { return enc.EncodeNegFixInt(-1) }, expect: expect{error: encerr}},
+		// positive fixint
+		{spec: "EncodeFixedUint(0)", fn: func() error { return enc.EncodeFixedUint(0) }, expect: expect{result: []byte{0x00}}},
+		{spec: "EncodeFixedUint(127)", fn: func() error { return enc.EncodeFixedUint(127) }, expect: expect{result: []byte{0x7f}}},
+		{spec: "EncodeFixedUint(128)", fn: func() error { return enc.EncodeFixedUint(128) }, expect: expect{panic: ErrValueOutOfRange}},
+		{spec: "EncodeFixedUint(0) (error)", errorState: true, fn: func() error { return enc.EncodeFixedUint(0) }, expect: expect{error: encerr}},
 		// int8
 		{spec: "EncodeInt8(-128)", fn: func() error { return enc.EncodeInt8(-128) }, expect: expect{result: []byte{typeInt8, 0x80}}},
 		{spec: "EncodeInt8(-33)", fn: func() error { return enc.EncodeInt8(-33) }, expect: expect{result: []byte{typeInt8, 0xdf}}},
@@ -155,8 +173,6 @@ func TestEncoder(t *testing.T) {
 		{spec: "EncodeInt64(2147483647) (error)", errorState: true, fn: func() error { return enc.EncodeInt64(2147483647) }, expect: expect{error: encerr}},
 		{spec: "EncodeInt64(9223372036854775807) (error)", errorState: true, fn: func() error { return enc.EncodeInt64(9223372036854775807) }, expect: expect{error: encerr}},
 		// int
-		{spec: "EncodeInt(-9223372036854775808)", fn: func() error { return enc.EncodeInt(-9223372036854775808) }, expect: expect{result: []byte{typeInt64, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}}},
-		{spec: "EncodeInt(-2147483649)", fn: func() error { return enc.EncodeInt(-2147483649) }, expect: expect{result: []byte{typeInt64, 0xff, 0xff, 0xff, 0xff, 0x7f, 0xff, 0xff, 0xff}}},
 		{spec: "EncodeInt(-2147483648)", fn: func() error { return enc.EncodeInt(-2147483648) }, expect: expect{result: []byte{typeInt32, 0x80, 0x00, 0x00, 0x00}}},
 		{spec: "EncodeInt(-32769)", fn: func() error { return enc.EncodeInt(-32769) }, expect: expect{result: []byte{typeInt32, 0xff, 0xff, 0x7f, 0xff}}},
 		{spec: "EncodeInt(-32768)", fn: func() error { return enc.EncodeInt(-32768) }, expect: expect{result: []byte{typeInt16, 0x80, 0x00}}},
@@ -170,11 +186,6 @@ func TestEncoder(t *testing.T) {
 		{spec: "EncodeInt(32767)", fn: func() error { return enc.EncodeInt(32767) }, expect: expect{result: []byte{typeUint16, 0x7f, 0xff}}},
 		{spec: "EncodeInt(32768)", fn: func() error { return enc.EncodeInt(32768) }, expect: expect{result: []byte{typeUint16, 0x80, 0x00}}},
 		{spec: "EncodeInt(2147483647)", fn: func() error { return enc.EncodeInt(2147483647) }, expect: expect{result: []byte{typeUint32, 0x7f, 0xff, 0xff, 0xff}}},
-		{spec: "EncodeInt(2147483648)", fn: func() error { return enc.EncodeInt(2147483648) }, expect: expect{result: []byte{typeUint32, 0x80, 0x00, 0x00, 0x00}}},
-		{spec: "EncodeInt(4294967295)", fn: func() error { return enc.EncodeInt(4294967295) }, expect: expect{result: []byte{typeUint32, 0xff, 0xff, 0xff, 0xff}}},
-		{spec: "EncodeInt(4294967296)", fn: func() error { return enc.EncodeInt(4294967296) }, expect: expect{result: []byte{typeUint64, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}}},
-		{spec: "EncodeInt(9223372036854775807)", fn: func() error { return enc.EncodeInt(9223372036854775807) }, expect: expect{result: []byte{typeUint64, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}}},
-		{spec: "EncodeInt(-9223372036854775808) (error)", errorState: true, fn: func() error { return enc.EncodeInt(-9223372036854775808) }, expect: expect{error: encerr}},
 		{spec: "EncodeInt(-2147483648) (error)", errorState: true, fn: func() error { return enc.EncodeInt(-2147483648) }, expect: expect{error: encerr}},
 		{spec: "EncodeInt(-32768) (error)", errorState: true, fn: func() error { return enc.EncodeInt(-32768) }, expect: expect{error: encerr}},
 		{spec: "EncodeInt(-128) (error)", errorState: true, fn: func() error { return enc.EncodeInt(-128) }, expect: expect{error: encerr}},
@@ -182,7 +193,6 @@ func TestEncoder(t *testing.T) {
 		{spec: "EncodeInt(127) (error)", errorState: true, fn: func() error { return enc.EncodeInt(127) }, expect: expect{error: encerr}},
 		{spec: "EncodeInt(32767) (error)", errorState: true, fn: func() error { return enc.EncodeInt(32767) }, expect: expect{error: encerr}},
 		{spec: "EncodeInt(2147483647) (error)", errorState: true, fn: func() error { return enc.EncodeInt(2147483647) }, expect: expect{error: encerr}},
-		{spec: "EncodeInt(9223372036854775807) (error)", errorState: true, fn: func() error { return enc.EncodeInt(9223372036854775807) }, expect: expect{error: encerr}},
 		// uint8
 		{spec: "EncodeUint8(0)", fn: func() error { return enc.EncodeUint8(0) }, expect: expect{result: []byte{0x00}}},
 		{spec: "EncodeUint8(127)", fn: func() error { return enc.EncodeUint8(127) }, expect: expect{result: []byte{0x7f}}},
@@ -238,13 +248,10 @@ func TestEncoder(t *testing.T) {
 		{spec: "EncodeUint(65535)", fn: func() error { return enc.EncodeUint(65535) }, expect: expect{result: []byte{typeUint16, 0xff, 0xff}}},
 		{spec: "EncodeUint(65536)", fn: func() error { return enc.EncodeUint(65536) }, expect: expect{result: []byte{typeUint32, 0x00, 0x01, 0x00, 0x00}}},
 		{spec: "EncodeUint(4294967295)", fn: func() error { return enc.EncodeUint(4294967295) }, expect: expect{result: []byte{typeUint32, 0xff, 0xff, 0xff, 0xff}}},
-		{spec: "EncodeUint(4294967296)", fn: func() error { return enc.EncodeUint(4294967296) }, expect: expect{result: []byte{typeUint64, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}}},
-		{spec: "EncodeUint(18446744073709551615)", fn: func() error { return enc.EncodeUint(18446744073709551615) }, expect: expect{result: []byte{typeUint64, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}}},
 		{spec: "EncodeUint(0) (error)", errorState: true, fn: func() error { return enc.EncodeUint(0) }, expect: expect{error: encerr}},
 		{spec: "EncodeUint(255) (error)", errorState: true, fn: func() error { return enc.EncodeUint(255) }, expect: expect{error: encerr}},
 		{spec: "EncodeUint(65535) (error)", errorState: true, fn: func() error { return enc.EncodeUint(65535) }, expect: expect{error: encerr}},
 		{spec: "EncodeUint(4294967295) (error)", errorState: true, fn: func() error { return enc.EncodeUint(4294967295) }, expect: expect{error: encerr}},
-		{spec: "EncodeUint(18446744073709551615) (error)", errorState: true, fn: func() error { return enc.EncodeUint(18446744073709551615) }, expect: expect{error: encerr}},
 
 		// float family
 		// float32
@@ -277,14 +284,12 @@ func TestEncoder(t *testing.T) {
 		{spec: "WriteArrayHeader(16)", fn: func() error { return enc.WriteArrayHeader(16) }, expect: expect{result: []byte{0xdc, 0x00, 0x10}}},
 		{spec: "WriteArrayHeader(65535)", fn: func() error { return enc.WriteArrayHeader(65535) }, expect: expect{result: []byte{0xdc, 0xff, 0xff}}},
 		{spec: "WriteArrayHeader(65536)", fn: func() error { return enc.WriteArrayHeader(65536) }, expect: expect{result: []byte{0xdd, 0x00, 0x01, 0x00, 0x00}}},
-		{spec: "WriteArrayHeader(4294967295)", fn: func() error { return enc.WriteArrayHeader(4294967295) }, expect: expect{result: []byte{0xdd, 0xff, 0xff, 0xff, 0xff}}},
 		{spec: "WriteArrayHeader(0) (error)", errorState: true, fn: func() error { return enc.WriteArrayHeader(0) }, expect: expect{error: encerr}},
 		{spec: "WriteArrayHeader(1) (error)", errorState: true, fn: func() error { return enc.WriteArrayHeader(1) }, expect: expect{error: encerr}},
 		{spec: "WriteArrayHeader(15) (error)", errorState: true, fn: func() error { return enc.WriteArrayHeader(15) }, expect: expect{error: encerr}},
 		{spec: "WriteArrayHeader(16) (error)", errorState: true, fn: func() error { return enc.WriteArrayHeader(16) }, expect: expect{error: encerr}},
 		{spec: "WriteArrayHeader(65535) (error)", errorState: true, fn: func() error { return enc.WriteArrayHeader(65535) }, expect: expect{error: encerr}},
 		{spec: "WriteArrayHeader(65536) (error)", errorState: true, fn: func() error { return enc.WriteArrayHeader(65536) }, expect: expect{error: encerr}},
-		{spec: "WriteArrayHeader(4294967295) (error)", errorState: true, fn: func() error { return enc.WriteArrayHeader(4294967295) }, expect: expect{error: encerr}},
 		// begin map
 		{spec: "WriteMapHeader(0)", fn: func() error { return enc.WriteMapHeader(0) }, expect: expect{result: []byte{0x80}}},
 		{spec: "WriteMapHeader(1)", fn: func() error { return enc.WriteMapHeader(1) }, expect: expect{result: []byte{0x81}}},
@@ -292,14 +297,12 @@ func TestEncoder(t *testing.T) {
 		{spec: "WriteMapHeader(16)", fn: func() error { return enc.WriteMapHeader(16) }, expect: expect{result: []byte{0xde, 0x00, 0x10}}},
 		{spec: "WriteMapHeader(65535)", fn: func() error { return enc.WriteMapHeader(65535) }, expect: expect{result: []byte{0xde, 0xff, 0xff}}},
 		{spec: "WriteMapHeader(65536)", fn: func() error { return enc.WriteMapHeader(65536) }, expect: expect{result: []byte{0xdf, 0x00, 0x01, 0x00, 0x00}}},
-		{spec: "WriteMapHeader(4294967295)", fn: func() error { return enc.WriteMapHeader(4294967295) }, expect: expect{result: []byte{0xdf, 0xff, 0xff, 0xff, 0xff}}},
 		{spec: "WriteMapHeader(0) (error)", errorState: true, fn: func() error { return enc.WriteMapHeader(0) }, expect: expect{error: encerr}},
 		{spec: "WriteMapHeader(1) (error)", errorState: true, fn: func() error { return enc.WriteMapHeader(1) }, expect: expect{error: encerr}},
 		{spec: "WriteMapHeader(15) (error)", errorState: true, fn: func() error { return enc.WriteMapHeader(15) }, expect: expect{error: encerr}},
 		{spec: "WriteMapHeader(16) (error)", errorState: true, fn: func() error { return enc.WriteMapHeader(16) }, expect: expect{error: encerr}},
 		{spec: "WriteMapHeader(65535) (error)", errorState: true, fn: func() error { return enc.WriteMapHeader(65535) }, expect: expect{error: encerr}},
 		{spec: "WriteMapHeader(65536) (error)", errorState: true, fn: func() error { return enc.WriteMapHeader(65536) }, expect: expect{error: encerr}},
-		{spec: "WriteMapHeader(4294967295) (error)", errorState: true, fn: func() error { return enc.WriteMapHeader(4294967295) }, expect: expect{error: encerr}},
 		// begin string
 		{spec: "WriteStringHeader(0)", fn: func() error { return enc.WriteStringHeader(0) }, expect: expect{result: []byte{0b10100000}}},
 		{spec: "WriteStringHeader(1)", fn: func() error { return enc.WriteStringHeader(1) }, expect: expect{result: []byte{0b10100001}}},
@@ -310,7 +313,6 @@ func TestEncoder(t *testing.T) {
 		{spec: "WriteStringHeader(65535)", fn: func() error { return enc.WriteStringHeader(65535) }, expect: expect{result: []byte{0xda, 0b11111111, 0b11111111}}},
 		{spec: "WriteStringHeader(65536)", fn: func() error { return enc.WriteStringHeader(65536) }, expect: expect{result: []byte{0xdb, 0b00000000, 0b00000001, 0b00000000, 0b00000000}}},
 		{spec: "WriteStringHeader(16777216)", fn: func() error { return enc.WriteStringHeader(16777216) }, expect: expect{result: []byte{0xdb, 0b00000001, 0b00000000, 0b00000000, 0b00000000}}},
-		{spec: "WriteStringHeader(4294967295)", fn: func() error { return enc.WriteStringHeader(4294967295) }, expect: expect{result: []byte{0xdb, 0b11111111, 0b11111111, 0b11111111, 0b11111111}}},
 		{spec: "WriteStringHeader(0) (error)", errorState: true, fn: func() error { return enc.WriteStringHeader(0) }, expect: expect{error: encerr}},
 		{spec: "WriteStringHeader(1) (error)", errorState: true, fn: func() error { return enc.WriteStringHeader(1) }, expect: expect{error: encerr}},
 		{spec: "WriteStringHeader(31) (error)", errorState: true, fn: func() error { return enc.WriteStringHeader(31) }, expect: expect{error: encerr}},
@@ -320,7 +322,16 @@ func TestEncoder(t *testing.T) {
 		{spec: "WriteStringHeader(65535) (error)", errorState: true, fn: func() error { return enc.WriteStringHeader(65535) }, expect: expect{error: encerr}},
 		{spec: "WriteStringHeader(65536) (error)", errorState: true, fn: func() error { return enc.WriteStringHeader(65536) }, expect: expect{error: encerr}},
 		{spec: "WriteStringHeader(16777216) (error)", errorState: true, fn: func() error { return enc.WriteStringHeader(16777216) }, expect: expect{error: encerr}},
-		{spec: "WriteStringHeader(4294967295) (error)", errorState: true, fn: func() error { return enc.WriteStringHeader(4294967295) }, expect: expect{error: encerr}},
+
+		// string bytes
+		{spec: "EncodeStringBytes(nil)", fn: func() error { return enc.EncodeStringBytes(nil) }, expect: expect{result: []byte{atomEmptyString}}},
+		{spec: "EncodeStringBytes([]byte(\"abc\"))", fn: func() error { return enc.EncodeStringBytes([]byte("abc")) }, expect: expect{result: []byte{0xa3, 'a', 'b', 'c'}}},
+		{spec: "EncodeStringBytes([]byte(\"abc\")) (error)", errorState: true, fn: func() error { return enc.EncodeStringBytes([]byte("abc")) }, expect: expect{error: encerr}},
+
+		// bytes from string
+		{spec: "EncodeBytesFromString(\"\")", fn: func() error { return enc.EncodeBytesFromString("") }, expect: expect{result: []byte{typeBin8, 0x00}}},
+		{spec: "EncodeBytesFromString(\"abc\")", fn: func() error { return enc.EncodeBytesFromString("abc") }, expect: expect{result: []byte{typeBin8, 0x03, 'a', 'b', 'c'}}},
+		{spec: "EncodeBytesFromString(\"abc\") (error)", errorState: true, fn: func() error { return enc.EncodeBytesFromString("abc") }, expect: expect{error: encerr}},
 
 		// low level writer
 		// write (byte)
@@ -350,7 +361,7 @@ func TestEncoder(t *testing.T) {
 
 			// ARRANGE
 			if tc.errorState {
-				enc.err = encerr
+				*enc.err = encerr
 			}
 			defer testPanic(t, tc.expect.panic)
 
@@ -379,7 +390,7 @@ func TestEncoder(t *testing.T) {
 		testcases := []struct {
 			spec       string
 			errorState bool
-			len        int
+			len        int64
 			expect
 			skip bool
 		}{
@@ -410,10 +421,10 @@ func TestEncoder(t *testing.T) {
 
 				// ARRANGE
 				if tc.errorState {
-					enc.err = encerr
+					*enc.err = encerr
 				}
 
-				b := bytes.Repeat([]byte{0x01}, tc.len)
+				b := bytes.Repeat([]byte{0x01}, int(tc.len))
 
 				// ACT
 				err := enc.EncodeBytes(b)
@@ -476,7 +487,7 @@ func TestEncoder(t *testing.T) {
 
 				// ARRANGE
 				if tc.errorState {
-					enc.err = encerr
+					*enc.err = encerr
 				}
 
 				s := strings.Repeat("a", int(tc.len))
@@ -498,7 +509,7 @@ func TestEncoder(t *testing.T) {
 
 	t.Run("ResetError", func(t *testing.T) {
 		// ARRANGE
-		enc.err = encerr
+		*enc.err = encerr
 
 		// ACT
 		err := enc.ResetError()
@@ -514,7 +525,7 @@ func TestEncoder(t *testing.T) {
 
 		t.Run("clears the error", func(t *testing.T) {
 			wanted := error(nil)
-			got := enc.err
+			got := *enc.err
 			if !errors.Is(got, wanted) {
 				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
 			}
@@ -523,12 +534,12 @@ func TestEncoder(t *testing.T) {
 
 	t.Run("SetWriter", func(t *testing.T) {
 		// ARRANGE
-		enc.err = encerr
+		*enc.err = encerr
 		enc.out = buf
 		defer func() { enc.out = buf }()
 
 		// ACT
-		enc.SetWriter(io.Discard)
+		og := enc.SetWriter(io.Discard)
 
 		// ASSERT
 		t.Run("sets output", func(t *testing.T) {
@@ -538,11 +549,71 @@ func TestEncoder(t *testing.T) {
 				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
 			}
 		})
+
+		t.Run("returns previous output", func(t *testing.T) {
+			wanted := io.Writer(buf)
+			got := og
+			if wanted != got {
+				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+			}
+		})
+	})
+
+	t.Run("SetWriter(nil)", func(t *testing.T) {
+		// ARRANGE
+		enc.out = buf
+		defer func() { enc.out = buf }()
+		defer testPanic(t, ErrNilWriter)
+
+		// ACT
+		enc.SetWriter(nil)
+	})
+
+	t.Run("Tee", func(t *testing.T) {
+		// ARRANGE
+		*enc.err = nil
+		enc.out = buf
+		buf.Reset()
+		spool := &bytes.Buffer{}
+		defer func() { enc.out = buf }()
+
+		// ACT
+		og := enc.Tee(spool)
+		_ = enc.Encode(1)
+		enc.SetWriter(og)
+		_ = enc.Encode(2)
+
+		// ASSERT
+		t.Run("writes to original writer", func(t *testing.T) {
+			wanted := []byte{0x01, 0x02}
+			got := buf.Bytes()
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+			}
+		})
+
+		t.Run("writes to additional writer", func(t *testing.T) {
+			wanted := []byte{0x01}
+			got := spool.Bytes()
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+			}
+		})
+	})
+
+	t.Run("Tee(nil)", func(t *testing.T) {
+		// ARRANGE
+		enc.out = buf
+		defer func() { enc.out = buf }()
+		defer testPanic(t, ErrNilWriter)
+
+		// ACT
+		enc.Tee(nil)
 	})
 
 	t.Run("Using", func(t *testing.T) {
 		// ARRANGE
-		enc.err = nil
+		*enc.err = nil
 		enc.out = buf
 		buf.Reset()
 		other := &bytes.Buffer{}
@@ -565,7 +636,7 @@ func TestEncoder(t *testing.T) {
 
 		t.Run("sets encoder error", func(t *testing.T) {
 			wanted := encerr
-			got := enc.err
+			got := *enc.err
 			if !errors.Is(got, wanted) {
 				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
 			}
@@ -580,11 +651,509 @@ func TestEncoder(t *testing.T) {
 		})
 
 		t.Run("encoded to specified writer", func(t *testing.T) {
-			wanted := []byte{typeUint8, 0x05, 0xd4}
+			wanted := []byte{typeUint16, 0x05, 0xd4}
 			got := other.Bytes()
 			if !bytes.Equal(wanted, got) {
 				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
 			}
 		})
 	})
+
+	t.Run("Using (error captured and returned)", func(t *testing.T) {
+		// ARRANGE
+		*enc.err = nil
+		enc.out = buf
+		defer func() { enc.out = buf; *enc.err = nil }()
+		fnerr := errors.New("callback error")
+
+		// ACT
+		err := enc.Using(&limitWriter{err: encerr}, func() error {
+			_ = enc.Encode(1)
+			return fnerr
+		})
+
+		// ASSERT
+		testError(t, encerr, err)
+
+		wanted := (&WriteError{Err: encerr}).Error() + "; callback: callback error"
+		got := err.Error()
+		if wanted != got {
+			t.Errorf("\nwanted %q\ngot    %q", wanted, got)
+		}
+	})
+
+	t.Run("Using (callback error is reachable)", func(t *testing.T) {
+		// ARRANGE
+		*enc.err = nil
+		enc.out = buf
+		defer func() { enc.out = buf; *enc.err = nil }()
+		fnerr := errors.New("callback error")
+
+		// ACT
+		err := enc.Using(&limitWriter{err: encerr}, func() error {
+			_ = enc.Encode(1)
+			return fmt.Errorf("wrapped: %w", fnerr)
+		})
+
+		// ASSERT
+		testError(t, fnerr, err)
+
+		var werr *WriteError
+		if !errors.As(err, &werr) {
+			t.Errorf("\nwanted *WriteError\ngot    %#v", err)
+		}
+	})
+
+	t.Run("Using (callback returns the captured error)", func(t *testing.T) {
+		// ARRANGE
+		*enc.err = nil
+		enc.out = buf
+		defer func() { enc.out = buf; *enc.err = nil }()
+
+		// ACT
+		err := enc.Using(&limitWriter{err: encerr}, func() error {
+			return enc.Encode(1)
+		})
+
+		// ASSERT
+		testError(t, encerr, err)
+
+		wanted := (&WriteError{Err: encerr}).Error()
+		got := err.Error()
+		if wanted != got {
+			t.Errorf("\nwanted %q\ngot    %q", wanted, got)
+		}
+	})
+
+	t.Run("Using (when in error state)", func(t *testing.T) {
+		// ARRANGE
+		*enc.err = encerr
+		enc.out = buf
+		buf.Reset()
+		other := &bytes.Buffer{}
+		defer func() { enc.out = buf; *enc.err = nil }()
+		called := false
+
+		// ACT
+		err := enc.Using(other, func() error {
+			called = true
+			return nil
+		})
+
+		// ASSERT
+		testError(t, encerr, err)
+
+		t.Run("does not call function", func(t *testing.T) {
+			if called {
+				t.Error("function was called")
+			}
+		})
+	})
+
+	t.Run("Using (nested)", func(t *testing.T) {
+		// ARRANGE
+		*enc.err = nil
+		enc.out = buf
+		buf.Reset()
+		outer := &bytes.Buffer{}
+		inner := &bytes.Buffer{}
+		defer func() { enc.out = buf; *enc.err = nil }()
+
+		// ACT
+		err := enc.Using(outer, func() error {
+			_ = enc.Encode(1)
+			_ = enc.Using(inner, func() error {
+				_ = enc.Encode(2)
+				return encerr
+			})
+			_ = enc.Encode(3)
+			return nil
+		})
+
+		// ASSERT
+		t.Run("returns inner error", func(t *testing.T) {
+			testError(t, encerr, err)
+		})
+
+		t.Run("retains inner error", func(t *testing.T) {
+			testError(t, encerr, *enc.err)
+		})
+
+		t.Run("restores writers", func(t *testing.T) {
+			wanted := io.Writer(buf)
+			got := enc.out
+			if wanted != got {
+				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+			}
+
+			if !bytes.Equal([]byte{0x01}, outer.Bytes()) {
+				t.Errorf("\nouter: wanted %#v\ngot    %#v", []byte{0x01}, outer.Bytes())
+			}
+			if !bytes.Equal([]byte{0x02}, inner.Bytes()) {
+				t.Errorf("\ninner: wanted %#v\ngot    %#v", []byte{0x02}, inner.Bytes())
+			}
+		})
+	})
+}
+
+func TestEncoderZeroValue(t *testing.T) {
+	t.Run("with writer set", func(t *testing.T) {
+		// ARRANGE
+		var enc Encoder
+		buf := &bytes.Buffer{}
+		enc.SetWriter(buf)
+
+		// ACT
+		err := enc.Encode(1)
+
+		// ASSERT
+		testError(t, nil, err)
+
+		wanted := []byte{0x01}
+		got := buf.Bytes()
+		if !bytes.Equal(wanted, got) {
+			t.Errorf("\nwanted %x\ngot    %x", wanted, got)
+		}
+	})
+
+	t.Run("error state", func(t *testing.T) {
+		// ARRANGE
+		var enc Encoder
+		w := &failingWriter{fails: 1, err: errors.New("writer error")}
+		enc.SetWriter(w)
+
+		// ACT
+		_ = enc.Encode(1)
+		err := enc.Encode(2)
+
+		// ASSERT
+		testError(t, w.err, err)
+		testError(t, w.err, enc.ResetError())
+		if w.buf.Len() != 0 {
+			t.Errorf("\nwanted no output\ngot    %x", w.buf.Bytes())
+		}
+	})
+
+	t.Run("with no writer", func(t *testing.T) {
+		// ARRANGE
+		var enc Encoder
+
+		// ACT
+		err := enc.Encode(1)
+
+		// ASSERT
+		testError(t, ErrNilWriter, err)
+		testError(t, nil, enc.ResetError())
+	})
+}
+
+func TestEncoderWriteError(t *testing.T) {
+	// ARRANGE
+	encerr := errors.New("writer error")
+
+	testcases := []struct {
+		spec    string // for information only, not part of the test
+		limit   int    // number of bytes accepted by the writer
+		fn      func(Encoder) error
+		written int
+	}{
+		{spec: "EncodeInt(1024), no bytes written", limit: 0, fn: func(enc Encoder) error { return enc.EncodeInt(1024) }, written: 0},
+		{spec: "EncodeInt(1024), type written", limit: 1, fn: func(enc Encoder) error { return enc.EncodeInt(1024) }, written: 1},
+		{spec: "EncodeInt(1024), after complete value", limit: 1, fn: func(enc Encoder) error { _ = enc.EncodeInt(1); return enc.EncodeInt(1024) }, written: 0},
+		{spec: "EncodeString(hello), header + 2 bytes written", limit: 3, fn: func(enc Encoder) error { return enc.EncodeString("hello") }, written: 3},
+		{spec: "WriteArrayHeader(16), type written", limit: 1, fn: func(enc Encoder) error { return enc.WriteArrayHeader(16) }, written: 1},
+		{spec: "EncodeBytes(..x4), header + 1 byte written", limit: 3, fn: func(enc Encoder) error { return enc.EncodeBytes([]byte{1, 2, 3, 4}) }, written: 3},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			// ARRANGE
+			w := &limitWriter{limit: tc.limit, err: encerr}
+			enc := NewEncoder(w)
+
+			// ACT
+			err := tc.fn(enc)
+
+			// ASSERT
+			testError(t, encerr, err)
+
+			t.Run("bytes of value written", func(t *testing.T) {
+				var werr *WriteError
+				if !errors.As(err, &werr) {
+					t.Fatalf("\nwanted *WriteError\ngot    %T", err)
+				}
+
+				wanted := tc.written
+				got := werr.Written
+				if wanted != got {
+					t.Errorf("\nwanted %d\ngot    %d", wanted, got)
+				}
+
+				if werr.Partial() != (tc.written > 0) {
+					t.Errorf("\nPartial() returned %v", werr.Partial())
+				}
+			})
+		})
+	}
+
+	t.Run("first write fails", func(t *testing.T) {
+		// ARRANGE
+		w := &failingWriter{fails: 1, err: encerr}
+		enc := NewEncoder(w)
+
+		// ACT
+		err := enc.EncodeInt(1024)
+		_ = enc.EncodeInt(1)
+
+		// ASSERT
+		testError(t, encerr, err)
+
+		t.Run("nothing written", func(t *testing.T) {
+			if w.buf.Len() != 0 {
+				t.Errorf("\nwanted no output\ngot    %x", w.buf.Bytes())
+			}
+		})
+
+		t.Run("error retained by copies", func(t *testing.T) {
+			cpy := enc
+			testError(t, encerr, cpy.EncodeNil())
+			testError(t, encerr, enc.ResetError())
+			testError(t, nil, cpy.EncodeNil())
+		})
+	})
+}
+
+func TestEncoderAllocations(t *testing.T) {
+	// ARRANGE
+	enc := NewEncoder(io.Discard)
+	bin := []byte("bytes")
+	ts := time.Unix(1, 1)
+	buf := make([]byte, 0, 64)
+
+	testcases := []struct {
+		spec string
+		fn   func()
+	}{
+		{spec: "EncodeNil", fn: func() { _ = enc.EncodeNil() }},
+		{spec: "EncodeBool", fn: func() { _ = enc.EncodeBool(true) }},
+		{spec: "EncodeInt", fn: func() { _ = enc.EncodeInt(math.MinInt) }},
+		{spec: "EncodeInt8", fn: func() { _ = enc.EncodeInt8(math.MinInt8) }},
+		{spec: "EncodeInt16", fn: func() { _ = enc.EncodeInt16(math.MinInt16) }},
+		{spec: "EncodeInt32", fn: func() { _ = enc.EncodeInt32(math.MinInt32) }},
+		{spec: "EncodeInt64", fn: func() { _ = enc.EncodeInt64(math.MinInt64) }},
+		{spec: "EncodeUint", fn: func() { _ = enc.EncodeUint(math.MaxUint) }},
+		{spec: "EncodeUint8", fn: func() { _ = enc.EncodeUint8(math.MaxUint8) }},
+		{spec: "EncodeUint16", fn: func() { _ = enc.EncodeUint16(math.MaxUint16) }},
+		{spec: "EncodeUint32", fn: func() { _ = enc.EncodeUint32(math.MaxUint32) }},
+		{spec: "EncodeUint64", fn: func() { _ = enc.EncodeUint64(math.MaxUint64) }},
+		{spec: "EncodeFixedInt", fn: func() { _ = enc.EncodeFixedInt(1) }},
+		{spec: "EncodeNegFixInt", fn: func() { _ = enc.EncodeNegFixInt(-1) }},
+		{spec: "EncodeFixedUint", fn: func() { _ = enc.EncodeFixedUint(1) }},
+		{spec: "EncodeFloat32", fn: func() { _ = enc.EncodeFloat32(math.MaxFloat32) }},
+		{spec: "EncodeFloat64", fn: func() { _ = enc.EncodeFloat64(math.MaxFloat64) }},
+		{spec: "EncodeString", fn: func() { _ = enc.EncodeString("string") }},
+		{spec: "EncodeStringBytes", fn: func() { _ = enc.EncodeStringBytes(bin) }},
+		{spec: "EncodeBytes", fn: func() { _ = enc.EncodeBytes(bin) }},
+		{spec: "EncodeTime", fn: func() { _ = enc.EncodeTime(ts) }},
+		{spec: "WriteArrayHeader", fn: func() { _ = enc.WriteArrayHeader(math.MaxUint16 + 1) }},
+		{spec: "WriteMapHeader", fn: func() { _ = enc.WriteMapHeader(math.MaxUint16 + 1) }},
+		{spec: "WriteStringHeader", fn: func() { _ = enc.WriteStringHeader(math.MaxUint16 + 1) }},
+		{spec: "AppendString", fn: func() { _ = AppendString(buf[:0], "string") }},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			// ACT
+			got := testing.AllocsPerRun(100, tc.fn)
+
+			// ASSERT
+			if got != 0 {
+				t.Errorf("\nwanted 0 allocations\ngot    %v", got)
+			}
+		})
+	}
+}
+
+func TestEncoderWriteHeader64(t *testing.T) {
+	// ARRANGE
+	type expect struct {
+		result []byte
+		error
+	}
+	testcases := []struct {
+		spec string
+		fn   func(Encoder) error
+		expect
+	}{
+		{spec: "WriteArrayHeader64(0)",
+			fn:     func(enc Encoder) error { return enc.WriteArrayHeader64(0) },
+			expect: expect{result: []byte{atomEmptyArray}},
+		},
+		{spec: "WriteArrayHeader64(MaxUint32)",
+			fn:     func(enc Encoder) error { return enc.WriteArrayHeader64(math.MaxUint32) },
+			expect: expect{result: []byte{typeArray32, 0xff, 0xff, 0xff, 0xff}},
+		},
+		{spec: "WriteArrayHeader64(MaxUint32+1)",
+			fn:     func(enc Encoder) error { return enc.WriteArrayHeader64(math.MaxUint32 + 1) },
+			expect: expect{result: []byte{}, error: ErrTooLarge},
+		},
+		{spec: "WriteArrayHeader64(-1)",
+			fn:     func(enc Encoder) error { return enc.WriteArrayHeader64(-1) },
+			expect: expect{result: []byte{}, error: ErrValueOutOfRange},
+		},
+		{spec: "WriteMapHeader64(65536)",
+			fn:     func(enc Encoder) error { return enc.WriteMapHeader64(65536) },
+			expect: expect{result: []byte{typeMap32, 0x00, 0x01, 0x00, 0x00}},
+		},
+		{spec: "WriteMapHeader64(MaxUint32+1)",
+			fn:     func(enc Encoder) error { return enc.WriteMapHeader64(math.MaxUint32 + 1) },
+			expect: expect{result: []byte{}, error: ErrTooLarge},
+		},
+		{spec: "WriteStringHeader64(255)",
+			fn:     func(enc Encoder) error { return enc.WriteStringHeader64(255) },
+			expect: expect{result: []byte{typeString8, 0xff}},
+		},
+		{spec: "WriteStringHeader64(MaxUint32)",
+			fn:     func(enc Encoder) error { return enc.WriteStringHeader64(math.MaxUint32) },
+			expect: expect{result: []byte{typeString32, 0xff, 0xff, 0xff, 0xff}},
+		},
+		{spec: "WriteStringHeader64(MaxInt64)",
+			fn:     func(enc Encoder) error { return enc.WriteStringHeader64(math.MaxInt64) },
+			expect: expect{result: []byte{}, error: ErrTooLarge},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			enc, buf := NewTestEncoder()
+
+			// ACT
+			err := tc.fn(enc)
+
+			// ASSERT
+			testError(t, tc.error, err)
+
+			wanted := tc.result
+			got := buf.Bytes()
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+			}
+		})
+	}
+}
+
+func TestEncoderLengthLimits(t *testing.T) {
+	// ARRANGE
+	tooLarge := int64(math.MaxUint32) + 1
+
+	type expect struct {
+		result []byte
+		error
+	}
+	testcases := []struct {
+		spec string
+		is64 bool // true if the test case requires a 64-bit int
+		fn   func(Encoder) error
+		expect
+	}{
+		{spec: "WriteArrayHeader(-1)",
+			fn:     func(enc Encoder) error { return enc.WriteArrayHeader(-1) },
+			expect: expect{result: []byte{}, error: ErrValueOutOfRange},
+		},
+		{spec: "WriteMapHeader(-1)",
+			fn:     func(enc Encoder) error { return enc.WriteMapHeader(-1) },
+			expect: expect{result: []byte{}, error: ErrValueOutOfRange},
+		},
+		{spec: "WriteStringHeader(-1)",
+			fn:     func(enc Encoder) error { return enc.WriteStringHeader(-1) },
+			expect: expect{result: []byte{}, error: ErrValueOutOfRange},
+		},
+		{spec: "WriteArrayHeader(MaxUint32+1)", is64: true,
+			fn:     func(enc Encoder) error { return enc.WriteArrayHeader(int(tooLarge)) },
+			expect: expect{result: []byte{}, error: ErrTooLarge},
+		},
+		{spec: "WriteMapHeader(MaxUint32+1)", is64: true,
+			fn:     func(enc Encoder) error { return enc.WriteMapHeader(int(tooLarge)) },
+			expect: expect{result: []byte{}, error: ErrTooLarge},
+		},
+		{spec: "WriteStringHeader(MaxUint32+1)", is64: true,
+			fn:     func(enc Encoder) error { return enc.WriteStringHeader(int(tooLarge)) },
+			expect: expect{result: []byte{}, error: ErrTooLarge},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			if tc.is64 && strconv.IntSize < 64 {
+				t.Skip("requires a 64-bit int")
+			}
+			enc, buf := NewTestEncoder()
+
+			// ACT
+			err := tc.fn(enc)
+
+			// ASSERT
+			testError(t, tc.error, err)
+
+			wanted := tc.result
+			got := buf.Bytes()
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+			}
+		})
+	}
+}
+
+// readerFromBuffer is an io.Writer implementing io.ReaderFrom, recording
+// whether ReadFrom was called.
+type readerFromBuffer struct {
+	bytes.Buffer
+	readFrom bool
+}
+
+func (w *readerFromBuffer) ReadFrom(r io.Reader) (int64, error) {
+	w.readFrom = true
+	return w.Buffer.ReadFrom(r)
+}
+
+func TestEncodeBytesFrom(t *testing.T) {
+	// ARRANGE
+	type expect struct {
+		result   []byte
+		readFrom bool
+		error
+	}
+	testcases := []struct {
+		spec string // for information only, not part of the test
+		data string
+		n    int64
+		opts []EncoderOption
+		expect
+	}{
+		{spec: "empty", data: "", n: 0, expect: expect{result: []byte{typeBin8, 0x00}, readFrom: true}},
+		{spec: "bin8", data: "abc", n: 3, expect: expect{result: []byte{typeBin8, 0x03, 'a', 'b', 'c'}, readFrom: true}},
+		{spec: "fewer than available", data: "abcdef", n: 2, expect: expect{result: []byte{typeBin8, 0x02, 'a', 'b'}, readFrom: true}},
+		{spec: "old spec", data: "abc", n: 3, opts: []EncoderOption{OldSpec()}, expect: expect{result: []byte{0xa3, 'a', 'b', 'c'}, readFrom: true}},
+		{spec: "traced", data: "abc", n: 3, opts: []EncoderOption{Trace(io.Discard)}, expect: expect{result: []byte{typeBin8, 0x03, 'a', 'b', 'c'}}},
+		{spec: "truncated", data: "ab", n: 3, expect: expect{result: []byte{typeBin8, 0x03, 'a', 'b'}, readFrom: true, error: ErrTruncated}},
+		{spec: "negative", data: "abc", n: -1, expect: expect{error: ErrValueOutOfRange}},
+		{spec: "too large", data: "abc", n: maxLength + 1, expect: expect{error: ErrTooLarge}},
+		{spec: "max size", data: "abc", n: 3, opts: []EncoderOption{MaxSize(4)}, expect: expect{error: ErrTooLarge}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			// ARRANGE
+			buf := &readerFromBuffer{}
+			enc := NewEncoder(buf, tc.opts...)
+
+			// ACT
+			err := enc.EncodeBytesFrom(strings.NewReader(tc.data), tc.n)
+
+			// ASSERT
+			testError(t, tc.expect.error, err)
+
+			if !bytes.Equal(tc.expect.result, buf.Bytes()) {
+				t.Errorf("\nwanted %x\ngot    %x", tc.expect.result, buf.Bytes())
+			}
+			if tc.expect.readFrom != buf.readFrom {
+				t.Errorf("\nwanted ReadFrom called: %v\ngot                    %v", tc.expect.readFrom, buf.readFrom)
+			}
+		})
+	}
 }
@@ -1,8 +1,171 @@
 package msgpack
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
+// Errors returned (or panicked) by the package are wrapped with
+// additional context; use errors.Is to test for a specific class
+// of error.
 var (
-	ErrValueOutOfRange = errors.New("value out of range")
-	ErrUnsupportedType = errors.New("unsupported type")
+	ErrContainerMismatch = errors.New("container mismatch")     // the number of entries written to a container does not match its header
+	ErrDepthExceeded     = errors.New("maximum depth exceeded") // a value is nested more deeply than permitted
+	ErrInvalidFormat     = errors.New("invalid format")         // a byte is not a valid msgpack format
+	ErrInvalidUTF8       = errors.New("invalid utf-8")          // a string is not valid utf-8
+	ErrNilWriter         = errors.New("nil writer")             // an io.Writer is required but nil was specified
+	ErrNotFound          = errors.New("not found")              // a path does not identify a value in an encoded document
+	ErrOverflow          = errors.New("overflow")               // a value cannot be represented by the type it is converted to
+	ErrPatchTestFailed   = errors.New("patch test failed")      // a value tested by a patch is not equal to the value expected
+	ErrTooLarge          = errors.New("too large")              // a value (or output) exceeds a size limit
+	ErrTruncated         = errors.New("truncated data")         // data ends part way through a value
+	ErrTypeMismatch      = errors.New("type mismatch")          // a value is of a different type to that expected
+	ErrUnsupportedType   = errors.New("unsupported type")       // a value is of a type that is not supported
+	ErrValueOutOfRange   = errors.New("value out of range")     // a value is outside the range of a format or function
 )
+
+// EncodeError is returned (or panicked) when an error occurs encoding
+// an element of an array or an entry in a map, identifying the path to
+// the value that could not be encoded, e.g. "orders[3].customer.name".
+//
+// Array elements are identified by index ([3]); map entries are
+// identified by key, with string keys separated by '.' and any other
+// keys enclosed in brackets ([42]).
+type EncodeError struct {
+	Path string
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *EncodeError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the error that occurred encoding the value.
+func (e *EncodeError) Unwrap() error {
+	return e.Err
+}
+
+// WriteError is captured by an Encoder when an error is returned by its
+// io.Writer.  Written is the number of bytes of the value being encoded
+// that had been written before the error occurred.
+//
+// If Written is zero the output (up to the error) consists only of
+// complete values; otherwise the output ends with a partially written
+// value and a consumer of the output will be unable to resynchronize.
+type WriteError struct {
+	Written int
+	Err     error
+}
+
+// Error implements the error interface.
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("write error (%d bytes of value written): %v", e.Written, e.Err)
+}
+
+// Unwrap returns the error returned by the io.Writer.
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// Partial returns true if the error occurred after some (but not all)
+// of the bytes of a value had been written.
+func (e *WriteError) Partial() bool {
+	return e.Written > 0
+}
+
+// callbackError is captured by Encoder.Using when the function returns
+// an error other than an error already captured by the Encoder while the
+// function was executing.  Both errors may be tested using errors.Is
+// (and errors.As).
+type callbackError struct {
+	err      error // the error captured by the Encoder
+	callback error // the error returned by the function
+}
+
+// Error implements the error interface.
+func (e *callbackError) Error() string {
+	return e.err.Error() + "; callback: " + e.callback.Error()
+}
+
+// Unwrap returns the error captured by the Encoder.
+func (e *callbackError) Unwrap() error {
+	return e.err
+}
+
+// Is returns true if the error returned by the function is the target.
+func (e *callbackError) Is(target error) bool {
+	return errors.Is(e.callback, target)
+}
+
+// As finds the first error in the chain of the error returned by the
+// function that matches target.
+func (e *callbackError) As(target any) bool {
+	return errors.As(e.callback, target)
+}
+
+// atIndex returns an error annotated with a path identifying an
+// element of an array.
+func atIndex(i int, err error) error {
+	return atPath(fmt.Sprintf("[%d]", i), err)
+}
+
+// atKey returns an error annotated with a path identifying an entry
+// in a map.
+func atKey(k any, err error) error {
+	if s, ok := k.(string); ok {
+		return atPath(s, err)
+	}
+	return atPath(fmt.Sprintf("[%v]", k), err)
+}
+
+// atPath returns an error annotated with a path segment.  If the error
+// is (or wraps) an EncodeError a new EncodeError is returned with the
+// segment prepended to the existing path, wrapping the error (or, if
+// the error is an EncodeError, the error it wraps); otherwise a new
+// EncodeError is returned with a path of the segment.
+func atPath(segment string, err error) error {
+	var ee *EncodeError
+	if !errors.As(err, &ee) {
+		return &EncodeError{Path: segment, Err: err}
+	}
+
+	path := segment + "." + ee.Path
+	if strings.HasPrefix(ee.Path, "[") {
+		path = segment + ee.Path
+	}
+	if err == ee {
+		return &EncodeError{Path: path, Err: ee.Err}
+	}
+	return &EncodeError{Path: path, Err: err}
+}
+
+// annotatePanic recovers a panic, re-panicking with any error annotated
+// using the specified function.  This is deferred by functions encoding
+// containers so that a panic (e.g. ErrUnsupportedType) identifies the
+// value that could not be encoded.
+func annotatePanic(annotate func(error) error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if err, ok := r.(error); ok {
+		panic(annotate(err))
+	}
+	panic(r)
+}
+
+// recoverError recovers a panic with an error value, setting the error
+// referenced by err.  A panic with any other value is re-panicked.
+func recoverError(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if e, ok := r.(error); ok {
+		*err = e
+		return
+	}
+	panic(r)
+}
@@ -0,0 +1,286 @@
+package msgpack
+
+import "fmt"
+
+// Format identifies a msgpack format, as determined by the leading
+// byte of an encoded value (or, for FormatTimestamp, the leading byte
+// and extension type).
+type Format uint8
+
+// Formats defined by the msgpack specification.
+const (
+	FormatInvalid Format = iota // not a valid msgpack format (0xc1 is never used)
+
+	// nil and bool
+	FormatNil  // 0xc0
+	FormatBool // 0xc2 (false) / 0xc3 (true)
+
+	// ints
+	FormatFixInt    // 0x00-0x7f: positive fixint (0..127)
+	FormatNegFixInt // 0xe0-0xff: negative fixint (-32..-1)
+	FormatInt8      // 0xd0
+	FormatInt16     // 0xd1
+	FormatInt32     // 0xd2
+	FormatInt64     // 0xd3
+	FormatUint8     // 0xcc
+	FormatUint16    // 0xcd
+	FormatUint32    // 0xce
+	FormatUint64    // 0xcf
+
+	// floats
+	FormatFloat32 // 0xca
+	FormatFloat64 // 0xcb
+
+	// strings
+	FormatFixStr // 0xa0-0xbf: string with 0-31 bytes
+	FormatStr8   // 0xd9
+	FormatStr16  // 0xda
+	FormatStr32  // 0xdb
+
+	// binary data
+	FormatBin8  // 0xc4
+	FormatBin16 // 0xc5
+	FormatBin32 // 0xc6
+
+	// arrays
+	FormatFixArray // 0x90-0x9f: array with 0-15 elements
+	FormatArray16  // 0xdc
+	FormatArray32  // 0xdd
+
+	// maps
+	FormatFixMap // 0x80-0x8f: map with 0-15 entries
+	FormatMap16  // 0xde
+	FormatMap32  // 0xdf
+
+	// extensions
+	FormatFixExt1  // 0xd4
+	FormatFixExt2  // 0xd5
+	FormatFixExt4  // 0xd6
+	FormatFixExt8  // 0xd7
+	FormatFixExt16 // 0xd8
+	FormatExt8     // 0xc7
+	FormatExt16    // 0xc8
+	FormatExt32    // 0xc9
+
+	// FormatTimestamp identifies the timestamp extension type (-1),
+	// encoded as a fixext4, fixext8 or ext8 with the extension type -1.
+	// It is not identified by a leading byte alone.
+	FormatTimestamp
+)
+
+// FormatOf returns the Format identified by the leading byte of an
+// encoded value.  FormatInvalid is returned for the byte 0xc1, which
+// is never used.
+//
+// Extension formats are returned as the corresponding FixExt/Ext
+// format; FormatTimestamp is not returned since an extension type
+// cannot be identified from the leading byte alone.
+func FormatOf(b byte) Format {
+	switch {
+	case b <= 0x7f:
+		return FormatFixInt
+	case b <= 0x8f:
+		return FormatFixMap
+	case b <= 0x9f:
+		return FormatFixArray
+	case b <= 0xbf:
+		return FormatFixStr
+	case b >= maskNegFixInt:
+		return FormatNegFixInt
+	}
+
+	switch b {
+	case atomNil:
+		return FormatNil
+	case atomFalse, atomTrue:
+		return FormatBool
+	case typeBin8:
+		return FormatBin8
+	case typeBin16:
+		return FormatBin16
+	case typeBin32:
+		return FormatBin32
+	case typeExt8:
+		return FormatExt8
+	case typeExt16:
+		return FormatExt16
+	case typeExt32:
+		return FormatExt32
+	case typeFloat32:
+		return FormatFloat32
+	case typeFloat64:
+		return FormatFloat64
+	case typeUint8:
+		return FormatUint8
+	case typeUint16:
+		return FormatUint16
+	case typeUint32:
+		return FormatUint32
+	case typeUint64:
+		return FormatUint64
+	case typeInt8:
+		return FormatInt8
+	case typeInt16:
+		return FormatInt16
+	case typeInt32:
+		return FormatInt32
+	case typeInt64:
+		return FormatInt64
+	case typeFixExt1:
+		return FormatFixExt1
+	case typeFixExt2:
+		return FormatFixExt2
+	case typeFixExt4:
+		return FormatFixExt4
+	case typeFixExt8:
+		return FormatFixExt8
+	case typeFixExt16:
+		return FormatFixExt16
+	case typeString8:
+		return FormatStr8
+	case typeString16:
+		return FormatStr16
+	case typeString32:
+		return FormatStr32
+	case typeArray16:
+		return FormatArray16
+	case typeArray32:
+		return FormatArray32
+	case typeMap16:
+		return FormatMap16
+	case typeMap32:
+		return FormatMap32
+	default: // 0xc1
+		return FormatInvalid
+	}
+}
+
+// IsNil returns true if the specified leading byte identifies nil.
+func IsNil(b byte) bool { return b == atomNil }
+
+// IsBool returns true if the specified leading byte identifies a bool.
+func IsBool(b byte) bool { return b == atomFalse || b == atomTrue }
+
+// IsFixInt returns true if the specified leading byte is a positive or
+// negative fixint (the byte encodes both the type and value).
+func IsFixInt(b byte) bool { return b <= 0x7f || b >= maskNegFixInt }
+
+// IsInt returns true if the specified leading byte identifies any
+// signed or unsigned integer format, including fixints.
+func IsInt(b byte) bool {
+	f := FormatOf(b)
+	return f >= FormatFixInt && f <= FormatUint64
+}
+
+// IsFloat returns true if the specified leading byte identifies a
+// float32 or float64.
+func IsFloat(b byte) bool { return b == typeFloat32 || b == typeFloat64 }
+
+// IsStr returns true if the specified leading byte identifies a string
+// (fixstr, str8, str16 or str32).
+func IsStr(b byte) bool {
+	f := FormatOf(b)
+	return f >= FormatFixStr && f <= FormatStr32
+}
+
+// IsBin returns true if the specified leading byte identifies binary
+// data (bin8, bin16 or bin32).
+func IsBin(b byte) bool { return b >= typeBin8 && b <= typeBin32 }
+
+// IsArray returns true if the specified leading byte identifies an
+// array (fixarray, array16 or array32).
+func IsArray(b byte) bool {
+	f := FormatOf(b)
+	return f >= FormatFixArray && f <= FormatArray32
+}
+
+// IsMap returns true if the specified leading byte identifies a map
+// (fixmap, map16 or map32).
+func IsMap(b byte) bool {
+	f := FormatOf(b)
+	return f >= FormatFixMap && f <= FormatMap32
+}
+
+// IsExt returns true if the specified leading byte identifies an
+// extension type (fixext1-16, ext8, ext16 or ext32).
+func IsExt(b byte) bool {
+	f := FormatOf(b)
+	return f >= FormatFixExt1 && f <= FormatExt32
+}
+
+// formatNames holds the names of each Format, as returned by String.
+var formatNames = [...]string{
+	FormatInvalid:   "invalid",
+	FormatNil:       "nil",
+	FormatBool:      "bool",
+	FormatFixInt:    "fixint",
+	FormatNegFixInt: "negfixint",
+	FormatInt8:      "int8",
+	FormatInt16:     "int16",
+	FormatInt32:     "int32",
+	FormatInt64:     "int64",
+	FormatUint8:     "uint8",
+	FormatUint16:    "uint16",
+	FormatUint32:    "uint32",
+	FormatUint64:    "uint64",
+	FormatFloat32:   "float32",
+	FormatFloat64:   "float64",
+	FormatFixStr:    "fixstr",
+	FormatStr8:      "str8",
+	FormatStr16:     "str16",
+	FormatStr32:     "str32",
+	FormatBin8:      "bin8",
+	FormatBin16:     "bin16",
+	FormatBin32:     "bin32",
+	FormatFixArray:  "fixarray",
+	FormatArray16:   "array16",
+	FormatArray32:   "array32",
+	FormatFixMap:    "fixmap",
+	FormatMap16:     "map16",
+	FormatMap32:     "map32",
+	FormatFixExt1:   "fixext1",
+	FormatFixExt2:   "fixext2",
+	FormatFixExt4:   "fixext4",
+	FormatFixExt8:   "fixext8",
+	FormatFixExt16:  "fixext16",
+	FormatExt8:      "ext8",
+	FormatExt16:     "ext16",
+	FormatExt32:     "ext32",
+	FormatTimestamp: "timestamp",
+}
+
+// String returns the name of the Format, e.g. "fixstr", "map16".
+func (f Format) String() string {
+	if int(f) < len(formatNames) {
+		return formatNames[f]
+	}
+	return fmt.Sprintf("Format(%d)", f)
+}
+
+// Describe returns a human-readable description of the format
+// identified by the leading byte of an encoded value, for use in
+// diagnostics, dumps and logs.
+//
+// For formats where the leading byte also encodes a value or length
+// this is included in the description, e.g. "fixstr(len=5)",
+// "fixint(42)", "bool(true)".  For all other formats the description
+// is the name of the format (e.g. "map16"), or "invalid(0xc1)" for an
+// invalid leading byte.
+func Describe(b byte) string {
+	switch f := FormatOf(b); f {
+	case FormatFixInt, FormatNegFixInt:
+		return fmt.Sprintf("%s(%d)", f, int8(b))
+	case FormatFixStr:
+		return fmt.Sprintf("%s(len=%d)", f, b&^maskFixString)
+	case FormatFixArray:
+		return fmt.Sprintf("%s(len=%d)", f, b&^maskFixArray)
+	case FormatFixMap:
+		return fmt.Sprintf("%s(len=%d)", f, b&^maskFixMap)
+	case FormatBool:
+		return fmt.Sprintf("%s(%v)", f, b == atomTrue)
+	case FormatInvalid:
+		return fmt.Sprintf("%s(%#02x)", f, b)
+	default:
+		return f.String()
+	}
+}
@@ -0,0 +1,177 @@
+package msgpack
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFormatOf(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		b byte
+		Format
+	}{
+		{b: 0x00, Format: FormatFixInt},
+		{b: 0x7f, Format: FormatFixInt},
+		{b: 0x80, Format: FormatFixMap},
+		{b: 0x8f, Format: FormatFixMap},
+		{b: 0x90, Format: FormatFixArray},
+		{b: 0x9f, Format: FormatFixArray},
+		{b: 0xa0, Format: FormatFixStr},
+		{b: 0xbf, Format: FormatFixStr},
+		{b: 0xc0, Format: FormatNil},
+		{b: 0xc1, Format: FormatInvalid},
+		{b: 0xc2, Format: FormatBool},
+		{b: 0xc3, Format: FormatBool},
+		{b: 0xc4, Format: FormatBin8},
+		{b: 0xc5, Format: FormatBin16},
+		{b: 0xc6, Format: FormatBin32},
+		{b: 0xc7, Format: FormatExt8},
+		{b: 0xc8, Format: FormatExt16},
+		{b: 0xc9, Format: FormatExt32},
+		{b: 0xca, Format: FormatFloat32},
+		{b: 0xcb, Format: FormatFloat64},
+		{b: 0xcc, Format: FormatUint8},
+		{b: 0xcd, Format: FormatUint16},
+		{b: 0xce, Format: FormatUint32},
+		{b: 0xcf, Format: FormatUint64},
+		{b: 0xd0, Format: FormatInt8},
+		{b: 0xd1, Format: FormatInt16},
+		{b: 0xd2, Format: FormatInt32},
+		{b: 0xd3, Format: FormatInt64},
+		{b: 0xd4, Format: FormatFixExt1},
+		{b: 0xd5, Format: FormatFixExt2},
+		{b: 0xd6, Format: FormatFixExt4},
+		{b: 0xd7, Format: FormatFixExt8},
+		{b: 0xd8, Format: FormatFixExt16},
+		{b: 0xd9, Format: FormatStr8},
+		{b: 0xda, Format: FormatStr16},
+		{b: 0xdb, Format: FormatStr32},
+		{b: 0xdc, Format: FormatArray16},
+		{b: 0xdd, Format: FormatArray32},
+		{b: 0xde, Format: FormatMap16},
+		{b: 0xdf, Format: FormatMap32},
+		{b: 0xe0, Format: FormatNegFixInt},
+		{b: 0xff, Format: FormatNegFixInt},
+	}
+	for _, tc := range testcases {
+		t.Run(fmt.Sprintf("%#02x", tc.b), func(t *testing.T) {
+			// ACT
+			got := FormatOf(tc.b)
+
+			// ASSERT
+			wanted := tc.Format
+			if wanted != got {
+				t.Errorf("\nwanted %d\ngot    %d", wanted, got)
+			}
+		})
+	}
+}
+
+func TestFormatClassification(t *testing.T) {
+	// ARRANGE
+	type span [2]byte // inclusive range of leading bytes
+
+	testcases := []struct {
+		name  string
+		fn    func(byte) bool
+		spans []span
+	}{
+		{name: "IsNil", fn: IsNil, spans: []span{{0xc0, 0xc0}}},
+		{name: "IsBool", fn: IsBool, spans: []span{{0xc2, 0xc3}}},
+		{name: "IsFixInt", fn: IsFixInt, spans: []span{{0x00, 0x7f}, {0xe0, 0xff}}},
+		{name: "IsInt", fn: IsInt, spans: []span{{0x00, 0x7f}, {0xe0, 0xff}, {0xcc, 0xd3}}},
+		{name: "IsFloat", fn: IsFloat, spans: []span{{0xca, 0xcb}}},
+		{name: "IsStr", fn: IsStr, spans: []span{{0xa0, 0xbf}, {0xd9, 0xdb}}},
+		{name: "IsBin", fn: IsBin, spans: []span{{0xc4, 0xc6}}},
+		{name: "IsArray", fn: IsArray, spans: []span{{0x90, 0x9f}, {0xdc, 0xdd}}},
+		{name: "IsMap", fn: IsMap, spans: []span{{0x80, 0x8f}, {0xde, 0xdf}}},
+		{name: "IsExt", fn: IsExt, spans: []span{{0xc7, 0xc9}, {0xd4, 0xd8}}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			for i := 0; i < 256; i++ {
+				b := byte(i)
+
+				wanted := false
+				for _, s := range tc.spans {
+					wanted = wanted || (b >= s[0] && b <= s[1])
+				}
+
+				// ACT
+				got := tc.fn(b)
+
+				// ASSERT
+				if wanted != got {
+					t.Errorf("%s(%#02x): wanted %v, got %v", tc.name, b, wanted, got)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatString(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		Format
+		string
+	}{
+		{Format: FormatInvalid, string: "invalid"},
+		{Format: FormatFixStr, string: "fixstr"},
+		{Format: FormatMap16, string: "map16"},
+		{Format: FormatTimestamp, string: "timestamp"},
+		{Format: FormatTimestamp + 1, string: fmt.Sprintf("Format(%d)", FormatTimestamp+1)},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.string, func(t *testing.T) {
+			// ACT
+			got := tc.Format.String()
+
+			// ASSERT
+			wanted := tc.string
+			if wanted != got {
+				t.Errorf("\nwanted %q\ngot    %q", wanted, got)
+			}
+		})
+	}
+
+	t.Run("all formats are named", func(t *testing.T) {
+		for f := FormatInvalid; f <= FormatTimestamp; f++ {
+			if formatNames[f] == "" {
+				t.Errorf("format %d has no name", f)
+			}
+		}
+	})
+}
+
+func TestDescribe(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		b      byte
+		result string
+	}{
+		{b: 0x05, result: "fixint(5)"},
+		{b: 0xfd, result: "negfixint(-3)"},
+		{b: 0xa5, result: "fixstr(len=5)"},
+		{b: 0x93, result: "fixarray(len=3)"},
+		{b: 0x82, result: "fixmap(len=2)"},
+		{b: 0xc0, result: "nil"},
+		{b: 0xc1, result: "invalid(0xc1)"},
+		{b: 0xc2, result: "bool(false)"},
+		{b: 0xc3, result: "bool(true)"},
+		{b: 0xde, result: "map16"},
+		{b: 0xd9, result: "str8"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.result, func(t *testing.T) {
+			// ACT
+			got := Describe(tc.b)
+
+			// ASSERT
+			wanted := tc.result
+			if wanted != got {
+				t.Errorf("\nwanted %q\ngot    %q", wanted, got)
+			}
+		})
+	}
+}
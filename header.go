@@ -0,0 +1,214 @@
+package msgpack
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// ReadHeader parses the header of the encoded value at the start of
+// data, without decoding the value itself.  This allows indexers and
+// framers to determine the format and size of a value without a full
+// decoder.
+//
+// The length returned depends on the format:
+//
+//   - str, bin and ext: the number of bytes of data following the header
+//   - array: the number of elements
+//   - map: the number of entries (key/value pairs)
+//   - int, uint and float: the number of bytes of data following the header
+//   - nil, bool and fixint: 0 (the value is encoded in the header)
+//
+// headerLen is the number of bytes of the header, including (for
+// extension formats) the extension type.  An extension with the
+// timestamp extension type (-1) is returned as FormatTimestamp.
+//
+// An error wrapping ErrTruncated is returned if data ends before the
+// end of the header, or ErrInvalidFormat if the leading byte is not a
+// valid msgpack format.
+func ReadHeader(data []byte) (format Format, length int64, headerLen int, err error) {
+	if len(data) == 0 {
+		return FormatInvalid, 0, 0, fmt.Errorf("%w: no data", ErrTruncated)
+	}
+
+	b := data[0]
+	format = FormatOf(b)
+
+	// the number of bytes of the header following the leading byte and
+	// the fixed length of any data following the header
+	var size, fixed int
+	switch format {
+	case FormatInvalid:
+		return format, 0, 0, fmt.Errorf("%w: %#02x", ErrInvalidFormat, b)
+	case FormatNil, FormatBool, FormatFixInt, FormatNegFixInt:
+		return format, 0, 1, nil
+	case FormatFixStr:
+		return format, int64(b &^ maskFixString), 1, nil
+	case FormatFixArray:
+		return format, int64(b &^ maskFixArray), 1, nil
+	case FormatFixMap:
+		return format, int64(b &^ maskFixMap), 1, nil
+	case FormatInt8, FormatUint8:
+		fixed = 1
+	case FormatInt16, FormatUint16:
+		fixed = 2
+	case FormatInt32, FormatUint32, FormatFloat32:
+		fixed = 4
+	case FormatInt64, FormatUint64, FormatFloat64:
+		fixed = 8
+	case FormatStr8, FormatBin8:
+		size = 1
+	case FormatStr16, FormatBin16, FormatArray16, FormatMap16:
+		size = 2
+	case FormatStr32, FormatBin32, FormatArray32, FormatMap32:
+		size = 4
+	case FormatFixExt1:
+		size, fixed = 1, 1
+	case FormatFixExt2:
+		size, fixed = 1, 2
+	case FormatFixExt4:
+		size, fixed = 1, 4
+	case FormatFixExt8:
+		size, fixed = 1, 8
+	case FormatFixExt16:
+		size, fixed = 1, 16
+	case FormatExt8:
+		size = 2
+	case FormatExt16:
+		size = 3
+	case FormatExt32:
+		size = 5
+	}
+
+	headerLen = 1 + size
+	if len(data) < headerLen {
+		return format, 0, 0, fmt.Errorf("%w: %s header requires %d bytes, got %d", ErrTruncated, format, headerLen, len(data))
+	}
+
+	hdr := data[1:headerLen]
+	switch format {
+	case FormatStr8, FormatBin8, FormatExt8:
+		length = int64(hdr[0])
+	case FormatStr16, FormatBin16, FormatArray16, FormatMap16, FormatExt16:
+		length = int64(binary.BigEndian.Uint16(hdr))
+	case FormatStr32, FormatBin32, FormatArray32, FormatMap32, FormatExt32:
+		length = int64(binary.BigEndian.Uint32(hdr))
+	default:
+		length = int64(fixed)
+	}
+
+	if format >= FormatFixExt1 && format <= FormatExt32 && int8(data[headerLen-1]) == extTimestamp {
+		switch {
+		case format == FormatFixExt4, format == FormatFixExt8, format == FormatExt8 && length == 12:
+			format = FormatTimestamp
+		}
+	}
+
+	return format, length, headerLen, nil
+}
+
+// valueLen returns the number of bytes of the encoded value at the start
+// of data, including the elements (or entries) of an array (or map).
+//
+// An error wrapping ErrTruncated is returned if data ends before the end
+// of the value, or ErrInvalidFormat if an invalid format is encountered.
+func valueLen(data []byte) (int, error) {
+	pos := 0
+	for pending := int64(1); pending > 0; pending-- {
+		f, n, hl, err := ReadHeader(data[pos:])
+		if err != nil {
+			return 0, fmt.Errorf("offset %d: %w", pos, err)
+		}
+		start := pos
+		pos += hl
+
+		switch {
+		case f >= FormatFixArray && f <= FormatArray32:
+			pending += n
+		case f >= FormatFixMap && f <= FormatMap32:
+			pending += 2 * n
+		case n > int64(len(data)-pos):
+			return 0, fmt.Errorf("offset %d: %w: %s requires %d bytes, got %d", start, ErrTruncated, f, int64(hl)+n, len(data)-start)
+		default:
+			pos += int(n)
+		}
+	}
+	return pos, nil
+}
+
+// source is encoded data with the offset of the end of each (non-empty)
+// array and map, keyed by the offset of its header, so that the length of
+// any value is known without scanning its elements (or entries).
+type source struct {
+	data []byte
+	ends map[int]int
+}
+
+// newSource returns a source for the encoded value at the start of data,
+// scanning it once, and the number of bytes of the value.
+//
+// An error wrapping ErrTruncated is returned if data ends before the end
+// of the value, ErrInvalidFormat if an invalid format is encountered, or
+// ErrDepthExceeded if arrays and maps are nested more deeply than
+// MaxDepth.
+func newSource(data []byte) (source, int, error) {
+	type container struct {
+		start   int   // the offset of the header of the array or map
+		pending int64 // the number of values of the array or map yet to be read
+	}
+	src := source{data: data, ends: map[int]int{}}
+	var open []container
+
+	pos := 0
+	for {
+		f, n, hl, err := ReadHeader(data[pos:])
+		if err != nil {
+			return source{}, 0, fmt.Errorf("offset %d: %w", pos, err)
+		}
+		start := pos
+		pos += hl
+
+		isArray := f >= FormatFixArray && f <= FormatArray32
+		isMap := f >= FormatFixMap && f <= FormatMap32
+		switch {
+		case (isArray || isMap) && len(open) == MaxDepth:
+			return source{}, 0, fmt.Errorf("offset %d: %w: maximum depth is %d", start, ErrDepthExceeded, MaxDepth)
+		case isMap && n > 0:
+			open = append(open, container{start: start, pending: 2 * n})
+			continue
+		case isArray && n > 0:
+			open = append(open, container{start: start, pending: n})
+			continue
+		case isArray || isMap:
+		case n > int64(len(data)-pos):
+			return source{}, 0, fmt.Errorf("offset %d: %w: %s requires %d bytes, got %d", start, ErrTruncated, f, int64(hl)+n, len(data)-start)
+		default:
+			pos += int(n)
+		}
+
+		// a value has been read, completing any arrays and maps of which
+		// it is the last value
+		for {
+			if len(open) == 0 {
+				return src, pos, nil
+			}
+			c := &open[len(open)-1]
+			if c.pending--; c.pending > 0 {
+				break
+			}
+			src.ends[c.start] = pos
+			open = open[:len(open)-1]
+		}
+	}
+}
+
+// end returns the offset of the end of the value at offset at.
+func (src source) end(at int) int {
+	if end, ok := src.ends[at]; ok {
+		return end
+	}
+	_, n, hl, _ := ReadHeader(src.data[at:])
+	if IsArray(src.data[at]) || IsMap(src.data[at]) {
+		return at + hl // an empty array or map
+	}
+	return at + hl + int(n)
+}
@@ -0,0 +1,90 @@
+package msgpack
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestReadHeader(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		name      string
+		data      []byte
+		format    Format
+		length    int64
+		headerLen int
+		error
+	}{
+		{name: "no data", data: []byte{}, error: ErrTruncated},
+		{name: "invalid", data: []byte{0xc1}, error: ErrInvalidFormat},
+		{name: "nil", data: []byte{0xc0}, format: FormatNil, headerLen: 1},
+		{name: "true", data: []byte{0xc3}, format: FormatBool, headerLen: 1},
+		{name: "fixint", data: []byte{0x7f}, format: FormatFixInt, headerLen: 1},
+		{name: "negfixint", data: []byte{0xff}, format: FormatNegFixInt, headerLen: 1},
+		{name: "int16", data: []byte{0xd1, 0x01, 0x02}, format: FormatInt16, length: 2, headerLen: 1},
+		{name: "uint64", data: []byte{0xcf}, format: FormatUint64, length: 8, headerLen: 1},
+		{name: "float32", data: []byte{0xca}, format: FormatFloat32, length: 4, headerLen: 1},
+		{name: "fixstr", data: []byte{0xa5, 'h', 'e', 'l', 'l', 'o'}, format: FormatFixStr, length: 5, headerLen: 1},
+		{name: "str8", data: []byte{0xd9, 0x20}, format: FormatStr8, length: 32, headerLen: 2},
+		{name: "str16", data: []byte{0xda, 0x01, 0x00}, format: FormatStr16, length: 256, headerLen: 3},
+		{name: "str32", data: []byte{0xdb, 0xff, 0xff, 0xff, 0xff}, format: FormatStr32, length: 1<<32 - 1, headerLen: 5},
+		{name: "str32/truncated", data: []byte{0xdb, 0x00, 0x00}, format: FormatStr32, error: ErrTruncated},
+		{name: "bin8", data: []byte{0xc4, 0x03}, format: FormatBin8, length: 3, headerLen: 2},
+		{name: "fixarray", data: []byte{0x93}, format: FormatFixArray, length: 3, headerLen: 1},
+		{name: "array16", data: []byte{0xdc, 0x00, 0x10}, format: FormatArray16, length: 16, headerLen: 3},
+		{name: "fixmap", data: []byte{0x82}, format: FormatFixMap, length: 2, headerLen: 1},
+		{name: "map32", data: []byte{0xdf, 0x00, 0x01, 0x00, 0x00}, format: FormatMap32, length: 65536, headerLen: 5},
+		{name: "fixext4", data: []byte{0xd6, 0x01}, format: FormatFixExt4, length: 4, headerLen: 2},
+		{name: "fixext4/truncated", data: []byte{0xd6}, format: FormatFixExt4, error: ErrTruncated},
+		{name: "ext8", data: []byte{0xc7, 0x03, 0x01}, format: FormatExt8, length: 3, headerLen: 3},
+		{name: "ext16", data: []byte{0xc8, 0x01, 0x00, 0x01}, format: FormatExt16, length: 256, headerLen: 4},
+		{name: "ext32", data: []byte{0xc9, 0x00, 0x00, 0x00, 0x02, 0x01}, format: FormatExt32, length: 2, headerLen: 6},
+		{name: "ext8/type -1, not a timestamp", data: []byte{0xc7, 0x03, 0xff}, format: FormatExt8, length: 3, headerLen: 3},
+		{name: "fixext2/type -1, not a timestamp", data: []byte{0xd5, 0xff}, format: FormatFixExt2, length: 2, headerLen: 2},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			// ACT
+			format, length, headerLen, err := ReadHeader(tc.data)
+
+			// ASSERT
+			testError(t, tc.error, err)
+
+			wanted := fmt.Sprintf("%s, %d, %d", tc.format, tc.length, tc.headerLen)
+			got := fmt.Sprintf("%s, %d, %d", format, length, headerLen)
+			if wanted != got {
+				t.Errorf("\nwanted %s\ngot    %s", wanted, got)
+			}
+		})
+	}
+
+	t.Run("timestamps", func(t *testing.T) {
+		testcases := []struct {
+			time.Time
+			length    int64
+			headerLen int
+		}{
+			{Time: time.Unix(1, 0), length: 4, headerLen: 2},
+			{Time: time.Unix(1, 1), length: 8, headerLen: 2},
+			{Time: time.Unix(-1, 0), length: 12, headerLen: 3},
+		}
+		for _, tc := range testcases {
+			// ARRANGE
+			enc, buf := NewTestEncoder()
+			_ = enc.EncodeTime(tc.Time)
+
+			// ACT
+			format, length, headerLen, err := ReadHeader(buf.Bytes())
+
+			// ASSERT
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if format != FormatTimestamp || length != tc.length || headerLen != tc.headerLen {
+				t.Errorf("\nwanted %s, %d, %d\ngot    %s, %d, %d", FormatTimestamp, tc.length, tc.headerLen, format, length, headerLen)
+			}
+		}
+	})
+
+}
@@ -1,6 +1,7 @@
 package msgpack
 
 import (
+	"bytes"
 	"errors"
 	"testing"
 )
@@ -37,3 +38,39 @@ func testPanic(t *testing.T, wanted error) {
 		}
 	}
 }
+
+// limitWriter is an io.Writer that accepts a limited number of bytes,
+// returning an error for any write that would exceed that limit (after
+// writing as many bytes as the limit allows).
+type limitWriter struct {
+	buf   bytes.Buffer
+	limit int
+	err   error
+}
+
+func (w *limitWriter) Write(b []byte) (int, error) {
+	if len(b) <= w.limit {
+		w.limit -= len(b)
+		return w.buf.Write(b)
+	}
+
+	n, _ := w.buf.Write(b[:w.limit])
+	w.limit = 0
+	return n, w.err
+}
+
+// failingWriter is an io.Writer that returns an error (writing nothing)
+// for a number of writes, then accepts any further writes.
+type failingWriter struct {
+	buf   bytes.Buffer
+	fails int
+	err   error
+}
+
+func (w *failingWriter) Write(b []byte) (int, error) {
+	if w.fails > 0 {
+		w.fails--
+		return 0, w.err
+	}
+	return w.buf.Write(b)
+}
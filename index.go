@@ -0,0 +1,101 @@
+package msgpack
+
+import "fmt"
+
+// MapIndex is an index of the (string) keys of an encoded map, recording
+// the offset and length of the value of each entry so that values may be
+// retrieved repeatedly from a large document, in constant time, without
+// re-scanning the document.
+//
+// The index refers to (and does not copy) the document from which it
+// was created; the document must not be modified while the index is in
+// use.
+type MapIndex struct {
+	data    []byte
+	keys    []string
+	entries map[string]span
+}
+
+// span identifies the bytes of a value in an encoded document.
+type span struct {
+	offset int
+	length int
+}
+
+// IndexMap scans an encoded map once, returning a MapIndex of the
+// entries of the map with string keys.  Entries with keys of any other
+// type are not indexed; where a key occurs more than once only the
+// first entry with that key is indexed.  Values are not decoded.
+//
+// An error wrapping ErrTypeMismatch is returned if data is not an
+// encoded map, or ErrTruncated or ErrInvalidFormat if the map is not
+// complete and valid.
+func IndexMap(data []byte) (*MapIndex, error) {
+	f, n, hl, err := ReadHeader(data)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("IndexMap: %w", err)
+	case f < FormatFixMap || f > FormatMap32:
+		return nil, fmt.Errorf("IndexMap: %w: expected map, got %s", ErrTypeMismatch, f)
+	}
+
+	// the number of entries is not trusted until each has been read; every
+	// entry requires at least two bytes, which limits the size hint
+	hint := n
+	if limit := int64(len(data)-hl) / 2; hint > limit {
+		hint = limit
+	}
+
+	ix := &MapIndex{data: data, entries: make(map[string]span, hint)}
+	pos := hl
+	for i := int64(0); i < n; i++ {
+		kn, err := valueLen(data[pos:])
+		if err != nil {
+			return nil, fmt.Errorf("IndexMap: offset %d: %w", pos, err)
+		}
+		vn, err := valueLen(data[pos+kn:])
+		if err != nil {
+			return nil, fmt.Errorf("IndexMap: offset %d: %w", pos+kn, err)
+		}
+
+		if k, ok := stringValue(data[pos : pos+kn]); ok {
+			key := string(k)
+			if _, dup := ix.entries[key]; !dup {
+				ix.keys = append(ix.keys, key)
+				ix.entries[key] = span{offset: pos + kn, length: vn}
+			}
+		}
+		pos += kn + vn
+	}
+	return ix, nil
+}
+
+// Len returns the number of keys in the index.
+func (ix *MapIndex) Len() int {
+	return len(ix.keys)
+}
+
+// Keys returns the keys in the index, in the order in which they occur
+// in the map.
+func (ix *MapIndex) Keys() []string {
+	return append([]string{}, ix.keys...)
+}
+
+// Get returns the encoded value of the entry with the specified key,
+// and true, or nil and false if there is no entry with that key.  The
+// value is a slice of the indexed document.
+func (ix *MapIndex) Get(key string) ([]byte, bool) {
+	s, ok := ix.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return ix.data[s.offset : s.offset+s.length], true
+}
+
+// Offset returns the offset (from the start of the indexed document) and
+// length, in bytes, of the encoded value of the entry with the specified
+// key, and true, or false if there is no entry with that key.
+func (ix *MapIndex) Offset(key string) (offset, length int, ok bool) {
+	s, ok := ix.entries[key]
+	return s.offset, s.length, ok
+}
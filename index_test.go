@@ -0,0 +1,82 @@
+package msgpack
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestIndexMap(t *testing.T) {
+	// ARRANGE
+	data := []byte{
+		0x84,
+		0xa1, 'a', 0x01,
+		0x02, 0xc0, // non-string key
+		0xd9, 0x01, 'b', 0x92, 0xc3, 0xc2,
+		0xa1, 'a', 0x02, // duplicate key
+	}
+
+	// ACT
+	ix, err := IndexMap(data)
+
+	// ASSERT
+	testError(t, nil, err)
+
+	t.Run("keys", func(t *testing.T) {
+		wanted := []string{"a", "b"}
+		got := ix.Keys()
+		if !reflect.DeepEqual(wanted, got) || ix.Len() != 2 {
+			t.Errorf("\nwanted %v\ngot    %v (len %d)", wanted, got, ix.Len())
+		}
+	})
+
+	testcases := []struct {
+		key    string
+		value  []byte
+		offset int
+		ok     bool
+	}{
+		{key: "a", value: []byte{0x01}, offset: 3, ok: true},
+		{key: "b", value: []byte{0x92, 0xc3, 0xc2}, offset: 9, ok: true},
+		{key: "c"},
+	}
+	for _, tc := range testcases {
+		t.Run("get "+tc.key, func(t *testing.T) {
+			// ACT
+			value, ok := ix.Get(tc.key)
+			offset, length, _ := ix.Offset(tc.key)
+
+			// ASSERT
+			if tc.ok != ok || !bytes.Equal(tc.value, value) {
+				t.Errorf("\nwanted %x, %v\ngot    %x, %v", tc.value, tc.ok, value, ok)
+			}
+			if tc.offset != offset || len(tc.value) != length {
+				t.Errorf("\nwanted offset %d, length %d\ngot    offset %d, length %d", tc.offset, len(tc.value), offset, length)
+			}
+		})
+	}
+}
+
+func TestIndexMapErrors(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		spec string
+		data []byte
+		error
+	}{
+		{spec: "no data", data: []byte{}, error: ErrTruncated},
+		{spec: "not a map", data: []byte{0x90}, error: ErrTypeMismatch},
+		{spec: "truncated key", data: []byte{0x81, 0xa2, 'a'}, error: ErrTruncated},
+		{spec: "truncated value", data: []byte{0x81, 0xa1, 'a'}, error: ErrTruncated},
+		{spec: "truncated map32", data: []byte{0xdf, 0xff, 0xff, 0xff, 0xff}, error: ErrTruncated},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			// ACT
+			_, err := IndexMap(tc.data)
+
+			// ASSERT
+			testError(t, tc.error, err)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package msgpack
+
+import "sort"
+
+// Map returns a []byte containing a msgpack encoded map of the
+// specified entries, each value encoded using the Encoder.Encode method.
+//
+// Entries are encoded in ascending key order so that the result is
+// deterministic.
+//
+// An error is returned if any value is of an unsupported type.
+func Map(m map[string]any) (b []byte, err error) {
+	defer recoverError(&err)
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return encodeBytes(func(enc Encoder) error {
+		_ = enc.WriteMapHeader(len(keys))
+		for _, k := range keys {
+			if err := encodeEntry(enc, k, m[k]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// encodeEntry encodes a map entry with a string key, annotating any
+// error (or panic) with the key.
+func encodeEntry(enc Encoder, k string, v any) error {
+	defer annotatePanic(func(err error) error { return atKey(k, err) })
+
+	if err := enc.EncodeField(k, v); err != nil {
+		return atKey(k, err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package msgpack
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestMap(t *testing.T) {
+	// ARRANGE
+	type expect struct {
+		result []byte
+		error
+		path string
+	}
+	testcases := []struct {
+		spec string // for information only, not part of the test
+		m    map[string]any
+		expect
+	}{
+		{spec: "Map(nil)", m: nil, expect: expect{result: []byte{atomEmptyMap}}},
+		{spec: "Map({b:2, a:1})", m: map[string]any{"b": 2, "a": 1}, expect: expect{result: []byte{maskFixMap | 2, maskFixString | 1, 'a', 0x01, maskFixString | 1, 'b', 0x02}}},
+		{spec: "Map({a:struct{}{}})", m: map[string]any{"a": struct{}{}}, expect: expect{error: ErrUnsupportedType, path: "a"}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			// ACT
+			got, err := Map(tc.m)
+
+			// ASSERT
+			testError(t, tc.expect.error, err)
+
+			t.Run("result", func(t *testing.T) {
+				wanted := tc.result
+				if !bytes.Equal(wanted, got) {
+					t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+				}
+			})
+
+			if tc.path != "" {
+				t.Run("path", func(t *testing.T) {
+					var ee *EncodeError
+					if !errors.As(err, &ee) || ee.Path != tc.path {
+						t.Errorf("\nwanted path %q\ngot    %#v", tc.path, err)
+					}
+				})
+			}
+		})
+	}
+}
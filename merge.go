@@ -0,0 +1,177 @@
+package msgpack
+
+import "fmt"
+
+// MergeMaps merges two encoded maps, returning an encoded map with the
+// entries of dst followed by any entries of src with keys not present in
+// dst.  Where a key is present in both maps the value from src replaces
+// the value in dst (the entry retains its position in dst).
+//
+// The maps are merged without decoding any value; keys are compared by
+// value for strings (regardless of string format) and byte-for-byte for
+// any other type.
+//
+// An error wrapping ErrTypeMismatch is returned if either dst or src is
+// not an encoded map, ErrTruncated or ErrInvalidFormat if either is not a
+// complete, valid encoded value, or ErrDepthExceeded if arrays and maps
+// in either are nested more deeply than MaxDepth; see also DeepMergeMaps.
+func MergeMaps(dst, src []byte) ([]byte, error) {
+	result, err := mergeMaps(dst, src, false)
+	if err != nil {
+		return nil, fmt.Errorf("MergeMaps: %w", err)
+	}
+	return result, nil
+}
+
+// DeepMergeMaps merges two encoded maps in the same way as MergeMaps
+// except that where the values for a key present in both maps are both
+// maps, those maps are merged (recursively) rather than the value from
+// src replacing the value in dst.
+func DeepMergeMaps(dst, src []byte) ([]byte, error) {
+	result, err := mergeMaps(dst, src, true)
+	if err != nil {
+		return nil, fmt.Errorf("DeepMergeMaps: %w", err)
+	}
+	return result, nil
+}
+
+// mergeMaps merges two encoded maps, merging any maps with the same key
+// if deep is true.  Each map is scanned (and validated) only once.
+func mergeMaps(dst, src []byte, deep bool) ([]byte, error) {
+	d, _, err := newSource(dst)
+	if err != nil {
+		return nil, fmt.Errorf("dst: %w", err)
+	}
+	s, _, err := newSource(src)
+	if err != nil {
+		return nil, fmt.Errorf("src: %w", err)
+	}
+	return appendMerged(nil, d, 0, s, 0, deep)
+}
+
+// appendMerged appends the merge of the maps at offset dat in d and sat
+// in s to out, returning the extended slice.  The depth of any recursion
+// is limited by the depth of the maps, which is limited (by newSource) to
+// MaxDepth.
+func appendMerged(out []byte, d source, dat int, s source, sat int, deep bool) ([]byte, error) {
+	de, err := readMap(d, dat)
+	if err != nil {
+		return nil, fmt.Errorf("dst: %w", err)
+	}
+	se, err := readMap(s, sat)
+	if err != nil {
+		return nil, fmt.Errorf("src: %w", err)
+	}
+
+	// the keys of dst are indexed once; where a key occurs more than once
+	// the first entry with the key is merged
+	keys := make(map[string]int, len(de))
+	for i := len(de) - 1; i >= 0; i-- {
+		keys[canonicalKey(de[i].key)] = i
+	}
+
+	// from identifies the src entry (if any) replacing (or merged with) the
+	// value of each dst entry
+	from := make([]int, len(de))
+	for i := range from {
+		from[i] = -1
+	}
+	var added []mergeEntry
+	for i, e := range se {
+		if j, ok := keys[canonicalKey(e.key)]; ok {
+			from[j] = i
+			continue
+		}
+		added = append(added, e)
+	}
+
+	n := int64(len(de) + len(added))
+	if n > maxLength {
+		return nil, fmt.Errorf("%w: %d entries (max %d)", ErrTooLarge, n, maxLength)
+	}
+	out = appendMapHeader(out, n)
+	for i, e := range de {
+		out = append(out, e.key...)
+		switch r := from[i]; {
+		case r < 0:
+			out = append(out, d.data[e.at:d.end(e.at)]...)
+		case deep && IsMap(d.data[e.at]) && IsMap(s.data[se[r].at]):
+			if out, err = appendMerged(out, d, e.at, s, se[r].at, true); err != nil {
+				return nil, err
+			}
+		default:
+			out = append(out, s.data[se[r].at:s.end(se[r].at)]...)
+		}
+	}
+	for _, e := range added {
+		out = append(out, e.key...)
+		out = append(out, s.data[e.at:s.end(e.at)]...)
+	}
+	return out, nil
+}
+
+// mergeEntry is an entry of an encoded map being merged: the encoded key
+// and the offset of the value.
+type mergeEntry struct {
+	key []byte
+	at  int
+}
+
+// readMap returns the entries of the encoded map at offset at in src.  An
+// error wrapping ErrTypeMismatch is returned if the value is not a map.
+func readMap(src source, at int) ([]mergeEntry, error) {
+	f, n, hl, _ := ReadHeader(src.data[at:])
+	if f < FormatFixMap || f > FormatMap32 {
+		return nil, fmt.Errorf("%w: expected map, got %s", ErrTypeMismatch, f)
+	}
+
+	// the source has been scanned, so the map is known to have n entries
+	entries := make([]mergeEntry, n)
+	pos := at + hl
+	for i := range entries {
+		vat := src.end(pos)
+		entries[i] = mergeEntry{key: src.data[pos:vat], at: vat}
+		pos = src.end(vat)
+	}
+	return entries, nil
+}
+
+// canonicalKey returns a canonical form of an encoded key: strings are
+// re-encoded in the smallest string format (a string may be encoded in any
+// string format), any other key is unchanged.
+func canonicalKey(key []byte) string {
+	if s, ok := stringValue(key); ok {
+		return string(append(appendStringHeader(nil, int64(len(s))), s...))
+	}
+	return string(key)
+}
+
+// entry is an entry of an encoded map: an encoded key and value.
+type entry struct {
+	key   []byte
+	value []byte
+}
+
+// appendMap appends an encoded map with the specified entries to dst,
+// returning the extended slice.
+func appendMap(dst []byte, entries []entry) ([]byte, error) {
+	if int64(len(entries)) > maxLength {
+		return nil, fmt.Errorf("%w: %d entries (max %d)", ErrTooLarge, len(entries), maxLength)
+	}
+	dst = appendMapHeader(dst, int64(len(entries)))
+	for _, e := range entries {
+		dst = append(dst, e.key...)
+		dst = append(dst, e.value...)
+	}
+	return dst, nil
+}
+
+// stringValue returns the bytes of an encoded string, and true, or nil
+// and false if p is not an encoded string.
+func stringValue(p []byte) ([]byte, bool) {
+	f, _, hl, err := ReadHeader(p)
+	if err != nil || f < FormatFixStr || f > FormatStr32 {
+		return nil, false
+	}
+	return p[hl:], true
+}
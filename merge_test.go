@@ -0,0 +1,84 @@
+package msgpack
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMergeMaps(t *testing.T) {
+	// ARRANGE
+	dst := []byte{
+		0x83,
+		0xa1, 'a', 0x01,
+		0xd9, 0x01, 'b', 0x81, 0xa1, 'x', 0x01, // key "b" encoded as str8
+		0x01, 0xa3, 'o', 'n', 'e', // int key
+	}
+	src := []byte{
+		0x83,
+		0xa1, 'b', 0x81, 0xa1, 'y', 0x02,
+		0xa1, 'c', 0xc0,
+		0x01, 0xa3, 'u', 'n', 'o',
+	}
+
+	deep := []byte{0x81, 0xa1, 'b'}
+	for i := 0; i < MaxDepth; i++ {
+		deep = append(deep, 0x81, 0xa1, 'b')
+	}
+	deep = append(deep, 0xc0)
+
+	testcases := []struct {
+		spec   string
+		fn     func([]byte, []byte) ([]byte, error)
+		dst    []byte
+		src    []byte
+		result []byte
+		error
+	}{
+		{spec: "shallow",
+			fn:  MergeMaps,
+			dst: dst,
+			src: src,
+			result: []byte{
+				0x84,
+				0xa1, 'a', 0x01,
+				0xd9, 0x01, 'b', 0x81, 0xa1, 'y', 0x02,
+				0x01, 0xa3, 'u', 'n', 'o',
+				0xa1, 'c', 0xc0,
+			},
+		},
+		{spec: "deep",
+			fn:  DeepMergeMaps,
+			dst: dst,
+			src: src,
+			result: []byte{
+				0x84,
+				0xa1, 'a', 0x01,
+				0xd9, 0x01, 'b', 0x82, 0xa1, 'x', 0x01, 0xa1, 'y', 0x02,
+				0x01, 0xa3, 'u', 'n', 'o',
+				0xa1, 'c', 0xc0,
+			},
+		},
+		{spec: "empty src", fn: MergeMaps, dst: dst, src: []byte{0x80}, result: dst},
+		{spec: "empty dst", fn: MergeMaps, dst: []byte{0x80}, src: src, result: src},
+		{spec: "dst not a map", fn: MergeMaps, dst: []byte{0x90}, src: src, error: ErrTypeMismatch},
+		{spec: "src not a map", fn: DeepMergeMaps, dst: dst, src: []byte{0xc0}, error: ErrTypeMismatch},
+		{spec: "truncated", fn: MergeMaps, dst: dst, src: src[:5], error: ErrTruncated},
+		{spec: "truncated map32 header", fn: MergeMaps, dst: []byte{0xdf, 0xff, 0xff, 0xff, 0xff}, src: src, error: ErrTruncated},
+		{spec: "too deep", fn: DeepMergeMaps, dst: dst, src: deep, error: ErrDepthExceeded},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			// ACT
+			result, err := tc.fn(tc.dst, tc.src)
+
+			// ASSERT
+			testError(t, tc.error, err)
+
+			wanted := tc.result
+			got := result
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %x\ngot    %x", wanted, got)
+			}
+		})
+	}
+}
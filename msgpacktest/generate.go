@@ -0,0 +1,138 @@
+package msgpacktest
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/blugnu/msgpack"
+)
+
+// Generate returns a random, valid msgpack document together with the
+// Go value that it encodes, for property testing encoders, decoders and
+// transcoders.
+//
+// Values are generated as the following Go types:
+//
+//   - nil
+//   - bool
+//   - int64 (or uint64, for values greater than math.MaxInt64)
+//   - float32 and float64
+//   - string and []byte
+//   - time.Time (encoded using the timestamp extension, in UTC)
+//   - []any and map[string]any
+//
+// Arrays and maps are nested to no more than the specified depth; a
+// depth of zero (or less) generates only scalar values.  Lengths and
+// values are chosen to exercise each msgpack format (e.g. fixint,
+// int8..int64, uint8..uint64, fixstr, str8, str16).
+func Generate(r *rand.Rand, depth int) (data []byte, v any) {
+	buf := &bytes.Buffer{}
+	enc := msgpack.NewEncoder(buf)
+	v = generate(enc, r, depth)
+	return buf.Bytes(), v
+}
+
+// generate encodes a random value, returning the Go equivalent.
+func generate(enc msgpack.Encoder, r *rand.Rand, depth int) any {
+	kinds := 8
+	if depth > 0 {
+		kinds = 10
+	}
+
+	switch r.Intn(kinds) {
+	case 0:
+		_ = enc.EncodeNil()
+		return nil
+	case 1:
+		b := r.Intn(2) == 1
+		_ = enc.EncodeBool(b)
+		return b
+	case 2:
+		if r.Intn(8) == 0 {
+			u := uint64(math.MaxInt64) + 1 + uint64(r.Int63())
+			_ = enc.EncodeUint64(u)
+			return u
+		}
+		i := generateInt(r)
+		_ = enc.EncodeInt64(i)
+		return i
+	case 3:
+		f := float32(r.NormFloat64())
+		_ = enc.EncodeFloat32(f)
+		return f
+	case 4:
+		f := r.NormFloat64() * math.Pow10(r.Intn(20))
+		_ = enc.EncodeFloat64(f)
+		return f
+	case 5:
+		s := generateString(r)
+		_ = enc.EncodeString(s)
+		return s
+	case 6:
+		b := make([]byte, generateLen(r))
+		_, _ = r.Read(b)
+		_ = enc.EncodeBytes(b)
+		return b
+	case 7:
+		t := time.Unix(r.Int63n(1<<35)-1<<33, int64(r.Intn(2))*r.Int63n(1e9)).UTC()
+		_ = enc.EncodeTime(t)
+		return t
+	case 8:
+		a := make([]any, r.Intn(20))
+		_ = enc.WriteArrayHeader(len(a))
+		for i := range a {
+			a[i] = generate(enc, r, depth-1)
+		}
+		return a
+	default:
+		n := r.Intn(20)
+		m := make(map[string]any, n)
+		_ = enc.WriteMapHeader(n)
+		for len(m) < n {
+			k := generateString(r)
+			if _, dup := m[k]; dup {
+				continue
+			}
+			_ = enc.EncodeString(k)
+			m[k] = generate(enc, r, depth-1)
+		}
+		return m
+	}
+}
+
+// generateInt returns a random int64 of a randomly chosen magnitude,
+// so that each of the int formats is exercised.
+func generateInt(r *rand.Rand) int64 {
+	bits := []uint{5, 7, 8, 15, 16, 31, 32, 62}[r.Intn(8)]
+	i := r.Int63n(1 << bits)
+	if r.Intn(2) == 0 {
+		return -i
+	}
+	return i
+}
+
+// generateLen returns a random length, usually short but occasionally
+// long enough to require a str16/bin16 format.
+func generateLen(r *rand.Rand) int {
+	switch r.Intn(10) {
+	case 0:
+		return 256 + r.Intn(256)
+	case 1, 2:
+		return 32 + r.Intn(224)
+	default:
+		return r.Intn(32)
+	}
+}
+
+// generateString returns a random (valid utf-8) string.
+func generateString(r *rand.Rand) string {
+	const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789 _-£€✓"
+	runes := []rune(chars)
+	s := make([]rune, generateLen(r))
+	for i := range s {
+		s[i] = runes[r.Intn(len(runes))]
+	}
+	return string(s)
+}
@@ -0,0 +1,75 @@
+package msgpacktest
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/blugnu/msgpack"
+)
+
+// skip returns the number of bytes of the value at the start of data.
+func skip(t *testing.T, data []byte) int {
+	t.Helper()
+
+	_, n, hl, err := msgpack.ReadHeader(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	switch {
+	case msgpack.IsArray(data[0]):
+	case msgpack.IsMap(data[0]):
+		n *= 2
+	default:
+		return hl + int(n)
+	}
+
+	size := hl
+	for i := int64(0); i < n; i++ {
+		size += skip(t, data[size:])
+	}
+	return size
+}
+
+func TestGenerate(t *testing.T) {
+	t.Run("is deterministic", func(t *testing.T) {
+		// ACT
+		a, av := Generate(rand.New(rand.NewSource(42)), 3)
+		b, bv := Generate(rand.New(rand.NewSource(42)), 3)
+
+		// ASSERT
+		if !bytes.Equal(a, b) || !reflect.DeepEqual(av, bv) {
+			t.Errorf("documents generated from the same seed are different")
+		}
+	})
+
+	t.Run("scalars", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < 1000; i++ {
+			// ACT
+			data, v := Generate(r, 0)
+
+			// ASSERT
+			buf := &bytes.Buffer{}
+			_ = msgpack.NewEncoder(buf).Encode(v)
+			if wanted, got := buf.Bytes(), data; !bytes.Equal(wanted, got) {
+				t.Fatalf("%#v:\nwanted %#v\ngot    %#v", v, wanted, got)
+			}
+		}
+	})
+
+	t.Run("documents are well-formed", func(t *testing.T) {
+		r := rand.New(rand.NewSource(2))
+		for i := 0; i < 100; i++ {
+			// ACT
+			data, _ := Generate(r, 4)
+
+			// ASSERT
+			if wanted, got := len(data), skip(t, data); wanted != got {
+				t.Fatalf("\nwanted %d bytes\ngot    %d bytes", wanted, got)
+			}
+		}
+	})
+}
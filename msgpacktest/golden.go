@@ -0,0 +1,139 @@
+// Package msgpacktest provides helpers for testing msgpack encoders.
+package msgpacktest
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blugnu/msgpack"
+)
+
+// update is set by the -msgpacktest.update flag to record golden files.
+var update = flag.Bool("msgpacktest.update", false, "record msgpack golden files")
+
+// Dir is the directory containing golden files.
+var Dir = "testdata"
+
+// Golden compares encoded output with the contents of a golden file,
+// <Dir>/<name>.golden, locking down the wire format of an encoder.
+//
+// When the test is run with the -msgpacktest.update flag the output is
+// recorded to the golden file instead (creating Dir if necessary).
+//
+// Output is compared semantically with respect to the order of map
+// entries: the entries of every map in both the golden file and the
+// output are sorted (see msgpack.SortMapKeys) before they are compared,
+// so that the output of an encoder writing maps with more than one
+// entry (e.g. using msgpack.EncodeMap, in the random order of iteration
+// of a Go map) does not produce spurious failures.  Values are otherwise
+// compared byte-for-byte, so a value encoded using a different format
+// (e.g. an int16 rather than a fixint) does not match.  Data that is not
+// a sequence of valid encoded values is compared without being sorted.
+//
+// On a mismatch, the offset of the first difference (in the sorted
+// data) is reported along with a trace of both the (sorted) wanted and
+// actual output.
+func Golden(t testing.TB, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join(Dir, name+".golden")
+
+	if *update {
+		if err := os.MkdirAll(Dir, 0o755); err != nil {
+			t.Fatalf("msgpacktest: %v", err)
+			return
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("msgpacktest: %v", err)
+		}
+		return
+	}
+
+	wanted, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("msgpacktest: %s does not exist (run with -msgpacktest.update to record it)", path)
+		return
+	}
+	if err != nil {
+		t.Fatalf("msgpacktest: %v", err)
+		return
+	}
+
+	wanted, got = normalize(wanted), normalize(got)
+	if bytes.Equal(wanted, got) {
+		return
+	}
+
+	t.Errorf("msgpacktest: output does not match %s (first difference at offset %d)\nwanted\n%s\ngot\n%s",
+		path, mismatch(wanted, got), trace(wanted), trace(got))
+}
+
+// normalize returns a copy of a sequence of encoded values with the
+// entries of every map sorted, or the data unmodified if it is not a
+// sequence of valid encoded values.
+func normalize(data []byte) []byte {
+	var result []byte
+	for len(data) > 0 {
+		n, ok := valueLen(data)
+		if !ok {
+			return data
+		}
+		sorted, err := msgpack.SortMapKeys(data[:n])
+		if err != nil {
+			return data
+		}
+		result = append(result, sorted...)
+		data = data[n:]
+	}
+	return result
+}
+
+// valueLen returns the number of bytes of the encoded value at the start
+// of data, or false if data does not start with a complete, valid value.
+func valueLen(data []byte) (int, bool) {
+	pos := 0
+	for pending := int64(1); pending > 0; pending-- {
+		f, n, hl, err := msgpack.ReadHeader(data[pos:])
+		if err != nil {
+			return 0, false
+		}
+		pos += hl
+
+		switch {
+		case f >= msgpack.FormatFixArray && f <= msgpack.FormatArray32:
+			pending += n
+		case f >= msgpack.FormatFixMap && f <= msgpack.FormatMap32:
+			pending += 2 * n
+		case n > int64(len(data)-pos):
+			return 0, false
+		default:
+			pos += int(n)
+		}
+	}
+	return pos, true
+}
+
+// mismatch returns the offset of the first difference between a and b.
+func mismatch(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// trace returns a description of each token in encoded msgpack data.
+func trace(data []byte) string {
+	buf := &bytes.Buffer{}
+	// any default options are overridden, so that the trace is complete
+	// (MaxSize) and unaffected by other options
+	enc := msgpack.NewEncoder(io.Discard, msgpack.MaxSize(0), msgpack.Trace(buf))
+	_ = enc.Raw().Write(data)
+	return buf.String()
+}
@@ -0,0 +1,129 @@
+package msgpacktest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blugnu/msgpack"
+)
+
+// fakeT captures failures reported by Golden.
+type fakeT struct {
+	testing.TB
+	msg string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.msg = fmt.Sprintf(format, args...)
+}
+
+func (f *fakeT) Fatalf(format string, args ...any) {
+	f.msg = fmt.Sprintf(format, args...)
+}
+
+func TestGolden(t *testing.T) {
+	// ARRANGE
+	data := append(msgpack.String("name"), msgpack.String("blugnu")...)
+
+	t.Run("when output matches", func(t *testing.T) {
+		// ACT
+		ft := &fakeT{TB: t}
+		Golden(ft, "string", data)
+
+		// ASSERT
+		if ft.msg != "" {
+			t.Errorf("unexpected failure: %s", ft.msg)
+		}
+	})
+
+	t.Run("when output does not match", func(t *testing.T) {
+		// ACT
+		ft := &fakeT{TB: t}
+		Golden(ft, "string", append(msgpack.String("name"), msgpack.String("blugnu!")...))
+
+		// ASSERT
+		wanted := "first difference at offset 5"
+		got := ft.msg
+		if !strings.Contains(got, wanted) {
+			t.Errorf("\nwanted message containing %q\ngot    %q", wanted, got)
+		}
+	})
+
+	t.Run("when map entries are in a different order", func(t *testing.T) {
+		// ARRANGE
+		og := Dir
+		Dir = t.TempDir()
+		defer func() { Dir = og }()
+		recorded := []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02}
+		if err := os.WriteFile(filepath.Join(Dir, "map.golden"), append(recorded, recorded...), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		reordered := []byte{0x82, 0xa1, 'b', 0x02, 0xa1, 'a', 0x01}
+
+		// ACT
+		ft := &fakeT{TB: t}
+		Golden(ft, "map", append(recorded, reordered...))
+
+		// ASSERT
+		if ft.msg != "" {
+			t.Errorf("unexpected failure: %s", ft.msg)
+		}
+	})
+
+	t.Run("when trace would exceed a default MaxSize", func(t *testing.T) {
+		// ARRANGE
+		msgpack.SetDefaultOptions(msgpack.MaxSize(1))
+		defer msgpack.SetDefaultOptions()
+
+		// ACT
+		got := trace(data)
+
+		// ASSERT
+		wanted := "blugnu"
+		if !strings.Contains(got, wanted) {
+			t.Errorf("\nwanted trace containing %q\ngot    %q", wanted, got)
+		}
+	})
+
+	t.Run("when golden file does not exist", func(t *testing.T) {
+		// ACT
+		ft := &fakeT{TB: t}
+		Golden(ft, "missing", data)
+
+		// ASSERT
+		wanted := "run with -msgpacktest.update"
+		got := ft.msg
+		if !strings.Contains(got, wanted) {
+			t.Errorf("\nwanted message containing %q\ngot    %q", wanted, got)
+		}
+	})
+
+	t.Run("when updating", func(t *testing.T) {
+		// ARRANGE
+		og := Dir
+		Dir = filepath.Join(t.TempDir(), "golden")
+		*update = true
+		defer func() {
+			Dir = og
+			*update = false
+		}()
+
+		// ACT
+		ft := &fakeT{TB: t}
+		Golden(ft, "string", data)
+
+		// ASSERT
+		if ft.msg != "" {
+			t.Errorf("unexpected failure: %s", ft.msg)
+		}
+		got, err := os.ReadFile(filepath.Join(Dir, "string.golden"))
+		if err != nil || string(got) != string(data) {
+			t.Errorf("\nwanted %#v\ngot    %#v (err: %v)", data, got, err)
+		}
+	})
+}
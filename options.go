@@ -0,0 +1,238 @@
+package msgpack
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// EncoderOption is a function that configures an Encoder, applied
+// when the Encoder is created by NewEncoder.
+type EncoderOption func(*Encoder)
+
+// defaultOptions holds the options set by SetDefaultOptions (as an
+// []EncoderOption).
+var defaultOptions atomic.Value
+
+// SetDefaultOptions sets options applied to every Encoder subsequently
+// created by NewEncoder, so that consistent settings (e.g. limits or
+// compatibility modes) may be enforced centrally.  Calling
+// SetDefaultOptions with no options removes any default options.
+//
+// Default options are applied before the options specified when an
+// Encoder is created, which therefore override the defaults where an
+// option sets a value (e.g. MaxSize).  Encoders that have already been
+// created, and the Encoders used by the package-level helper functions
+// (String() etc), are not affected.
+//
+// SetDefaultOptions is safe for concurrent use but is intended to be
+// called during initialization.
+func SetDefaultOptions(opts ...EncoderOption) {
+	defaultOptions.Store(append([]EncoderOption{}, opts...))
+}
+
+// DefaultOptions returns the options set by SetDefaultOptions.
+func DefaultOptions() []EncoderOption {
+	opts, _ := defaultOptions.Load().([]EncoderOption)
+	return opts
+}
+
+// TrackContainers returns an option that enables container tracking
+// (debug mode) on an Encoder.
+//
+// When container tracking is enabled, the Encoder counts the values
+// written to each array and map.  WithArray and WithMap use these counts
+// to verify that the number of entries written matches the number
+// declared in the array or map header.
+//
+// Container tracking adds a small overhead to every value encoded and
+// is intended for use when developing and testing hand-written encoders.
+func TrackContainers() EncoderOption {
+	return func(enc *Encoder) {
+		enc.containers = &containers{}
+	}
+}
+
+// MaxSize returns an option that limits the number of bytes that may be
+// written by an Encoder.  If a value would exceed the limit nothing of
+// the value is written and the Encoder enters an error state with an
+// error wrapping ErrTooLarge; nothing further is written while the
+// limit remains exceeded.  The limit is checked before writing any part
+// of a value, including the data of a string or binary value following
+// its header, except that the elements of an array (or entries of a
+// map) are values in their own right and may be written only in part.
+//
+// This protects message brokers with hard limits on the size of a
+// message.  To re-use the Encoder for a new message, call ResetSize
+// (and ResetError, if the limit was exceeded).
+//
+// A limit of zero (or less) means no limit.
+func MaxSize(n int64) EncoderOption {
+	return func(enc *Encoder) {
+		if n < 0 {
+			n = 0
+		}
+		enc.count.limit = n
+	}
+}
+
+// CollectStats returns an option that enables the collection of
+// statistics by an Encoder: the number of values encoded in each format
+// family, the number of bytes written and the number of errors.
+//
+// A snapshot of the statistics is obtained by calling Stats, allowing
+// services to export serialization metrics without wrapping the writer.
+// FormatStats provides a more detailed breakdown of the values encoded
+// and bytes written in each individual format.
+func CollectStats() EncoderOption {
+	return func(enc *Encoder) {
+		enc.stats = &stats{}
+	}
+}
+
+// Trace returns an option that describes each token written by an
+// Encoder to the specified io.Writer (trace mode).
+//
+// Each token (a header and any data that follows it) is described on
+// a separate line with its byte offset, format, length and a preview
+// of its value, indented according to the nesting of arrays and maps.
+// This makes it feasible to diagnose malformed output from complex
+// hand-written streaming encoders.
+//
+// Errors writing the trace are ignored.  Trace mode is intended for use
+// when developing and testing encoders.
+func Trace(w io.Writer) EncoderOption {
+	return func(enc *Encoder) {
+		enc.trace = &tracer{w: w}
+	}
+}
+
+// OldSpec returns an option that restricts an Encoder to the formats
+// of the old msgpack spec, for peers that have not been updated to the
+// current spec (e.g. older Ruby implementations):
+//
+//   - strings of 32-255 bytes are encoded as str16 (there is no str8)
+//   - binary data is encoded as a (raw) string (there are no bin formats)
+//   - EncodeTime returns an error (there are no extension types)
+func OldSpec() EncoderOption {
+	return func(enc *Encoder) {
+		enc.oldSpec = true
+	}
+}
+
+// CompatRubyOldSpec returns an option bundling the options required to
+// interoperate with Ruby msgpack implementations that predate the
+// current spec.  It is equivalent to OldSpec.
+func CompatRubyOldSpec() EncoderOption {
+	return OldSpec()
+}
+
+// CompatPython returns an option bundling the options required to
+// interoperate with the Python msgpack implementation (msgpack >= 1.0)
+// with its default settings.
+//
+// The option changes nothing: strings and binary data are encoded using
+// the str and bin formats (decoded as str and bytes), timestamps using
+// the timestamp extension (decoded as msgpack.Timestamp) and integers
+// using the smallest format for their value, as Python expects.  Note
+// that by default (strict_map_key=True) Python rejects maps with keys
+// that are not strings (or binary data); no option changes the keys
+// written by an Encoder, so such maps must be avoided.
+func CompatPython() EncoderOption {
+	return func(*Encoder) {}
+}
+
+// CompatJS returns an option bundling the options required to
+// interoperate with the JavaScript @msgpack/msgpack implementation with
+// its default settings.
+//
+// The option changes nothing: strings and binary data are encoded using
+// the str and bin formats (decoded as string and Uint8Array), timestamps
+// using the timestamp extension (decoded as a Date) and integers using
+// the smallest format for their value, as JavaScript expects.  Note that
+// by default JavaScript rejects (u)int64 values outside the range of a
+// safe integer (+/- 2^53-1) and truncates timestamps to milliseconds; no
+// option changes the values written by an Encoder, so such values must
+// be avoided (or encoded differently, e.g. as strings).
+func CompatJS() EncoderOption {
+	return func(*Encoder) {}
+}
+
+// OmitNilValues returns an option that omits map entries with a nil
+// value (a nil interface, pointer or map, or a nil []byte, []int,
+// []string, []any or MapSlice) when encoding a map using EncodeMap or
+// EncodeMapCtx.  The map header declares only the number of entries
+// that are encoded.
+//
+// This distinguishes absent values from nil values for consumers that
+// treat these differently, and reduces the size of maps with many
+// optional values.  Entries written individually (e.g. using
+// EncodeField) are not affected.
+func OmitNilValues() EncoderOption {
+	return func(enc *Encoder) {
+		enc.omitNil = true
+	}
+}
+
+// ValueHook returns an option that calls a function with each value to
+// be encoded by Encode, EncodeField and the typed field helpers (e.g.
+// EncodeStringField), encoding the value returned by the function in
+// its place.  This enables cross-cutting concerns, such as redacting
+// secrets, truncating long strings or normalizing floats, without
+// changing every call site:
+//
+//	redact := msgpack.ValueHook(func(key string, v any) any {
+//		if key == "password" {
+//			return "********"
+//		}
+//		return v
+//	})
+//
+// The key is that of the map entry (or field) for which the value is
+// being encoded, if known and a string, otherwise "".  Map keys are not
+// themselves passed to the hook, and values encoded using typed methods
+// other than the field helpers (e.g. EncodeString) are not affected.
+//
+// If the option is specified more than once, the hooks are called in
+// the order specified, each with the value returned by the previous
+// hook.
+func ValueHook(fn func(key string, v any) any) EncoderOption {
+	return func(enc *Encoder) {
+		if prev := enc.hook; prev != nil {
+			enc.hook = func(key string, v any) any { return fn(key, prev(key, v)) }
+			return
+		}
+		enc.hook = fn
+	}
+}
+
+// UnsafeStrings returns an option that writes strings (e.g. encoded by
+// EncodeString or EncodeBytesFromString) to the io.Writer of the
+// Encoder without first copying them to a []byte, for extremely hot
+// paths (such as logging) writing to an io.Writer that does not
+// implement io.StringWriter.
+//
+// The bytes of the string are passed to the io.Writer using unsafe; the
+// io.Writer must honour the io.Writer contract, neither modifying nor
+// retaining the slice.  This is not enabled by default since a writer
+// that does either will corrupt the string, which is immutable.
+func UnsafeStrings() EncoderOption {
+	return func(enc *Encoder) {
+		enc.unsafeStr = true
+	}
+}
+
+// SpoolThreshold returns an option that sets the maximum number of bytes
+// of a sequence that WithSequence will buffer in memory; once the
+// buffered values would exceed this size they are spooled to a
+// temporary file instead.
+//
+// A threshold of zero (or less) means that sequences are always
+// buffered in memory (the default).
+func SpoolThreshold(n int64) EncoderOption {
+	return func(enc *Encoder) {
+		if n < 0 {
+			n = 0
+		}
+		enc.spoolThreshold = n
+	}
+}
@@ -0,0 +1,455 @@
+package msgpack
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaxSize(t *testing.T) {
+	t.Run("when limit is not exceeded", func(t *testing.T) {
+		// ARRANGE
+		buf := &bytes.Buffer{}
+		enc := NewEncoder(buf, MaxSize(4))
+
+		// ACT
+		err := enc.EncodeString("abc")
+
+		// ASSERT
+		testError(t, nil, err)
+
+		wanted := int64(4)
+		got := enc.Size()
+		if wanted != got {
+			t.Errorf("\nwanted %d\ngot    %d", wanted, got)
+		}
+	})
+
+	t.Run("when limit would be exceeded", func(t *testing.T) {
+		// ARRANGE
+		buf := &bytes.Buffer{}
+		enc := NewEncoder(buf, MaxSize(4))
+
+		// ACT
+		err := enc.EncodeString("abcd")
+
+		// ASSERT
+		testError(t, ErrTooLarge, err)
+
+		t.Run("writes nothing", func(t *testing.T) {
+			if buf.Len() != 0 {
+				t.Errorf("\nwanted no output\ngot    %x", buf.Bytes())
+			}
+		})
+
+		t.Run("reports no partial write", func(t *testing.T) {
+			var werr *WriteError
+			if !errors.As(err, &werr) || werr.Partial() {
+				t.Errorf("\nwanted *WriteError (not partial)\ngot    %#v", err)
+			}
+		})
+
+		t.Run("sets error state", func(t *testing.T) {
+			testError(t, ErrTooLarge, enc.ResetError())
+		})
+
+		t.Run("subsequent writes fail", func(t *testing.T) {
+			_ = enc.ResetError()
+
+			err := enc.EncodeInt(1)
+
+			testError(t, ErrTooLarge, err)
+		})
+
+		t.Run("after ResetSize", func(t *testing.T) {
+			_ = enc.ResetError()
+			enc.ResetSize()
+
+			err := enc.EncodeInt(1)
+
+			testError(t, nil, err)
+		})
+	})
+
+	t.Run("when an int would exceed the limit", func(t *testing.T) {
+		// ARRANGE
+		buf := &bytes.Buffer{}
+		enc := NewEncoder(buf, MaxSize(2))
+
+		// ACT
+		err := enc.EncodeInt(1024)
+
+		// ASSERT
+		testError(t, ErrTooLarge, err)
+		if buf.Len() != 0 {
+			t.Errorf("\nwanted no output\ngot    %x", buf.Bytes())
+		}
+	})
+
+	t.Run("when limit is zero", func(t *testing.T) {
+		// ARRANGE
+		buf := &bytes.Buffer{}
+		enc := NewEncoder(buf, MaxSize(0))
+
+		// ACT
+		err := enc.EncodeString("abcd")
+
+		// ASSERT
+		testError(t, nil, err)
+	})
+}
+
+func TestOldSpec(t *testing.T) {
+	// ARRANGE
+	type expect struct {
+		result []byte
+		error
+	}
+	testcases := []struct {
+		spec string
+		fn   func(Encoder) error
+		expect
+	}{
+		{spec: "fixstr",
+			fn:     func(enc Encoder) error { return enc.EncodeString("abc") },
+			expect: expect{result: []byte{maskFixString | 3, 'a', 'b', 'c'}},
+		},
+		{spec: "string of 32 bytes",
+			fn:     func(enc Encoder) error { return enc.WriteStringHeader(32) },
+			expect: expect{result: []byte{typeString16, 0x00, 0x20}},
+		},
+		{spec: "binary data",
+			fn:     func(enc Encoder) error { return enc.EncodeBytes([]byte{1, 2}) },
+			expect: expect{result: []byte{maskFixString | 2, 1, 2}},
+		},
+		{spec: "time",
+			fn:     func(enc Encoder) error { return enc.EncodeTime(time.Unix(1, 0)) },
+			expect: expect{result: []byte{}, error: ErrUnsupportedType},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			enc := NewEncoder(buf, CompatRubyOldSpec())
+
+			// ACT
+			err := tc.fn(enc)
+
+			// ASSERT
+			testError(t, tc.error, err)
+
+			wanted := tc.result
+			got := buf.Bytes()
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+			}
+		})
+	}
+}
+
+func TestCompatPresets(t *testing.T) {
+	// ARRANGE
+	encode := func(enc Encoder) {
+		_ = enc.EncodeString(strings.Repeat("a", 32))
+		_ = enc.EncodeBytes([]byte{1, 2})
+		_ = enc.EncodeInt(-1000)
+		_ = enc.EncodeTime(time.Unix(1, 0))
+		_ = EncodeMap(enc, map[string]any{"a": nil}, nil)
+	}
+	buf := &bytes.Buffer{}
+	encode(NewEncoder(buf))
+	wanted := buf.Bytes()
+
+	testcases := []struct {
+		spec   string
+		option EncoderOption
+	}{
+		{spec: "CompatPython", option: CompatPython()},
+		{spec: "CompatJS", option: CompatJS()},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			enc := NewEncoder(buf, tc.option)
+
+			// ACT
+			encode(enc)
+
+			// ASSERT
+			testError(t, nil, enc.ResetError())
+
+			got := buf.Bytes()
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %x\ngot    %x", wanted, got)
+			}
+		})
+	}
+}
+
+// optional is a struct consisting only of a pointer; a value of the type
+// is not nil (even if the pointer is).
+type optional struct{ p *int }
+
+func TestOmitNilValues(t *testing.T) {
+	// ARRANGE
+	var nilPtr *int
+	var nilErr error
+	RegisterEncoder(func(enc Encoder, o optional) error {
+		if o.p == nil {
+			return enc.EncodeString("none")
+		}
+		return enc.EncodeInt(*o.p)
+	})
+	defer RegisterEncoder[optional](nil)
+	none := []byte{maskFixString | 4, 'n', 'o', 'n', 'e'}
+	testcases := []struct {
+		spec   string
+		fn     func(Encoder) error
+		result []byte
+	}{
+		{spec: "nil values",
+			fn: func(enc Encoder) error {
+				return EncodeMap(enc, map[string]any{"a": nil, "b": nilPtr, "c": []byte(nil), "d": nilErr}, nil)
+			},
+			result: []byte{atomEmptyMap},
+		},
+		{spec: "non-nil values",
+			fn: func(enc Encoder) error {
+				return EncodeMap(enc, map[string]any{"a": nil, "b": 1}, nil)
+			},
+			result: []byte{maskFixMap | 1, maskFixString | 1, 'b', 0x01},
+		},
+		{spec: "custom encoder",
+			fn: func(enc Encoder) error {
+				return EncodeMap(enc, map[int]*int{1: nil}, func(Encoder, int, *int) error { panic("called for nil value") })
+			},
+			result: []byte{atomEmptyMap},
+		},
+		{spec: "non-nillable values",
+			fn: func(enc Encoder) error {
+				return EncodeMap(enc, map[string]int{"a": 0}, nil)
+			},
+			result: []byte{maskFixMap | 1, maskFixString | 1, 'a', 0x00},
+		},
+		{spec: "nil map and function values",
+			fn: func(enc Encoder) error {
+				return EncodeMap(enc, map[string]any{"a": map[string]int(nil), "b": (func())(nil), "c": ""}, nil)
+			},
+			result: []byte{maskFixMap | 1, maskFixString | 1, 'c', atomEmptyString},
+		},
+		{spec: "sorted",
+			fn: func(enc Encoder) error {
+				return EncodeMapSorted(enc, map[string]*int{"a": nil, "b": nil}, nil)
+			},
+			result: []byte{atomEmptyMap},
+		},
+		{spec: "single pointer struct",
+			fn: func(enc Encoder) error {
+				return EncodeMap(enc, map[string]optional{"a": {}}, nil)
+			},
+			result: append([]byte{maskFixMap | 1, maskFixString | 1, 'a'}, none...),
+		},
+		{spec: "single pointer struct in an interface",
+			fn: func(enc Encoder) error {
+				return EncodeMap(enc, map[string]any{"a": optional{}}, nil)
+			},
+			result: append([]byte{maskFixMap | 1, maskFixString | 1, 'a'}, none...),
+		},
+		{spec: "single pointer array",
+			fn: func(enc Encoder) error {
+				return EncodeMap(enc, map[string][1]*int{"a": {}}, func(enc Encoder, k string, _ [1]*int) error {
+					_ = enc.EncodeString(k)
+					return enc.EncodeNil()
+				})
+			},
+			result: []byte{maskFixMap | 1, maskFixString | 1, 'a', atomNil},
+		},
+		{spec: "pairs",
+			fn: func(enc Encoder) error {
+				return EncodePairs(enc, Pairs[string, []byte]{{Key: "a", Value: nil}, {Key: "b", Value: []byte{}}}, nil)
+			},
+			result: []byte{maskFixMap | 1, maskFixString | 1, 'b', typeBin8, 0x00},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			enc := NewEncoder(buf, OmitNilValues())
+
+			// ACT
+			err := tc.fn(enc)
+
+			// ASSERT
+			testError(t, nil, err)
+
+			wanted := tc.result
+			got := buf.Bytes()
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+			}
+		})
+	}
+}
+
+// writer is an io.Writer that does not implement io.StringWriter.
+type writer struct {
+	buf bytes.Buffer
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func TestUnsafeStrings(t *testing.T) {
+	// ARRANGE
+	w := &writer{}
+	enc := NewEncoder(w, UnsafeStrings())
+
+	// ACT
+	_ = enc.EncodeString("abc")
+	err := enc.EncodeBytesFromString("de")
+
+	// ASSERT
+	testError(t, nil, err)
+
+	wanted := []byte{0xa3, 'a', 'b', 'c', typeBin8, 0x02, 'd', 'e'}
+	got := w.buf.Bytes()
+	if !bytes.Equal(wanted, got) {
+		t.Errorf("\nwanted %x\ngot    %x", wanted, got)
+	}
+
+	t.Run("allocations", func(t *testing.T) {
+		// ARRANGE
+		enc := NewEncoder(struct{ io.Writer }{io.Discard}, UnsafeStrings())
+		s := strings.Repeat("a", 64)
+
+		// ACT
+		got := testing.AllocsPerRun(100, func() { _ = enc.EncodeString(s) })
+
+		// ASSERT
+		if got != 0 {
+			t.Errorf("\nwanted 0 allocations\ngot    %v", got)
+		}
+	})
+}
+
+func TestValueHook(t *testing.T) {
+	// ARRANGE
+	redact := ValueHook(func(key string, v any) any {
+		if key == "password" {
+			return "***"
+		}
+		return v
+	})
+	double := ValueHook(func(key string, v any) any {
+		if i, ok := v.(int); ok {
+			return i * 2
+		}
+		return v
+	})
+
+	testcases := []struct {
+		spec   string
+		fn     func(Encoder) error
+		result []byte
+	}{
+		{spec: "Encode",
+			fn:     func(enc Encoder) error { return enc.Encode(2) },
+			result: []byte{0x04},
+		},
+		{spec: "EncodeField",
+			fn:     func(enc Encoder) error { return enc.EncodeField("password", 1) },
+			result: []byte{maskFixString | 8, 'p', 'a', 's', 's', 'w', 'o', 'r', 'd', maskFixString | 3, '*', '*', '*'},
+		},
+		{spec: "EncodeStringField",
+			fn:     func(enc Encoder) error { return enc.EncodeStringField("password", "secret") },
+			result: []byte{maskFixString | 8, 'p', 'a', 's', 's', 'w', 'o', 'r', 'd', maskFixString | 3, '*', '*', '*'},
+		},
+		{spec: "EncodeMap",
+			fn:     func(enc Encoder) error { return EncodeMap(enc, map[string]any{"password": "secret"}, nil) },
+			result: []byte{maskFixMap | 1, maskFixString | 8, 'p', 'a', 's', 's', 'w', 'o', 'r', 'd', maskFixString | 3, '*', '*', '*'},
+		},
+		{spec: "map keys are not hooked",
+			fn:     func(enc Encoder) error { return EncodeMap(enc, map[int]int{1: 1}, nil) },
+			result: []byte{maskFixMap | 1, 0x01, 0x02},
+		},
+		{spec: "typed methods are not hooked",
+			fn:     func(enc Encoder) error { return enc.EncodeInt(1) },
+			result: []byte{0x01},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			enc := NewEncoder(buf, redact, double)
+
+			// ACT
+			err := tc.fn(enc)
+
+			// ASSERT
+			testError(t, nil, err)
+
+			wanted := tc.result
+			got := buf.Bytes()
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+			}
+		})
+	}
+}
+
+func TestSetDefaultOptions(t *testing.T) {
+	// ARRANGE
+	SetDefaultOptions(MaxSize(2), OldSpec())
+	defer SetDefaultOptions()
+
+	t.Run("applied to new encoders", func(t *testing.T) {
+		// ARRANGE
+		enc := NewEncoder(&bytes.Buffer{})
+
+		// ACT
+		err := enc.EncodeString("abc")
+
+		// ASSERT
+		testError(t, ErrTooLarge, err)
+		if !enc.oldSpec {
+			t.Error("wanted oldSpec")
+		}
+	})
+
+	t.Run("overridden by encoder options", func(t *testing.T) {
+		// ARRANGE
+		buf := &bytes.Buffer{}
+		enc := NewEncoder(buf, MaxSize(0))
+
+		// ACT
+		err := enc.EncodeString("abc")
+
+		// ASSERT
+		testError(t, nil, err)
+	})
+
+	t.Run("not applied to helpers", func(t *testing.T) {
+		// ACT
+		got := String("abc")
+
+		// ASSERT
+		wanted := []byte{maskFixString | 3, 'a', 'b', 'c'}
+		if !bytes.Equal(wanted, got) {
+			t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+		}
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		// ACT
+		SetDefaultOptions()
+
+		// ASSERT
+		if got := DefaultOptions(); len(got) != 0 {
+			t.Errorf("\nwanted no options\ngot    %d", len(got))
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package msgpack
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// PatchOp identifies the operation of a Patch.
+type PatchOp string
+
+// Patch operations, as defined by JSON Patch (RFC 6902).
+const (
+	PatchAdd     PatchOp = "add"
+	PatchRemove  PatchOp = "remove"
+	PatchReplace PatchOp = "replace"
+	PatchCopy    PatchOp = "copy"
+	PatchMove    PatchOp = "move"
+	PatchTest    PatchOp = "test"
+)
+
+// Patch is an operation to be applied to an encoded document by
+// ApplyPatch, modelled on JSON Patch (RFC 6902).  Path and From are
+// JSON Pointers (see SetPath); Value is an encoded value.
+//
+//   - add: adds Value at Path; an existing map entry is replaced and a
+//     value added to an array is inserted before the element at the
+//     specified index ("-" appends to the array)
+//   - remove: removes the value at Path
+//   - replace: replaces the (existing) value at Path with Value
+//   - copy: adds a copy of the value at From at Path
+//   - move: removes the value at From and adds it at Path
+//   - test: tests that the value at Path is equal to Value
+type Patch struct {
+	Op    PatchOp
+	Path  string
+	From  string
+	Value []byte
+}
+
+// ApplyPatch applies a sequence of patches to an encoded document,
+// returning the patched document.  The original document is not
+// modified.
+//
+// Patches are applied in order.  If any patch cannot be applied an
+// error is returned identifying the patch and no patched document is
+// returned, i.e. the patches are applied atomically.  An error wrapping
+// ErrPatchTestFailed is returned if a test is not satisfied.
+//
+// Values are compared by a test byte-for-byte; values that are equal
+// but encoded differently (e.g. maps with entries in a different order,
+// or an integer encoded in a larger format than necessary) are not
+// equal.
+func ApplyPatch(doc []byte, patches ...Patch) ([]byte, error) {
+	for i, p := range patches {
+		var err error
+		if doc, err = p.apply(doc); err != nil {
+			return nil, fmt.Errorf("ApplyPatch: [%d] %s %s: %w", i, p.Op, p.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+// apply applies the patch to a document, returning the patched document.
+func (p Patch) apply(doc []byte) ([]byte, error) {
+	switch p.Op {
+	case PatchAdd:
+		if err := checkValue(p.Value); err != nil {
+			return nil, err
+		}
+		return patchAdd(doc, p.Path, p.Value)
+
+	case PatchRemove:
+		return patchRemove(doc, p.Path)
+
+	case PatchReplace:
+		if err := checkValue(p.Value); err != nil {
+			return nil, err
+		}
+		loc, err := patchLocate(doc, p.Path)
+		if err != nil {
+			return nil, err
+		}
+		return loc.splice(doc, loc.value, loc.end, p.Value, 0)
+
+	case PatchCopy, PatchMove:
+		loc, err := patchLocate(doc, p.From)
+		if err != nil {
+			return nil, fmt.Errorf("from %s: %w", p.From, err)
+		}
+		value := append([]byte{}, doc[loc.value:loc.end]...)
+
+		if p.Op == PatchMove {
+			if p.Path == p.From {
+				return doc, nil
+			}
+			if strings.HasPrefix(p.Path, p.From+"/") {
+				return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidFormat)
+			}
+			if doc, err = patchRemove(doc, p.From); err != nil {
+				return nil, fmt.Errorf("from %s: %w", p.From, err)
+			}
+		}
+		return patchAdd(doc, p.Path, value)
+
+	case PatchTest:
+		loc, err := patchLocate(doc, p.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(doc[loc.value:loc.end], p.Value) {
+			return nil, fmt.Errorf("%w: value is %x", ErrPatchTestFailed, doc[loc.value:loc.end])
+		}
+		return doc, nil
+
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidFormat, p.Op)
+	}
+}
+
+// patchLocate returns the location of the (existing) value at a path.
+func patchLocate(doc []byte, path string) (location, error) {
+	loc, found, err := locatePath(doc, path)
+	switch {
+	case err != nil:
+		return loc, err
+	case !found:
+		return loc, ErrNotFound
+	}
+	return loc, nil
+}
+
+// patchAdd adds a value to a document, inserting it into an array
+// or adding (or replacing) a map entry.
+func patchAdd(doc []byte, path string, value []byte) ([]byte, error) {
+	loc, found, err := locatePath(doc, path)
+	switch {
+	case err != nil:
+		return nil, err
+	case loc.parent < 0, found && loc.isMap:
+		return loc.splice(doc, loc.value, loc.end, value, 0)
+	case loc.isMap:
+		entry := AppendString(nil, loc.key)
+		return loc.splice(doc, loc.end, loc.end, append(entry, value...), 1)
+	default:
+		return loc.splice(doc, loc.entry, loc.entry, value, 1)
+	}
+}
+
+// patchRemove removes the value at a path from a document.
+func patchRemove(doc []byte, path string) ([]byte, error) {
+	loc, err := patchLocate(doc, path)
+	switch {
+	case err != nil:
+		return nil, err
+	case loc.parent < 0:
+		return nil, fmt.Errorf("%w: cannot remove the document", ErrNotFound)
+	}
+	return loc.splice(doc, loc.entry, loc.end, nil, -1)
+}
@@ -0,0 +1,101 @@
+package msgpack
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestApplyPatch(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		spec    string
+		patches []Patch
+		result  []byte
+		error
+	}{
+		{spec: "no patches", result: testDoc()},
+		{spec: "add (map entry)",
+			patches: []Patch{{Op: PatchAdd, Path: "/z", Value: []byte{0xc0}}},
+			result:  []byte{0x84, 0xa1, 'a', 0x01, 0xa1, 'b', 0x92, 0xc3, 0xc2, 0xa3, 'c', '/', 'd', 0x81, 0xa1, 'e', 0xa1, 'x', 0xa1, 'z', 0xc0},
+		},
+		{spec: "add (existing map entry)",
+			patches: []Patch{{Op: PatchAdd, Path: "/a", Value: []byte{0x02}}},
+			result:  []byte{0x83, 0xa1, 'a', 0x02, 0xa1, 'b', 0x92, 0xc3, 0xc2, 0xa3, 'c', '/', 'd', 0x81, 0xa1, 'e', 0xa1, 'x'},
+		},
+		{spec: "add (array insert)",
+			patches: []Patch{{Op: PatchAdd, Path: "/b/0", Value: []byte{0xc0}}},
+			result:  []byte{0x83, 0xa1, 'a', 0x01, 0xa1, 'b', 0x93, 0xc0, 0xc3, 0xc2, 0xa3, 'c', '/', 'd', 0x81, 0xa1, 'e', 0xa1, 'x'},
+		},
+		{spec: "add (array append)",
+			patches: []Patch{{Op: PatchAdd, Path: "/b/-", Value: []byte{0xc0}}},
+			result:  []byte{0x83, 0xa1, 'a', 0x01, 0xa1, 'b', 0x93, 0xc3, 0xc2, 0xc0, 0xa3, 'c', '/', 'd', 0x81, 0xa1, 'e', 0xa1, 'x'},
+		},
+		{spec: "add (document)",
+			patches: []Patch{{Op: PatchAdd, Path: "", Value: []byte{0xc0}}},
+			result:  []byte{0xc0},
+		},
+		{spec: "remove",
+			patches: []Patch{{Op: PatchRemove, Path: "/b/1"}},
+			result:  []byte{0x83, 0xa1, 'a', 0x01, 0xa1, 'b', 0x91, 0xc3, 0xa3, 'c', '/', 'd', 0x81, 0xa1, 'e', 0xa1, 'x'},
+		},
+		{spec: "replace",
+			patches: []Patch{{Op: PatchReplace, Path: "/c~1d", Value: []byte{0x80}}},
+			result:  []byte{0x83, 0xa1, 'a', 0x01, 0xa1, 'b', 0x92, 0xc3, 0xc2, 0xa3, 'c', '/', 'd', 0x80},
+		},
+		{spec: "copy",
+			patches: []Patch{{Op: PatchCopy, From: "/c~1d/e", Path: "/b/1"}},
+			result:  []byte{0x83, 0xa1, 'a', 0x01, 0xa1, 'b', 0x93, 0xc3, 0xa1, 'x', 0xc2, 0xa3, 'c', '/', 'd', 0x81, 0xa1, 'e', 0xa1, 'x'},
+		},
+		{spec: "move",
+			patches: []Patch{{Op: PatchMove, From: "/b", Path: "/c~1d/b"}},
+			result:  []byte{0x82, 0xa1, 'a', 0x01, 0xa3, 'c', '/', 'd', 0x82, 0xa1, 'e', 0xa1, 'x', 0xa1, 'b', 0x92, 0xc3, 0xc2},
+		},
+		{spec: "move (same path)", patches: []Patch{{Op: PatchMove, From: "/a", Path: "/a"}}, result: testDoc()},
+		{spec: "test", patches: []Patch{{Op: PatchTest, Path: "/b/0", Value: []byte{0xc3}}}, result: testDoc()},
+		{spec: "multiple",
+			patches: []Patch{
+				{Op: PatchTest, Path: "/a", Value: []byte{0x01}},
+				{Op: PatchReplace, Path: "/a", Value: []byte{0x02}},
+				{Op: PatchRemove, Path: "/b"},
+				{Op: PatchRemove, Path: "/c~1d"},
+			},
+			result: []byte{0x81, 0xa1, 'a', 0x02},
+		},
+		{spec: "test failed",
+			patches: []Patch{
+				{Op: PatchReplace, Path: "/a", Value: []byte{0x02}},
+				{Op: PatchTest, Path: "/a", Value: []byte{0x01}},
+			},
+			error: ErrPatchTestFailed,
+		},
+		{spec: "remove (missing)", patches: []Patch{{Op: PatchRemove, Path: "/z"}}, error: ErrNotFound},
+		{spec: "remove (document)", patches: []Patch{{Op: PatchRemove, Path: ""}}, error: ErrNotFound},
+		{spec: "replace (missing)", patches: []Patch{{Op: PatchReplace, Path: "/z", Value: []byte{0xc0}}}, error: ErrNotFound},
+		{spec: "add (invalid value)", patches: []Patch{{Op: PatchAdd, Path: "/z"}}, error: ErrInvalidFormat},
+		{spec: "copy (missing)", patches: []Patch{{Op: PatchCopy, From: "/z", Path: "/y"}}, error: ErrNotFound},
+		{spec: "move (into itself)", patches: []Patch{{Op: PatchMove, From: "/b", Path: "/b/0"}}, error: ErrInvalidFormat},
+		{spec: "invalid op", patches: []Patch{{Op: "merge", Path: "/a"}}, error: ErrInvalidFormat},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			// ARRANGE
+			doc := testDoc()
+
+			// ACT
+			result, err := ApplyPatch(doc, tc.patches...)
+
+			// ASSERT
+			testError(t, tc.error, err)
+
+			wanted := tc.result
+			got := result
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %x\ngot    %x", wanted, got)
+			}
+
+			if !bytes.Equal(doc, testDoc()) {
+				t.Errorf("document was modified: %x", doc)
+			}
+		})
+	}
+}
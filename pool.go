@@ -0,0 +1,96 @@
+package msgpack
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+)
+
+// pool provides a pool of Encoders, each writing to a bytes.Buffer,
+// used by the package-level helper functions (String() etc) when
+// encoding values to a []byte.
+var pool = &sync.Pool{New: func() any { return newBufferEncoder() }}
+
+// poolDisabled and poolMaxBufferSize hold the pool configuration (see
+// SetPooling and SetMaxPooledBufferSize); they are accessed atomically.
+var (
+	poolDisabled      int32
+	poolMaxBufferSize int64
+)
+
+// SetPooling enables or disables pooling of the Encoders (and buffers)
+// used by the package-level helper functions (String() etc).  Pooling
+// is enabled by default.
+//
+// When pooling is disabled a new Encoder and buffer is allocated for
+// each call to a helper function.
+func SetPooling(enabled bool) {
+	v := int32(1)
+	if enabled {
+		v = 0
+	}
+	atomic.StoreInt32(&poolDisabled, v)
+}
+
+// SetMaxPooledBufferSize sets the maximum capacity (in bytes) of a buffer
+// that will be retained in the pool used by the package-level helper
+// functions (String() etc).  A buffer that has grown beyond this size
+// (when encoding a large value) is discarded rather than returned to the
+// pool, so that it may be garbage collected.
+//
+// A size of zero (the default) means there is no maximum.
+func SetMaxPooledBufferSize(n int) {
+	if n < 0 {
+		n = 0
+	}
+	atomic.StoreInt64(&poolMaxBufferSize, int64(n))
+}
+
+// newBufferEncoder returns a new Encoder writing to a new bytes.Buffer.
+// Default options are not applied.
+func newBufferEncoder() *Encoder {
+	enc := newEncoder(&bytes.Buffer{})
+	return &enc
+}
+
+// getEncoder returns an Encoder from the pool (or a new Encoder, if
+// pooling is disabled).  The buffer of the Encoder is reset.
+func getEncoder() *Encoder {
+	if atomic.LoadInt32(&poolDisabled) != 0 {
+		return newBufferEncoder()
+	}
+
+	enc := pool.Get().(*Encoder)
+	enc.out.(*bytes.Buffer).Reset()
+	return enc
+}
+
+// putEncoder returns an Encoder to the pool, unless pooling is disabled
+// or the buffer of the Encoder exceeds the maximum pooled buffer size.
+func putEncoder(enc *Encoder) {
+	if atomic.LoadInt32(&poolDisabled) != 0 {
+		return
+	}
+
+	if limit := atomic.LoadInt64(&poolMaxBufferSize); limit > 0 && int64(enc.out.(*bytes.Buffer).Cap()) > limit {
+		return
+	}
+
+	_ = enc.ResetError()
+	enc.ResetSize()
+	pool.Put(enc)
+}
+
+// encodeBytes calls the specified function with a pooled Encoder,
+// returning a copy of the bytes written by the function and any
+// error returned.
+func encodeBytes(fn func(Encoder) error) ([]byte, error) {
+	enc := getEncoder()
+	defer putEncoder(enc)
+
+	if err := fn(*enc); err != nil {
+		return nil, err
+	}
+
+	return append([]byte{}, enc.out.(*bytes.Buffer).Bytes()...), nil
+}
@@ -0,0 +1,66 @@
+package msgpack
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+	t.Run("when pooling is disabled", func(t *testing.T) {
+		// ARRANGE
+		SetPooling(false)
+		defer SetPooling(true)
+
+		// ACT
+		a := getEncoder()
+		putEncoder(a)
+		b := getEncoder()
+
+		// ASSERT
+		if a == b {
+			t.Error("encoder was re-used")
+		}
+
+		t.Run("helpers still work", func(t *testing.T) {
+			wanted := []byte{maskFixString | 1, 'a'}
+			got := String("a")
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+			}
+		})
+	})
+
+	t.Run("when buffer exceeds maximum size", func(t *testing.T) {
+		// ARRANGE
+		SetMaxPooledBufferSize(64)
+		defer SetMaxPooledBufferSize(0)
+
+		enc := getEncoder()
+		_ = enc.EncodeString(strings.Repeat("a", 128))
+
+		// ACT
+		putEncoder(enc)
+
+		// ASSERT
+		// the pool may legitimately discard any item, so we can only
+		// test that the oversized encoder is never returned
+		for i := 0; i < 10; i++ {
+			if got := getEncoder(); got == enc {
+				t.Fatal("oversized encoder was retained")
+			}
+		}
+	})
+
+	t.Run("SetMaxPooledBufferSize(-1)", func(t *testing.T) {
+		// ACT
+		SetMaxPooledBufferSize(-1)
+
+		// ASSERT
+		wanted := int64(0)
+		got := poolMaxBufferSize
+		if wanted != got {
+			t.Errorf("\nwanted %d\ngot    %d", wanted, got)
+		}
+	})
+}
@@ -0,0 +1,199 @@
+package msgpack
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// RawWriter writes values as big-endian raw bytes, with no msgpack type
+// indicator or other encoding.  It provides the primitive i/o layer
+// used by an Encoder, for specialised streaming scenarios (e.g. writing
+// pre-encoded msgpack data, or the data of an extension type following
+// a header written by WriteHeader).
+//
+// A RawWriter obtained from an Encoder (see Encoder.Raw) writes to the
+// current writer of the Encoder, subject to any limit on the size of the
+// output and included in any trace or statistics.  A RawWriter may also
+// be created for any io.Writer using NewRawWriter.
+type RawWriter struct {
+	enc Encoder
+}
+
+// NewRawWriter returns a new RawWriter that writes to the specified
+// io.Writer.
+func NewRawWriter(out io.Writer) RawWriter {
+	return RawWriter{newEncoder(out)}
+}
+
+// Raw returns a RawWriter writing to the current writer of the Encoder.
+// Bytes written by the RawWriter are not recorded as values in any open
+// container (when container tracking is enabled).
+func (enc Encoder) Raw() RawWriter {
+	return RawWriter{enc}
+}
+
+// Write writes a value to the writer as big-endian raw bytes.
+//
+// This method is provided as a more efficient alternative to
+// binary.Write(), optimised for handling the limited types that
+// a msgpack encoder is required to write.
+//
+// If the Encoder of a RawWriter obtained from Encoder.Raw is in an
+// error state nothing is written and the error is returned.
+//
+// The types supported are:
+//
+//   - []byte
+//   - byte / uint8
+//   - int8 / int16 / int32 / int64
+//   - uint16 / uint32 / uint64
+//   - float32 / float64
+//
+// The function will panic if a value of any other type is specified.
+//
+// To encode a []byte as msgpack encoded binary data, use
+// Encoder.EncodeBytes.
+func (w RawWriter) Write(b any) error {
+	enc := w.enc
+	if err := enc.state(); err != nil {
+		return err
+	}
+
+	switch v := b.(type) {
+	// byte family
+	case uint8: // a.k.a byte
+		return enc.write(append(enc.buffer(), v))
+	case []byte:
+		return enc.writeBytes(v)
+
+	// int family
+	case int8:
+		return enc.write(append(enc.buffer(), byte(v)))
+	case int16:
+		return enc.writeUint16(uint16(v))
+	case uint16:
+		return enc.writeUint16(v)
+	case int32:
+		return enc.writeUint32(uint32(v))
+	case uint32:
+		return enc.writeUint32(v)
+	case int64:
+		return enc.writeUint64(uint64(v))
+	case uint64:
+		return enc.writeUint64(v)
+
+	// float family
+	case float32:
+		return enc.writeUint32(math.Float32bits(v))
+	case float64:
+		return enc.writeUint64(math.Float64bits(v))
+
+	// unsupported
+	default:
+		panic(fmt.Errorf("Write: %w: %T", ErrUnsupportedType, v))
+	}
+}
+
+// RawReader reads big-endian raw bytes written by a RawWriter (or an
+// Encoder), for specialised scenarios such as reading the data of an
+// extension type following a header parsed by ReadHeader.
+//
+// The RawReader type is not safe for concurrent use.
+type RawReader struct {
+	in      io.Reader
+	err     error
+	scratch [8]byte
+}
+
+// NewRawReader returns a new RawReader that reads from the specified
+// io.Reader.
+func NewRawReader(in io.Reader) *RawReader {
+	return &RawReader{in: in}
+}
+
+// Read reads a value from the reader as big-endian raw bytes, into the
+// value referenced by the specified pointer (or the []byte specified,
+// reading len(b) bytes).
+//
+// The types supported are the pointer types corresponding to the types
+// supported by RawWriter.Write (*uint8, *int16, *float64 etc) and []byte.
+// The function will panic if a value of any other type is specified.
+//
+// If the reader has no more data io.EOF is returned.  If the reader
+// ends part way through a value an error wrapping ErrTruncated is
+// returned and the value is not modified (a []byte may have been
+// partially filled).  Any error is retained and returned on subsequent
+// calls to Read.
+func (r *RawReader) Read(v any) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	var n int
+	switch v := v.(type) {
+	case []byte:
+		return r.read(v)
+	case *uint8, *int8:
+		n = 1
+	case *uint16, *int16:
+		n = 2
+	case *uint32, *int32, *float32:
+		n = 4
+	case *uint64, *int64, *float64:
+		n = 8
+	default:
+		panic(fmt.Errorf("Read: %w: %T", ErrUnsupportedType, v))
+	}
+
+	p := r.scratch[:n]
+	if err := r.read(p); err != nil {
+		return err
+	}
+
+	be := binary.BigEndian
+	switch v := v.(type) {
+	case *uint8:
+		*v = p[0]
+	case *int8:
+		*v = int8(p[0])
+	case *uint16:
+		*v = be.Uint16(p)
+	case *int16:
+		*v = int16(be.Uint16(p))
+	case *uint32:
+		*v = be.Uint32(p)
+	case *int32:
+		*v = int32(be.Uint32(p))
+	case *float32:
+		*v = math.Float32frombits(be.Uint32(p))
+	case *uint64:
+		*v = be.Uint64(p)
+	case *int64:
+		*v = int64(be.Uint64(p))
+	case *float64:
+		*v = math.Float64frombits(be.Uint64(p))
+	}
+	return nil
+}
+
+// Err returns the error retained by the RawReader, or nil.
+func (r *RawReader) Err() error {
+	return r.err
+}
+
+// read fills p from the reader, retaining any error.
+func (r *RawReader) read(p []byte) error {
+	n, err := io.ReadFull(r.in, p)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		r.err = fmt.Errorf("Read: %w: wanted %d bytes, got %d", ErrTruncated, len(p), n)
+	default:
+		r.err = err
+	}
+	return r.err
+}
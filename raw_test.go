@@ -0,0 +1,176 @@
+package msgpack
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestRawWriter(t *testing.T) {
+	// ARRANGE
+	buf := &bytes.Buffer{}
+	w := NewRawWriter(buf)
+
+	// ACT
+	_ = w.Write(byte(0x01))
+	_ = w.Write(int16(-2))
+	_ = w.Write(uint32(3))
+	_ = w.Write(float64(1.5))
+	err := w.Write([]byte{0x04, 0x05})
+
+	// ASSERT
+	testError(t, nil, err)
+
+	wanted := []byte{0x01, 0xff, 0xfe, 0x00, 0x00, 0x00, 0x03, 0x3f, 0xf8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04, 0x05}
+	got := buf.Bytes()
+	if !bytes.Equal(wanted, got) {
+		t.Errorf("\nwanted %x\ngot    %x", wanted, got)
+	}
+
+	t.Run("unsupported type", func(t *testing.T) {
+		// ASSERT
+		defer testPanic(t, ErrUnsupportedType)
+
+		// ACT
+		_ = w.Write(true)
+	})
+
+	t.Run("encoder", func(t *testing.T) {
+		// ARRANGE
+		enc, buf := NewTestEncoder()
+		_ = enc.EncodeString("id")
+
+		// ACT
+		err := enc.Raw().Write([]byte{0xc0})
+
+		// ASSERT
+		testError(t, nil, err)
+
+		wanted := []byte{0xa2, 'i', 'd', 0xc0}
+		got := buf.Bytes()
+		if !bytes.Equal(wanted, got) {
+			t.Errorf("\nwanted %x\ngot    %x", wanted, got)
+		}
+	})
+
+	t.Run("encoder in error state", func(t *testing.T) {
+		// ARRANGE
+		encerr := errors.New("encoder error")
+		enc, buf := NewTestEncoder()
+		*enc.err = encerr
+
+		// ACT
+		err := enc.Raw().Write(byte(0x01))
+
+		// ASSERT
+		testError(t, encerr, err)
+		if buf.Len() != 0 {
+			t.Errorf("\nwanted no output\ngot    %x", buf.Bytes())
+		}
+	})
+
+	t.Run("encoder size limit", func(t *testing.T) {
+		// ARRANGE
+		enc := NewEncoder(&bytes.Buffer{}, MaxSize(2))
+
+		// ACT
+		err := enc.Raw().Write(uint32(1))
+
+		// ASSERT
+		testError(t, ErrTooLarge, err)
+	})
+}
+
+func TestRawReader(t *testing.T) {
+	// ARRANGE
+	data := []byte{0x01, 0xff, 0xfe, 0x00, 0x00, 0x00, 0x03, 0x3f, 0xf8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04, 0x05}
+	r := NewRawReader(bytes.NewReader(data))
+
+	var (
+		b   byte
+		i16 int16
+		u32 uint32
+		f64 float64
+		p   = make([]byte, 2)
+	)
+
+	// ACT
+	_ = r.Read(&b)
+	_ = r.Read(&i16)
+	_ = r.Read(&u32)
+	_ = r.Read(&f64)
+	err := r.Read(p)
+
+	// ASSERT
+	testError(t, nil, err)
+
+	wanted := "1 -2 3 1.5 0405"
+	got := fmt.Sprintf("%d %d %d %v %x", b, i16, u32, f64, p)
+	if wanted != got {
+		t.Errorf("\nwanted %s\ngot    %s", wanted, got)
+	}
+
+	t.Run("eof", func(t *testing.T) {
+		// ACT
+		err := r.Read(&b)
+
+		// ASSERT
+		testError(t, io.EOF, err)
+		testError(t, io.EOF, r.Err())
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		// ARRANGE
+		r := NewRawReader(bytes.NewReader([]byte{0x01, 0x02}))
+		u := uint32(42)
+
+		// ACT
+		err := r.Read(&u)
+
+		// ASSERT
+		testError(t, ErrTruncated, err)
+		if u != 42 {
+			t.Errorf("\nwanted 42\ngot    %d", u)
+		}
+
+		// ACT
+		err = r.Read(&b)
+
+		// ASSERT
+		testError(t, ErrTruncated, err)
+	})
+
+	t.Run("unsupported type", func(t *testing.T) {
+		// ASSERT
+		defer testPanic(t, ErrUnsupportedType)
+
+		// ACT
+		_ = NewRawReader(bytes.NewReader(data)).Read(true)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		// ARRANGE
+		buf := &bytes.Buffer{}
+		w := NewRawWriter(buf)
+		_ = w.Write(int64(-1 << 40))
+		_ = w.Write(float32(-0.25))
+		r := NewRawReader(buf)
+
+		var (
+			i64 int64
+			f32 float32
+		)
+
+		// ACT
+		_ = r.Read(&i64)
+		err := r.Read(&f32)
+
+		// ASSERT
+		testError(t, nil, err)
+		if i64 != -1<<40 || f32 != -0.25 {
+			t.Errorf("\nwanted %d %v\ngot    %d %v", int64(-1<<40), float32(-0.25), i64, f32)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package msgpack
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// registration is a function registered by RegisterEncoder for values of
+// a type T.  The type is identified by key, a nil *T: two such keys are
+// equal only if they are pointers to the same type.
+type registration struct {
+	key    any
+	match  func(any) bool
+	encode func(Encoder, any) error
+}
+
+// registry holds the functions registered by RegisterEncoder.  A registry
+// is replaced (never modified) when a function is registered, so that
+// Encode may consult it without locking.
+//
+// The function (if any) registered for the dynamic type of each value
+// encoded is identified only once, the first time a value of the type is
+// encoded, and cached, keyed by the type word of the value (see typeWord).
+type registry struct {
+	registrations []registration
+	cache         sync.Map // type word => func(Encoder, any) error (nil if none is registered)
+}
+
+// registered holds the current *registry; registering is serialized by
+// registering.
+var (
+	registered  atomic.Value
+	registering sync.Mutex
+)
+
+// RegisterEncoder registers a function used by Encoder.Encode to encode
+// values of type T, allowing an application to encode types that are
+// not otherwise supported (e.g. types provided by third-party packages
+// that cannot be modified) or to override the encoding of a supported
+// type:
+//
+//	msgpack.RegisterEncoder(func(enc msgpack.Encoder, d decimal.Decimal) error {
+//		return enc.EncodeString(d.String())
+//	})
+//
+// A registered function is consulted before any other encoding of a
+// value with a dynamic type of T; T should therefore be a concrete
+// (non-interface) type.  Registering a function for a type that already
+// has a registered function replaces that function; registering a nil
+// function removes any registered function.
+//
+// RegisterEncoder is safe for concurrent use but is intended to be
+// called during initialization.
+func RegisterEncoder[T any](fn func(Encoder, T) error) {
+	registering.Lock()
+	defer registering.Unlock()
+
+	key := any((*T)(nil))
+	current := registrations()
+	updated := make([]registration, 0, len(current)+1)
+	for _, r := range current {
+		if r.key != key {
+			updated = append(updated, r)
+		}
+	}
+	if fn != nil {
+		updated = append(updated, registration{
+			key:    key,
+			match:  func(v any) bool { _, ok := v.(T); return ok },
+			encode: func(enc Encoder, v any) error { return fn(enc, v.(T)) },
+		})
+	}
+	registered.Store(&registry{registrations: updated})
+}
+
+// registrations returns the functions currently registered.
+func registrations() []registration {
+	if r, _ := registered.Load().(*registry); r != nil {
+		return r.registrations
+	}
+	return nil
+}
+
+// registeredEncoder returns the function registered to encode the
+// specified value, or nil if there is no registered function.
+func registeredEncoder(v any) func(Encoder, any) error {
+	r, _ := registered.Load().(*registry)
+	if r == nil || len(r.registrations) == 0 || v == nil {
+		return nil
+	}
+
+	t := typeWord(v)
+	if fn, ok := r.cache.Load(t); ok {
+		return fn.(func(Encoder, any) error)
+	}
+
+	var fn func(Encoder, any) error
+	for _, reg := range r.registrations {
+		if reg.match(v) {
+			fn = reg.encode
+			break
+		}
+	}
+	r.cache.Store(t, fn)
+	return fn
+}
@@ -0,0 +1,75 @@
+package msgpack
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestRegisterEncoder(t *testing.T) {
+	// ARRANGE
+	type celsius float64
+	RegisterEncoder(func(enc Encoder, c celsius) error { return enc.EncodeInt(int(c)) })
+	RegisterEncoder(func(enc Encoder, t time.Time) error { return enc.EncodeInt64(t.Unix()) })
+	defer RegisterEncoder[celsius](nil)
+	defer RegisterEncoder[time.Time](nil)
+
+	testcases := []struct {
+		spec   string
+		value  any
+		result []byte
+	}{
+		{spec: "unsupported type", value: celsius(21.5), result: []byte{21}},
+		{spec: "overridden type", value: time.Unix(1, 0), result: []byte{0x01}},
+		{spec: "unregistered type", value: 2, result: []byte{0x02}},
+		{spec: "nil", value: nil, result: []byte{atomNil}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			enc, buf := NewTestEncoder()
+
+			// ACT
+			err := enc.Encode(tc.value)
+
+			// ASSERT
+			testError(t, nil, err)
+
+			wanted := tc.result
+			got := buf.Bytes()
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+			}
+		})
+	}
+
+	t.Run("unregistering", func(t *testing.T) {
+		defer testPanic(t, ErrUnsupportedType)
+
+		// ARRANGE
+		enc, _ := NewTestEncoder()
+		RegisterEncoder[celsius](nil)
+
+		// ACT
+		_ = enc.Encode(celsius(1))
+	})
+
+	t.Run("replacing", func(t *testing.T) {
+		// ARRANGE
+		enc, buf := NewTestEncoder()
+		RegisterEncoder(func(enc Encoder, c celsius) error { return enc.EncodeInt(1) })
+		_ = enc.Encode(celsius(0))
+		RegisterEncoder(func(enc Encoder, c celsius) error { return enc.EncodeInt(2) })
+
+		// ACT
+		err := enc.Encode(celsius(0))
+
+		// ASSERT
+		testError(t, nil, err)
+
+		wanted := []byte{0x01, 0x02}
+		got := buf.Bytes()
+		if !bytes.Equal(wanted, got) {
+			t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+		}
+	})
+}
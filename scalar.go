@@ -0,0 +1,33 @@
+package msgpack
+
+// Bool returns a []byte containing a msgpack encoded bool.
+func Bool(b bool) []byte {
+	r, _ := encodeBytes(func(enc Encoder) error { return enc.EncodeBool(b) })
+	return r
+}
+
+// Float32 returns a []byte containing a msgpack encoded float32.
+func Float32(f float32) []byte {
+	b, _ := encodeBytes(func(enc Encoder) error { return enc.EncodeFloat32(f) })
+	return b
+}
+
+// Float64 returns a []byte containing a msgpack encoded float64.
+func Float64(f float64) []byte {
+	b, _ := encodeBytes(func(enc Encoder) error { return enc.EncodeFloat64(f) })
+	return b
+}
+
+// Int returns a []byte containing a msgpack encoded integer, using
+// the most efficient encoding for the value.
+func Int(i int64) []byte {
+	b, _ := encodeBytes(func(enc Encoder) error { return enc.EncodeInt64(i) })
+	return b
+}
+
+// Uint returns a []byte containing a msgpack encoded unsigned integer,
+// using the most efficient encoding for the value.
+func Uint(i uint64) []byte {
+	b, _ := encodeBytes(func(enc Encoder) error { return enc.EncodeUint64(i) })
+	return b
+}
@@ -0,0 +1,36 @@
+package msgpack
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestScalars(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		spec   string // for information only, not part of the test
+		fn     func() []byte
+		result []byte
+	}{
+		{spec: "Bool(true)", fn: func() []byte { return Bool(true) }, result: []byte{atomTrue}},
+		{spec: "Bool(false)", fn: func() []byte { return Bool(false) }, result: []byte{atomFalse}},
+		{spec: "Float32(1)", fn: func() []byte { return Float32(1) }, result: []byte{typeFloat32, 0x3f, 0x80, 0x00, 0x00}},
+		{spec: "Float64(1)", fn: func() []byte { return Float64(1) }, result: []byte{typeFloat64, 0x3f, 0xf0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{spec: "Int(-1)", fn: func() []byte { return Int(-1) }, result: []byte{0xff}},
+		{spec: "Int(-129)", fn: func() []byte { return Int(-129) }, result: []byte{typeInt16, 0xff, 0x7f}},
+		{spec: "Uint(1)", fn: func() []byte { return Uint(1) }, result: []byte{0x01}},
+		{spec: "Uint(255)", fn: func() []byte { return Uint(255) }, result: []byte{typeUint8, 0xff}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			// ACT
+			got := tc.fn()
+
+			// ASSERT
+			wanted := tc.result
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+			}
+		})
+	}
+}
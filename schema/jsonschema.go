@@ -0,0 +1,486 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/blugnu/msgpack"
+)
+
+// jsonSchema is a Schema compiled from a JSON Schema.
+type jsonSchema struct {
+	types      []string
+	properties map[string]*jsonSchema
+	required   []string
+	strict     bool // additionalProperties: false
+	items      *jsonSchema
+	enum       []any // nil, bool, json.Number or string
+	minimum    *float64
+	maximum    *float64
+	exMinimum  *float64
+	exMaximum  *float64
+	minLength  *int64
+	maxLength  *int64
+	minItems   *int64
+	maxItems   *int64
+}
+
+// jsonSchemaDoc is the JSON representation of the supported subset of
+// JSON Schema.
+type jsonSchemaDoc struct {
+	Type                 json.RawMessage            `json:"type"`
+	Properties           map[string]json.RawMessage `json:"properties"`
+	Required             []string                   `json:"required"`
+	AdditionalProperties *bool                      `json:"additionalProperties"`
+	Items                json.RawMessage            `json:"items"`
+	Enum                 []json.RawMessage          `json:"enum"`
+	Const                json.RawMessage            `json:"const"`
+	Minimum              *float64                   `json:"minimum"`
+	Maximum              *float64                   `json:"maximum"`
+	ExclusiveMinimum     *float64                   `json:"exclusiveMinimum"`
+	ExclusiveMaximum     *float64                   `json:"exclusiveMaximum"`
+	MinLength            *int64                     `json:"minLength"`
+	MaxLength            *int64                     `json:"maxLength"`
+	MinItems             *int64                     `json:"minItems"`
+	MaxItems             *int64                     `json:"maxItems"`
+}
+
+// unsupportedKeywords are JSON Schema keywords affecting validation that
+// are not supported; a schema using any of these cannot be compiled.
+var unsupportedKeywords = []string{
+	"$ref", "$dynamicRef", "$recursiveRef", "allOf", "anyOf", "oneOf", "not", "if", "then", "else",
+	"pattern", "patternProperties", "dependencies", "dependentRequired", "dependentSchemas",
+	"prefixItems", "additionalItems", "contains", "minContains", "maxContains", "uniqueItems", "multipleOf",
+	"minProperties", "maxProperties", "propertyNames", "unevaluatedProperties", "unevaluatedItems",
+}
+
+// FromJSONSchema compiles a JSON Schema to a Schema for validating
+// msgpack data, without converting the data to JSON.
+//
+// A subset of JSON Schema is supported:
+//
+//   - type (a type name or array of type names)
+//   - properties, required and additionalProperties (boolean only)
+//   - items (a single schema)
+//   - enum and const (scalar values only; an array, object, binary data,
+//     extension or timestamp never matches)
+//   - minimum, maximum, exclusiveMinimum and exclusiveMaximum
+//   - minLength, maxLength, minItems and maxItems
+//
+// Annotations (title, description, format etc) are ignored.  An error is
+// returned if the schema is not valid JSON or uses any other keyword
+// affecting validation (e.g. $ref, anyOf, pattern).
+//
+// JSON types correspond to msgpack formats as follows: null (nil),
+// boolean (bool), integer (int, uint or a float with no fractional part),
+// number (int, uint or float), string (str), array (array) and object
+// (map with string keys).  Binary data, extensions and timestamps match
+// a schema only if it does not specify a type.
+//
+// Arrays and objects nested more deeply than msgpack.MaxDepth cannot be
+// validated; Validate returns an error wrapping msgpack.ErrDepthExceeded.
+func FromJSONSchema(data []byte) (Schema, error) {
+	s, err := compileJSONSchema(data, "")
+	if err != nil {
+		return nil, fmt.Errorf("schema: %w", err)
+	}
+	return s, nil
+}
+
+// compileJSONSchema compiles a JSON Schema at the specified location
+// (a JSON pointer) in the document.
+func compileJSONSchema(data []byte, at string) (*jsonSchema, error) {
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keywords); err != nil {
+		return nil, fmt.Errorf("%s: %w", pointer(at), err)
+	}
+	for _, k := range unsupportedKeywords {
+		if _, ok := keywords[k]; ok {
+			return nil, fmt.Errorf("%s: keyword not supported: %s", pointer(at), k)
+		}
+	}
+
+	var doc jsonSchemaDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", pointer(at), err)
+	}
+
+	s := &jsonSchema{
+		required:  doc.Required,
+		strict:    doc.AdditionalProperties != nil && !*doc.AdditionalProperties,
+		minimum:   doc.Minimum,
+		maximum:   doc.Maximum,
+		exMinimum: doc.ExclusiveMinimum,
+		exMaximum: doc.ExclusiveMaximum,
+		minLength: doc.MinLength,
+		maxLength: doc.MaxLength,
+		minItems:  doc.MinItems,
+		maxItems:  doc.MaxItems,
+	}
+
+	if len(doc.Type) > 0 {
+		var t string
+		if err := json.Unmarshal(doc.Type, &t); err == nil {
+			s.types = []string{t}
+		} else if err := json.Unmarshal(doc.Type, &s.types); err != nil {
+			return nil, fmt.Errorf("%s/type: must be a string or array of strings", pointer(at))
+		}
+		for _, t := range s.types {
+			switch t {
+			case "null", "boolean", "integer", "number", "string", "array", "object":
+			default:
+				return nil, fmt.Errorf("%s/type: unknown type: %s", pointer(at), t)
+			}
+		}
+	}
+
+	if len(doc.Properties) > 0 {
+		s.properties = make(map[string]*jsonSchema, len(doc.Properties))
+		for name, raw := range doc.Properties {
+			ps, err := compileJSONSchema(raw, at+"/properties/"+name)
+			if err != nil {
+				return nil, err
+			}
+			s.properties[name] = ps
+		}
+	}
+
+	if len(doc.Items) > 0 {
+		is, err := compileJSONSchema(doc.Items, at+"/items")
+		if err != nil {
+			return nil, err
+		}
+		s.items = is
+	}
+
+	enum := doc.Enum
+	if len(doc.Const) > 0 {
+		enum = []json.RawMessage{doc.Const}
+	}
+	if enum != nil {
+		s.enum = make([]any, 0, len(enum))
+		for _, raw := range enum {
+			dec := json.NewDecoder(bytes.NewReader(raw))
+			dec.UseNumber()
+			var v any
+			if err := dec.Decode(&v); err != nil {
+				return nil, fmt.Errorf("%s/enum: %w", pointer(at), err)
+			}
+			switch v.(type) {
+			case nil, bool, json.Number, string:
+				s.enum = append(s.enum, v)
+			default:
+				return nil, fmt.Errorf("%s/enum: only scalar values are supported", pointer(at))
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// pointer returns a JSON pointer for display, "#" for the root.
+func pointer(at string) string {
+	return "#" + at
+}
+
+// jsonType returns the JSON type of a msgpack format, or "" if the
+// format has no JSON equivalent.
+func jsonType(f msgpack.Format) string {
+	switch {
+	case f == msgpack.FormatNil:
+		return "null"
+	case f == msgpack.FormatBool:
+		return "boolean"
+	case f >= msgpack.FormatFixInt && f <= msgpack.FormatUint64:
+		return "integer"
+	case f == msgpack.FormatFloat32 || f == msgpack.FormatFloat64:
+		return "number"
+	case isString(f):
+		return "string"
+	case isArray(f):
+		return "array"
+	case isMap(f):
+		return "object"
+	default:
+		return ""
+	}
+}
+
+// validate implements Schema.
+func (s *jsonSchema) validate(r *reader, path string, v *violations) error {
+	f, _, _, err := r.header()
+	if err != nil {
+		return err
+	}
+
+	if isArray(f) || isMap(f) {
+		if !s.matchType(f, nil) {
+			v.add(path, "expected %s, got %s", s.typeNames(), f)
+			return r.skip()
+		}
+		// enumerated values are scalars, which an array or map never equals
+		if s.enum != nil {
+			v.add(path, "value is not one of the enumerated values")
+		}
+		if err := r.nest(); err != nil {
+			return err
+		}
+		defer r.unnest()
+
+		if isArray(f) {
+			return s.validateArray(r, path, v)
+		}
+		return s.validateObject(r, path, v)
+	}
+
+	b := r.data[r.pos]
+	_, _, p, err := r.content()
+	if err != nil {
+		return err
+	}
+	value := scalar(f, b, p)
+
+	if !s.matchType(f, value) {
+		v.add(path, "expected %s, got %s", s.typeNames(), f)
+		return nil
+	}
+	// binary data, extensions and timestamps have no JSON equivalent so
+	// are never one of the enumerated values
+	if s.enum != nil && (jsonType(f) == "" || !s.inEnum(value)) {
+		v.add(path, "value is not one of the enumerated values")
+	}
+
+	switch x := value.(type) {
+	case int64, uint64, float64:
+		s.validateNumber(toFloat(x), path, v)
+	case string:
+		length := int64(utf8.RuneCountInString(x))
+		if s.minLength != nil && length < *s.minLength {
+			v.add(path, "length %d is less than the minimum (%d)", length, *s.minLength)
+		}
+		if s.maxLength != nil && length > *s.maxLength {
+			v.add(path, "length %d is greater than the maximum (%d)", length, *s.maxLength)
+		}
+	}
+	return nil
+}
+
+// matchType returns true if a value of the specified format (and, for
+// a scalar, value) matches the type(s) of the schema.
+func (s *jsonSchema) matchType(f msgpack.Format, value any) bool {
+	if s.types == nil {
+		return true
+	}
+	jt := jsonType(f)
+	for _, t := range s.types {
+		switch {
+		case t == jt:
+			return true
+		case t == "number" && jt == "integer":
+			return true
+		case t == "integer" && jt == "number":
+			if x := value.(float64); x == math.Trunc(x) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// typeNames returns a description of the types of the schema.
+func (s *jsonSchema) typeNames() string {
+	if len(s.types) == 1 {
+		return s.types[0]
+	}
+	return fmt.Sprintf("one of %v", s.types)
+}
+
+// validateNumber validates a number against any range of the schema.
+func (s *jsonSchema) validateNumber(x float64, path string, v *violations) {
+	switch {
+	case s.minimum != nil && x < *s.minimum:
+		v.add(path, "%v is less than the minimum (%v)", x, *s.minimum)
+	case s.exMinimum != nil && x <= *s.exMinimum:
+		v.add(path, "%v is not greater than the exclusive minimum (%v)", x, *s.exMinimum)
+	}
+	switch {
+	case s.maximum != nil && x > *s.maximum:
+		v.add(path, "%v is greater than the maximum (%v)", x, *s.maximum)
+	case s.exMaximum != nil && x >= *s.exMaximum:
+		v.add(path, "%v is not less than the exclusive maximum (%v)", x, *s.exMaximum)
+	}
+}
+
+// validateArray validates an array at the current position.
+func (s *jsonSchema) validateArray(r *reader, path string, v *violations) error {
+	_, n, _, _ := r.content()
+	if s.minItems != nil && n < *s.minItems {
+		v.add(path, "%d items is less than the minimum (%d)", n, *s.minItems)
+	}
+	if s.maxItems != nil && n > *s.maxItems {
+		v.add(path, "%d items is greater than the maximum (%d)", n, *s.maxItems)
+	}
+
+	for i := int64(0); i < n; i++ {
+		if err := r.more(); err != nil {
+			return err
+		}
+		var err error
+		if s.items != nil {
+			err = s.items.validate(r, fmt.Sprintf("%s[%d]", path, i), v)
+		} else {
+			err = r.skip()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateObject validates a map at the current position.
+func (s *jsonSchema) validateObject(r *reader, path string, v *violations) error {
+	_, n, _, _ := r.content()
+	found := map[string]bool{}
+	for i := int64(0); i < n; i++ {
+		if err := r.more(); err != nil {
+			return err
+		}
+		kf, _, _, err := r.header()
+		if err != nil {
+			return err
+		}
+
+		var key string
+		if isString(kf) {
+			_, _, p, err := r.content()
+			if err != nil {
+				return err
+			}
+			key = string(p)
+		} else {
+			v.add(path, "expected string key, got %s", kf)
+			if err := r.skip(); err != nil {
+				return err
+			}
+		}
+
+		if err := r.more(); err != nil {
+			return err
+		}
+
+		ps, ok := s.properties[key]
+		switch {
+		case ok && isString(kf):
+			found[key] = true
+			err = ps.validate(r, join(path, key), v)
+		case s.strict && isString(kf):
+			v.add(join(path, key), "unexpected property")
+			err = r.skip()
+		default:
+			err = r.skip()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range s.required {
+		if !found[name] {
+			v.add(join(path, name), "required property is missing")
+		}
+	}
+	return nil
+}
+
+// inEnum returns true if a scalar value is one of the enumerated values
+// of the schema.
+func (s *jsonSchema) inEnum(value any) bool {
+	for _, e := range s.enum {
+		switch x := e.(type) {
+		case json.Number:
+			if equalNumber(x, value) {
+				return true
+			}
+		default:
+			if x == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// equalNumber returns true if a JSON number is equal to a msgpack value.
+func equalNumber(n json.Number, value any) bool {
+	switch x := value.(type) {
+	case int64:
+		i, err := n.Int64()
+		return err == nil && i == x
+	case uint64:
+		u, err := strconv.ParseUint(n.String(), 10, 64)
+		return err == nil && u == x
+	case float64:
+		f, err := n.Float64()
+		return err == nil && f == x
+	default:
+		return false
+	}
+}
+
+// scalar returns the value of a scalar encoded with the specified format,
+// leading byte and data: nil, bool, int64, uint64, float64 or string.
+// Values of any other format are returned as nil.
+func scalar(f msgpack.Format, b byte, p []byte) any {
+	switch f {
+	case msgpack.FormatBool:
+		return b == msgpack.AtomTrue
+	case msgpack.FormatFixInt, msgpack.FormatNegFixInt:
+		return int64(int8(b))
+	case msgpack.FormatInt8:
+		return int64(int8(p[0]))
+	case msgpack.FormatInt16:
+		return int64(int16(binary.BigEndian.Uint16(p)))
+	case msgpack.FormatInt32:
+		return int64(int32(binary.BigEndian.Uint32(p)))
+	case msgpack.FormatInt64:
+		return int64(binary.BigEndian.Uint64(p))
+	case msgpack.FormatUint8:
+		return int64(p[0])
+	case msgpack.FormatUint16:
+		return int64(binary.BigEndian.Uint16(p))
+	case msgpack.FormatUint32:
+		return int64(binary.BigEndian.Uint32(p))
+	case msgpack.FormatUint64:
+		if u := binary.BigEndian.Uint64(p); u > math.MaxInt64 {
+			return u
+		} else {
+			return int64(u)
+		}
+	case msgpack.FormatFloat32:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(p)))
+	case msgpack.FormatFloat64:
+		return math.Float64frombits(binary.BigEndian.Uint64(p))
+	case msgpack.FormatFixStr, msgpack.FormatStr8, msgpack.FormatStr16, msgpack.FormatStr32:
+		return string(p)
+	default:
+		return nil
+	}
+}
+
+// toFloat returns a numeric value as a float64.
+func toFloat(x any) float64 {
+	switch x := x.(type) {
+	case int64:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	default:
+		return x.(float64)
+	}
+}
@@ -0,0 +1,236 @@
+package schema
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blugnu/msgpack"
+)
+
+func TestFromJSONSchema(t *testing.T) {
+	// ARRANGE
+	person := `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "person",
+		"type": "object",
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"name": {"type": "string", "minLength": 1, "maxLength": 8},
+			"role": {"enum": ["admin", "user", null]},
+			"score": {"type": "number", "exclusiveMinimum": 0, "exclusiveMaximum": 100},
+			"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2},
+			"address": {
+				"type": ["object", "null"],
+				"properties": {"city": {"type": "string"}},
+				"required": ["city"],
+				"additionalProperties": false
+			}
+		},
+		"required": ["id", "name"]
+	}`
+
+	testcases := []struct {
+		spec   string
+		schema string
+		data   []byte
+		result []Violation
+		error
+	}{
+		{spec: "no type", schema: `{}`, data: msgpack.Bytes([]byte{1})},
+		{spec: "null", schema: `{"type": "null"}`, data: []byte{msgpack.AtomNil}},
+		{spec: "boolean", schema: `{"type": "boolean"}`, data: msgpack.Bool(true)},
+		{spec: "integer", schema: `{"type": "integer"}`, data: msgpack.Int(-1000)},
+		{spec: "integer (uint64)", schema: `{"type": "integer"}`, data: msgpack.Uint(1 << 63)},
+		{spec: "integer (integral float)", schema: `{"type": "integer"}`, data: msgpack.Float64(2)},
+		{spec: "integer (fractional float)",
+			schema: `{"type": "integer"}`,
+			data:   msgpack.Float64(2.5),
+			result: []Violation{{Message: "expected integer, got float64"}},
+		},
+		{spec: "number (int)", schema: `{"type": "number"}`, data: msgpack.Int(1)},
+		{spec: "number (float)", schema: `{"type": "number"}`, data: msgpack.Float32(1.5)},
+		{spec: "multiple types",
+			schema: `{"type": ["string", "null"]}`,
+			data:   msgpack.Int(1),
+			result: []Violation{{Message: "expected one of [string null], got fixint"}},
+		},
+		{spec: "binary is not a string",
+			schema: `{"type": "string"}`,
+			data:   msgpack.Bytes([]byte{1}),
+			result: []Violation{{Message: "expected string, got bin8"}},
+		},
+		{spec: "enum (number)", schema: `{"enum": [1, 2.5, 18446744073709551615]}`, data: msgpack.Uint(1<<64 - 1)},
+		{spec: "enum (float)", schema: `{"enum": [1, 2.5]}`, data: msgpack.Float64(2.5)},
+		{spec: "enum (bool)",
+			schema: `{"enum": [true]}`,
+			data:   msgpack.Bool(false),
+			result: []Violation{{Message: "value is not one of the enumerated values"}},
+		},
+		{spec: "const", schema: `{"const": "a"}`, data: msgpack.String("a")},
+		{spec: "const (array)",
+			schema: `{"const": 1}`,
+			data:   encode(func(enc msgpack.Encoder) { _ = msgpack.EncodeArray(enc, []int{1, 2}, nil) }),
+			result: []Violation{{Message: "value is not one of the enumerated values"}},
+		},
+		{spec: "enum (object)",
+			schema: `{"enum": [null]}`,
+			data:   encode(func(enc msgpack.Encoder) { _ = msgpack.EncodeMap(enc, map[string]int{}, nil) }),
+			result: []Violation{{Message: "value is not one of the enumerated values"}},
+		},
+		{spec: "enum (null)", schema: `{"enum": [null]}`, data: []byte{msgpack.AtomNil}},
+		{spec: "enum (binary)",
+			schema: `{"enum": [null]}`,
+			data:   msgpack.Bytes([]byte{1}),
+			result: []Violation{{Message: "value is not one of the enumerated values"}},
+		},
+		{spec: "enum (timestamp)",
+			schema: `{"enum": [null]}`,
+			data:   encode(func(enc msgpack.Encoder) { _ = enc.EncodeTime(time.Unix(1, 0)) }),
+			result: []Violation{{Message: "value is not one of the enumerated values"}},
+		},
+		{spec: "range",
+			schema: `{"minimum": 1, "maximum": 2}`,
+			data:   msgpack.Int(3),
+			result: []Violation{{Message: "3 is greater than the maximum (2)"}},
+		},
+		{spec: "array length",
+			schema: `{"minItems": 2}`,
+			data:   []byte{0x91, 0x01},
+			result: []Violation{{Message: "1 items is less than the minimum (2)"}},
+		},
+		{spec: "string length (runes)", schema: `{"maxLength": 2}`, data: msgpack.String("éé")},
+		{spec: "valid object",
+			schema: person,
+			data: encode(func(enc msgpack.Encoder) {
+				_ = enc.WriteMapHeader(6)
+				_ = enc.EncodeIntField("id", 1)
+				_ = enc.EncodeStringField("name", "blugnu")
+				_ = enc.EncodeString("role")
+				_ = enc.EncodeNil()
+				_ = enc.EncodeString("score")
+				_ = enc.EncodeFloat64(99.5)
+				_ = enc.EncodeString("address")
+				_ = enc.EncodeNil()
+				_ = enc.EncodeStringField("other", "ignored")
+			}),
+		},
+		{spec: "invalid object",
+			schema: person,
+			data: encode(func(enc msgpack.Encoder) {
+				_ = enc.WriteMapHeader(6)
+				_ = enc.EncodeIntField("id", 0)
+				_ = enc.EncodeStringField("name", "")
+				_ = enc.EncodeStringField("role", "guest")
+				_ = enc.EncodeIntField("score", 100)
+				_ = enc.EncodeString("tags")
+				_ = msgpack.EncodeArray(enc, []any{"a", 1, "c"}, nil)
+				_ = enc.EncodeString("address")
+				_ = enc.WriteMapHeader(1)
+				_ = enc.EncodeStringField("town", "London")
+			}),
+			result: []Violation{
+				{Path: "id", Message: "0 is less than the minimum (1)"},
+				{Path: "name", Message: "length 0 is less than the minimum (1)"},
+				{Path: "role", Message: "value is not one of the enumerated values"},
+				{Path: "score", Message: "100 is not less than the exclusive maximum (100)"},
+				{Path: "tags", Message: "3 items is greater than the maximum (2)"},
+				{Path: "tags[1]", Message: "expected string, got fixint"},
+				{Path: "address.town", Message: "unexpected property"},
+				{Path: "address.city", Message: "required property is missing"},
+			},
+		},
+		{spec: "truncated", schema: person, data: []byte{0x81, 0xa2, 'i', 'd'}, error: msgpack.ErrTruncated},
+		{spec: "nested too deeply",
+			schema: strings.Repeat(`{"items": `, msgpack.MaxDepth+1) + `{}` + strings.Repeat(`}`, msgpack.MaxDepth+1),
+			data:   nestedArrays(msgpack.MaxDepth + 1),
+			error:  msgpack.ErrDepthExceeded,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			s, err := FromJSONSchema([]byte(tc.schema))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// ACT
+			err = Validate(s, tc.data)
+
+			// ASSERT
+			var verr *ValidationError
+			switch {
+			case tc.error != nil:
+				if !errors.Is(err, tc.error) {
+					t.Errorf("\nwanted error %v\ngot          %v", tc.error, err)
+				}
+			case tc.result == nil:
+				if err != nil {
+					t.Errorf("\nwanted nil\ngot    %v", err)
+				}
+			case !errors.As(err, &verr):
+				t.Errorf("\nwanted *ValidationError\ngot    %#v", err)
+			default:
+				wanted := tc.result
+				got := verr.Violations
+				if !reflect.DeepEqual(wanted, got) {
+					t.Errorf("\nwanted %v\ngot    %v", wanted, got)
+				}
+			}
+		})
+	}
+}
+
+func TestFromJSONSchemaErrors(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		spec   string
+		schema string
+		result string
+	}{
+		{spec: "invalid json", schema: `{`, result: "schema: #: unexpected end of JSON input"},
+		{spec: "unsupported keyword",
+			schema: `{"properties": {"a": {"anyOf": []}}}`,
+			result: "schema: #/properties/a: keyword not supported: anyOf",
+		},
+		{spec: "unknown type", schema: `{"type": "date"}`, result: "schema: #/type: unknown type: date"},
+		{spec: "invalid type", schema: `{"type": 1}`, result: "schema: #/type: must be a string or array of strings"},
+		{spec: "non-scalar enum",
+			schema: `{"items": {"enum": [[1]]}}`,
+			result: "schema: #/items/enum: only scalar values are supported",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			// ACT
+			_, err := FromJSONSchema([]byte(tc.schema))
+
+			// ASSERT
+			if err == nil || err.Error() != tc.result {
+				t.Errorf("\nwanted %q\ngot    %v", tc.result, err)
+			}
+		})
+	}
+}
+
+func TestFromJSONSchemaUnsupportedKeywords(t *testing.T) {
+	// ARRANGE
+	keywords := []string{
+		"dependencies", "additionalItems", "unevaluatedProperties", "unevaluatedItems",
+		"minContains", "maxContains", "$dynamicRef", "$recursiveRef",
+	}
+	for _, k := range keywords {
+		t.Run(k, func(t *testing.T) {
+			// ACT
+			_, err := FromJSONSchema([]byte(`{"` + k + `": false}`))
+
+			// ASSERT
+			wanted := "schema: #: keyword not supported: " + k
+			if err == nil || err.Error() != wanted {
+				t.Errorf("\nwanted %q\ngot    %v", wanted, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/blugnu/msgpack"
+)
+
+// reader reads msgpack values from a []byte.
+type reader struct {
+	data  []byte
+	pos   int
+	depth int // the number of arrays and maps being validated that enclose the current position
+}
+
+// header returns the format and length of the value at the current
+// position, and the number of bytes of the header, without consuming it.
+func (r *reader) header() (f msgpack.Format, n int64, hl int, err error) {
+	f, n, hl, err = msgpack.ReadHeader(r.data[r.pos:])
+	if err != nil {
+		err = fmt.Errorf("offset %d: %w", r.pos, err)
+	}
+	return
+}
+
+// content consumes the header of the value at the current position,
+// returning the n bytes of data that follow it (for a string, binary
+// data, a number or an extension).  For an array or map only the header
+// is consumed and the data returned is empty.
+func (r *reader) content() (f msgpack.Format, n int64, p []byte, err error) {
+	f, n, hl, err := r.header()
+	if err != nil {
+		return f, n, nil, err
+	}
+
+	start := r.pos
+	r.pos += hl
+	if isContainer(f) {
+		return f, n, nil, nil
+	}
+
+	if n > int64(len(r.data)-r.pos) {
+		return f, n, nil, fmt.Errorf("offset %d: %w: %s requires %d bytes, got %d", start, msgpack.ErrTruncated, f, int64(hl)+n, len(r.data)-start)
+	}
+	p = r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return f, n, p, nil
+}
+
+// skip consumes the value at the current position, including the
+// elements (or entries) of an array (or map).
+func (r *reader) skip() error {
+	for pending := int64(1); pending > 0; pending-- {
+		if err := r.more(); err != nil {
+			return err
+		}
+		f, n, _, err := r.content()
+		if err != nil {
+			return err
+		}
+		switch {
+		case isArray(f):
+			pending += n
+		case isMap(f):
+			pending += 2 * n
+		}
+	}
+	return nil
+}
+
+// nest records the start of an array or map at the current position
+// that is to be validated, returning an error if the maximum depth of
+// nesting is exceeded.  The caller must call unnest when the array or
+// map has been validated.
+func (r *reader) nest() error {
+	if r.depth == msgpack.MaxDepth {
+		return fmt.Errorf("offset %d: %w: maximum depth is %d", r.pos, msgpack.ErrDepthExceeded, msgpack.MaxDepth)
+	}
+	r.depth++
+	return nil
+}
+
+// unnest records the end of an array or map that has been validated.
+func (r *reader) unnest() {
+	r.depth--
+}
+
+// more returns an error if there are no more values to be read.
+func (r *reader) more() error {
+	if r.pos >= len(r.data) {
+		return fmt.Errorf("offset %d: %w: expected a value", r.pos, msgpack.ErrTruncated)
+	}
+	return nil
+}
+
+// isArray returns true if the format is an array format.
+func isArray(f msgpack.Format) bool {
+	return f >= msgpack.FormatFixArray && f <= msgpack.FormatArray32
+}
+
+// isMap returns true if the format is a map format.
+func isMap(f msgpack.Format) bool {
+	return f >= msgpack.FormatFixMap && f <= msgpack.FormatMap32
+}
+
+// isContainer returns true if the format is an array or map format.
+func isContainer(f msgpack.Format) bool {
+	return isArray(f) || isMap(f)
+}
+
+// isString returns true if the format is a string format.
+func isString(f msgpack.Format) bool {
+	return f >= msgpack.FormatFixStr && f <= msgpack.FormatStr32
+}
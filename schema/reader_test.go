@@ -0,0 +1,41 @@
+package schema
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/blugnu/msgpack"
+)
+
+func TestReaderSkip(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		spec string
+		data []byte
+		pos  int
+		error
+	}{
+		{spec: "fixint", data: []byte{0x01, 0x02}, pos: 1},
+		{spec: "str8", data: []byte{0xd9, 0x02, 'a', 'b', 0x00}, pos: 4},
+		{spec: "nested containers", data: []byte{0x92, 0x81, 0xa1, 'a', 0x91, 0x01, 0xc0, 0x00}, pos: 7},
+		{spec: "truncated string", data: []byte{0xa3, 'a'}, error: msgpack.ErrTruncated},
+		{spec: "truncated array", data: []byte{0x92, 0x01}, error: msgpack.ErrTruncated},
+		{spec: "deeply nested", data: nestedArrays(1_000_000), pos: 1_000_001},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			r := &reader{data: tc.data}
+
+			// ACT
+			err := r.skip()
+
+			// ASSERT
+			if !errors.Is(err, tc.error) {
+				t.Errorf("\nwanted error %v\ngot          %v", tc.error, err)
+			}
+			if tc.error == nil && r.pos != tc.pos {
+				t.Errorf("\nwanted pos %d\ngot    %d", tc.pos, r.pos)
+			}
+		})
+	}
+}
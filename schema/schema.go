@@ -0,0 +1,354 @@
+// Package schema validates msgpack encoded data against a declared
+// schema, describing the expected shape of a value (maps with typed
+// fields, arrays of a type, optional and nullable values), producing
+// path-specific violations.
+//
+//	s := schema.Map(
+//		schema.Required("id", schema.Int()),
+//		schema.Required("name", schema.String()),
+//		schema.Optional("tags", schema.ArrayOf(schema.String())),
+//	)
+//	if err := schema.Validate(s, data); err != nil {
+//		...
+//	}
+//
+// Data is validated in its encoded form, without decoding it.
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blugnu/msgpack"
+)
+
+// Schema describes the expected shape of a msgpack value.
+type Schema interface {
+	// validate consumes a value from the reader, recording any
+	// violations of the schema.  An error is returned only if the
+	// data is malformed.
+	validate(r *reader, path string, v *violations) error
+}
+
+// Violation describes a value that does not conform to a schema,
+// identifying the path to the value, e.g. "orders[3].customer.name".
+// The path of the root value is empty.
+type Violation struct {
+	Path    string
+	Message string
+}
+
+// String returns a description of the violation.
+func (v Violation) String() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return v.Path + ": " + v.Message
+}
+
+// ValidationError is returned by Validate when data does not conform to
+// a schema, listing each violation.
+type ValidationError struct {
+	Violations []Violation
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	s := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		s[i] = v.String()
+	}
+	return "schema violations: " + strings.Join(s, "; ")
+}
+
+// violations collects the violations found when validating data.
+type violations []Violation
+
+// add records a violation at the specified path.
+func (v *violations) add(path string, format string, args ...any) {
+	*v = append(*v, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate validates msgpack data (a single value) against a schema.
+//
+// If the data does not conform to the schema a *ValidationError is
+// returned.  If the data is malformed, an error wrapping
+// msgpack.ErrTruncated or msgpack.ErrInvalidFormat is returned, or
+// msgpack.ErrDepthExceeded if arrays and maps being validated are nested
+// more deeply than msgpack.MaxDepth.
+func Validate(s Schema, data []byte) error {
+	r := &reader{data: data}
+	if err := r.more(); err != nil {
+		return err
+	}
+
+	var v violations
+	if err := s.validate(r, "", &v); err != nil {
+		return err
+	}
+	if r.pos < len(data) {
+		v.add("", "unexpected data after value (%d bytes)", len(data)-r.pos)
+	}
+
+	if len(v) > 0 {
+		return &ValidationError{Violations: v}
+	}
+	return nil
+}
+
+// typeSchema is a Schema matching values by their format.
+type typeSchema struct {
+	name  string
+	match func(msgpack.Format) bool
+}
+
+// validate implements Schema.
+func (s typeSchema) validate(r *reader, path string, v *violations) error {
+	f, _, _, err := r.header()
+	if err != nil {
+		return err
+	}
+	if !s.match(f) {
+		v.add(path, "expected %s, got %s", s.name, f)
+	}
+	return r.skip()
+}
+
+// formats returns a function matching the formats in the range
+// first..last (inclusive).
+func formats(first, last msgpack.Format) func(msgpack.Format) bool {
+	return func(f msgpack.Format) bool { return f >= first && f <= last }
+}
+
+// Any returns a Schema matching any value.
+func Any() Schema {
+	return typeSchema{name: "any value", match: func(msgpack.Format) bool { return true }}
+}
+
+// Nil returns a Schema matching nil.
+func Nil() Schema {
+	return typeSchema{name: "nil", match: formats(msgpack.FormatNil, msgpack.FormatNil)}
+}
+
+// Bool returns a Schema matching a bool.
+func Bool() Schema {
+	return typeSchema{name: "bool", match: formats(msgpack.FormatBool, msgpack.FormatBool)}
+}
+
+// Int returns a Schema matching an integer (of any size, signed or
+// unsigned).
+func Int() Schema {
+	return typeSchema{name: "int", match: formats(msgpack.FormatFixInt, msgpack.FormatUint64)}
+}
+
+// Float returns a Schema matching a float32 or float64.
+func Float() Schema {
+	return typeSchema{name: "float", match: formats(msgpack.FormatFloat32, msgpack.FormatFloat64)}
+}
+
+// Number returns a Schema matching an integer or a float.
+func Number() Schema {
+	return typeSchema{name: "number", match: formats(msgpack.FormatFixInt, msgpack.FormatFloat64)}
+}
+
+// String returns a Schema matching a string.
+func String() Schema {
+	return typeSchema{name: "string", match: formats(msgpack.FormatFixStr, msgpack.FormatStr32)}
+}
+
+// Bytes returns a Schema matching binary data.
+func Bytes() Schema {
+	return typeSchema{name: "binary", match: formats(msgpack.FormatBin8, msgpack.FormatBin32)}
+}
+
+// Time returns a Schema matching a timestamp.
+func Time() Schema {
+	return typeSchema{name: "timestamp", match: formats(msgpack.FormatTimestamp, msgpack.FormatTimestamp)}
+}
+
+// Array returns a Schema matching an array of any values.
+func Array() Schema {
+	return typeSchema{name: "array", match: isArray}
+}
+
+// nullable is a Schema matching nil or another Schema.
+type nullable struct {
+	Schema
+}
+
+// Nullable returns a Schema matching nil or a value matching the
+// specified Schema.
+func Nullable(s Schema) Schema {
+	return nullable{s}
+}
+
+// validate implements Schema.
+func (s nullable) validate(r *reader, path string, v *violations) error {
+	f, _, _, err := r.header()
+	if err != nil {
+		return err
+	}
+	if f == msgpack.FormatNil {
+		return r.skip()
+	}
+	return s.Schema.validate(r, path, v)
+}
+
+// arrayOf is a Schema matching an array with elements of a Schema.
+type arrayOf struct {
+	elem Schema
+}
+
+// ArrayOf returns a Schema matching an array with elements matching the
+// specified Schema.
+func ArrayOf(elem Schema) Schema {
+	return arrayOf{elem: elem}
+}
+
+// validate implements Schema.
+func (s arrayOf) validate(r *reader, path string, v *violations) error {
+	f, _, _, err := r.header()
+	if err != nil {
+		return err
+	}
+	if !isArray(f) {
+		v.add(path, "expected array, got %s", f)
+		return r.skip()
+	}
+	if err := r.nest(); err != nil {
+		return err
+	}
+	defer r.unnest()
+
+	_, n, _, _ := r.content()
+	for i := int64(0); i < n; i++ {
+		if err := r.more(); err != nil {
+			return err
+		}
+		if err := s.elem.validate(r, fmt.Sprintf("%s[%d]", path, i), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Field describes a field of a map with string keys.
+type Field struct {
+	Name     string
+	Schema   Schema
+	Required bool
+}
+
+// Required returns a Field that must be present in a map.
+func Required(name string, s Schema) Field {
+	return Field{Name: name, Schema: s, Required: true}
+}
+
+// Optional returns a Field that may be omitted from a map.
+func Optional(name string, s Schema) Field {
+	return Field{Name: name, Schema: s}
+}
+
+// mapSchema is a Schema matching a map with string keys.
+type mapSchema struct {
+	fields []Field
+	strict bool
+}
+
+// Map returns a Schema matching a map with string keys and the specified
+// fields.  Entries with keys that are not specified fields are allowed
+// (and not validated).
+func Map(fields ...Field) Schema {
+	return mapSchema{fields: fields}
+}
+
+// StrictMap returns a Schema matching a map with string keys and the
+// specified fields, for which an entry with a key that is not a
+// specified field is a violation.
+func StrictMap(fields ...Field) Schema {
+	return mapSchema{fields: fields, strict: true}
+}
+
+// validate implements Schema.
+func (s mapSchema) validate(r *reader, path string, v *violations) error {
+	f, _, _, err := r.header()
+	if err != nil {
+		return err
+	}
+	if !isMap(f) {
+		v.add(path, "expected map, got %s", f)
+		return r.skip()
+	}
+	if err := r.nest(); err != nil {
+		return err
+	}
+	defer r.unnest()
+
+	_, n, _, _ := r.content()
+	found := make([]bool, len(s.fields))
+	for i := int64(0); i < n; i++ {
+		if err := r.more(); err != nil {
+			return err
+		}
+		kf, _, _, err := r.header()
+		if err != nil {
+			return err
+		}
+
+		// a key that is not a string cannot identify a field; the
+		// key and the value are skipped
+		var key string
+		if isString(kf) {
+			_, _, p, err := r.content()
+			if err != nil {
+				return err
+			}
+			key = string(p)
+		} else {
+			v.add(path, "expected string key, got %s", kf)
+			if err := r.skip(); err != nil {
+				return err
+			}
+		}
+
+		if err := r.more(); err != nil {
+			return err
+		}
+
+		field := -1
+		for j := range s.fields {
+			if isString(kf) && s.fields[j].Name == key {
+				field = j
+				break
+			}
+		}
+		switch {
+		case field >= 0:
+			found[field] = true
+			err = s.fields[field].Schema.validate(r, join(path, key), v)
+		case s.strict && isString(kf):
+			v.add(join(path, key), "unexpected field")
+			err = r.skip()
+		default:
+			err = r.skip()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, fld := range s.fields {
+		if fld.Required && !found[i] {
+			v.add(join(path, fld.Name), "required field is missing")
+		}
+	}
+	return nil
+}
+
+// join returns the path of a field of the value at the specified path.
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
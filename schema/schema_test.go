@@ -0,0 +1,190 @@
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/blugnu/msgpack"
+)
+
+// encode returns the msgpack encoding of the values written by fn.
+func encode(fn func(enc msgpack.Encoder)) []byte {
+	buf := &bytes.Buffer{}
+	fn(msgpack.NewEncoder(buf))
+	return buf.Bytes()
+}
+
+// nestedArrays returns the msgpack encoding of n nested arrays, the
+// innermost holding a single 0.
+func nestedArrays(n int) []byte {
+	return append(bytes.Repeat([]byte{0x91}, n), 0x00)
+}
+
+// nestedArrayOf returns a Schema matching n nested arrays of ints.
+func nestedArrayOf(n int) Schema {
+	s := Int()
+	for i := 0; i < n; i++ {
+		s = ArrayOf(s)
+	}
+	return s
+}
+
+func TestValidate(t *testing.T) {
+	// ARRANGE
+	person := Map(
+		Required("id", Int()),
+		Required("name", String()),
+		Optional("email", Nullable(String())),
+		Optional("tags", ArrayOf(String())),
+		Optional("address", StrictMap(
+			Required("city", String()),
+		)),
+	)
+
+	testcases := []struct {
+		spec   string
+		schema Schema
+		data   []byte
+		result []Violation
+		error
+	}{
+		{spec: "nil", schema: Nil(), data: []byte{msgpack.AtomNil}},
+		{spec: "bool", schema: Bool(), data: msgpack.Bool(true)},
+		{spec: "int", schema: Int(), data: msgpack.Int(-1000)},
+		{spec: "float", schema: Float(), data: msgpack.Float64(1.5)},
+		{spec: "number (int)", schema: Number(), data: msgpack.Uint(1)},
+		{spec: "number (float)", schema: Number(), data: msgpack.Float32(1.5)},
+		{spec: "string", schema: String(), data: msgpack.String("abc")},
+		{spec: "bytes", schema: Bytes(), data: msgpack.Bytes([]byte{1})},
+		{spec: "time",
+			schema: Time(),
+			data:   encode(func(enc msgpack.Encoder) { _ = enc.EncodeTime(time.Unix(1, 0)) }),
+		},
+		{spec: "any", schema: Any(), data: msgpack.Int(1)},
+		{spec: "array", schema: Array(), data: encode(func(enc msgpack.Encoder) { _ = msgpack.EncodeArray(enc, []int{1, 2}, nil) })},
+		{spec: "nullable (nil)", schema: Nullable(Int()), data: []byte{msgpack.AtomNil}},
+		{spec: "nullable (value)", schema: Nullable(Int()), data: msgpack.Int(1)},
+		{spec: "type mismatch",
+			schema: Int(),
+			data:   msgpack.String("abc"),
+			result: []Violation{{Message: "expected int, got fixstr"}},
+		},
+		{spec: "array of strings",
+			schema: ArrayOf(String()),
+			data:   encode(func(enc msgpack.Encoder) { _ = msgpack.EncodeArray(enc, []any{"a", 1, "b", true}, nil) }),
+			result: []Violation{
+				{Path: "[1]", Message: "expected string, got fixint"},
+				{Path: "[3]", Message: "expected string, got bool"},
+			},
+		},
+		{spec: "valid map",
+			schema: person,
+			data: encode(func(enc msgpack.Encoder) {
+				_ = enc.WriteMapHeader(5)
+				_ = enc.EncodeIntField("id", 1)
+				_ = enc.EncodeStringField("name", "blugnu")
+				_ = enc.EncodeString("email")
+				_ = enc.EncodeNil()
+				_ = enc.EncodeString("tags")
+				_ = msgpack.EncodeArray(enc, []string{"a", "b"}, nil)
+				_ = enc.EncodeStringField("other", "ignored")
+			}),
+		},
+		{spec: "invalid map",
+			schema: person,
+			data: encode(func(enc msgpack.Encoder) {
+				_ = enc.WriteMapHeader(5)
+				_ = enc.EncodeStringField("id", "1")
+				_ = enc.EncodeString("tags")
+				_ = msgpack.EncodeArray(enc, []any{"a", 2}, nil)
+				_ = enc.EncodeString("address")
+				_ = enc.WriteMapHeader(1)
+				_ = enc.EncodeStringField("town", "London")
+				_ = enc.EncodeInt(42)
+				_ = enc.EncodeString("non-string key")
+				_ = msgpack.EncodeArray(enc, []int{1}, nil)
+				_ = enc.EncodeString("array key")
+			}),
+			result: []Violation{
+				{Path: "id", Message: "expected int, got fixstr"},
+				{Path: "tags[1]", Message: "expected string, got fixint"},
+				{Path: "address.town", Message: "unexpected field"},
+				{Path: "address.city", Message: "required field is missing"},
+				{Message: "expected string key, got fixint"},
+				{Message: "expected string key, got fixarray"},
+				{Path: "name", Message: "required field is missing"},
+			},
+		},
+		{spec: "not a map",
+			schema: person,
+			data:   encode(func(enc msgpack.Encoder) { _ = msgpack.EncodeArray(enc, []int{1}, nil) }),
+			result: []Violation{{Message: "expected map, got fixarray"}},
+		},
+		{spec: "trailing data",
+			schema: Int(),
+			data:   []byte{0x01, 0x02},
+			result: []Violation{{Message: "unexpected data after value (1 bytes)"}},
+		},
+		{spec: "no data", schema: Int(), data: []byte{}, error: msgpack.ErrTruncated},
+		{spec: "truncated string", schema: String(), data: []byte{0xa2, 'a'}, error: msgpack.ErrTruncated},
+		{spec: "truncated array", schema: ArrayOf(Int()), data: []byte{0x92, 0x01}, error: msgpack.ErrTruncated},
+		{spec: "truncated map", schema: Map(), data: []byte{0x81, 0xa1, 'a'}, error: msgpack.ErrTruncated},
+		{spec: "invalid", schema: Any(), data: []byte{0xc1}, error: msgpack.ErrInvalidFormat},
+		{spec: "nested to maximum depth",
+			schema: nestedArrayOf(msgpack.MaxDepth),
+			data:   nestedArrays(msgpack.MaxDepth),
+		},
+		{spec: "nested too deeply",
+			schema: nestedArrayOf(msgpack.MaxDepth + 1),
+			data:   nestedArrays(msgpack.MaxDepth + 1),
+			error:  msgpack.ErrDepthExceeded,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			// ACT
+			err := Validate(tc.schema, tc.data)
+
+			// ASSERT
+			var verr *ValidationError
+			switch {
+			case tc.error != nil:
+				if !errors.Is(err, tc.error) {
+					t.Errorf("\nwanted error %v\ngot          %v", tc.error, err)
+				}
+			case tc.result == nil:
+				if err != nil {
+					t.Errorf("\nwanted nil\ngot    %v", err)
+				}
+			case !errors.As(err, &verr):
+				t.Errorf("\nwanted *ValidationError\ngot    %#v", err)
+			default:
+				wanted := tc.result
+				got := verr.Violations
+				if !reflect.DeepEqual(wanted, got) {
+					t.Errorf("\nwanted %v\ngot    %v", wanted, got)
+				}
+			}
+		})
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	// ARRANGE
+	err := &ValidationError{Violations: []Violation{
+		{Message: "expected map, got nil"},
+		{Path: "a.b[1]", Message: "expected int, got bool"},
+	}}
+
+	// ACT
+	got := err.Error()
+
+	// ASSERT
+	wanted := "schema violations: expected map, got nil; a.b[1]: expected int, got bool"
+	if wanted != got {
+		t.Errorf("\nwanted %q\ngot    %q", wanted, got)
+	}
+}
@@ -0,0 +1,101 @@
+package msgpack
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// SortMapKeys returns a copy of an encoded value with the entries of every
+// map (including maps nested in other maps and arrays) in canonical key
+// order, so that values produced by encoders that do not order map keys
+// may be fed to systems requiring a deterministic encoding.  Values other
+// than maps and arrays are copied without being decoded.
+//
+// In canonical order string keys are sorted by value, in ascending
+// (byte-wise) order, followed by any keys of other types sorted by their
+// encoded bytes.  This is consistent with the order of entries encoded by
+// Map.  The header of each map and array is rewritten using the smallest
+// format for its length.
+//
+// An error wrapping ErrTruncated or ErrInvalidFormat is returned if data
+// is not a complete, valid encoded value, or wrapping ErrDepthExceeded if
+// arrays and maps are nested more deeply than MaxDepth.
+func SortMapKeys(data []byte) ([]byte, error) {
+	n, err := valueLen(data)
+	if err != nil {
+		return nil, fmt.Errorf("SortMapKeys: %w", err)
+	}
+	result, _, err := appendSorted(make([]byte, 0, n), data, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("SortMapKeys: %w", err)
+	}
+	return result, nil
+}
+
+// appendSorted appends a copy of the (valid) encoded value at the start of
+// data to dst with the keys of all maps sorted, returning the extended
+// slice and the number of bytes of data consumed.  at is the offset of
+// the value in the data passed to SortMapKeys and depth the number of
+// arrays and maps enclosing it.
+func appendSorted(dst []byte, data []byte, at, depth int) ([]byte, int, error) {
+	f, n, hl, _ := ReadHeader(data)
+	isArray := f >= FormatFixArray && f <= FormatArray32
+	isMap := f >= FormatFixMap && f <= FormatMap32
+	if (isArray || isMap) && depth == MaxDepth {
+		return nil, 0, fmt.Errorf("offset %d: %w: maximum depth is %d", at, ErrDepthExceeded, MaxDepth)
+	}
+
+	switch {
+	case isArray:
+		dst = appendArrayHeader(dst, n)
+		pos := hl
+		for i := int64(0); i < n; i++ {
+			var l int
+			var err error
+			if dst, l, err = appendSorted(dst, data[pos:], at+pos, depth+1); err != nil {
+				return nil, 0, err
+			}
+			pos += l
+		}
+		return dst, pos, nil
+
+	case isMap:
+		entries := make([]entry, n)
+		pos := hl
+		for i := range entries {
+			var kn, vn int
+			var err error
+			if entries[i].key, kn, err = appendSorted(nil, data[pos:], at+pos, depth+1); err != nil {
+				return nil, 0, err
+			}
+			if entries[i].value, vn, err = appendSorted(nil, data[pos+kn:], at+pos+kn, depth+1); err != nil {
+				return nil, 0, err
+			}
+			pos += kn + vn
+		}
+		sort.SliceStable(entries, func(i, j int) bool { return keyLess(entries[i].key, entries[j].key) })
+
+		dst, _ = appendMap(dst, entries)
+		return dst, pos, nil
+
+	default:
+		l, _ := valueLen(data)
+		return append(dst, data[:l]...), l, nil
+	}
+}
+
+// keyLess returns true if encoded key a is ordered before encoded key b
+// in canonical order.
+func keyLess(a, b []byte) bool {
+	as, aok := stringValue(a)
+	bs, bok := stringValue(b)
+	switch {
+	case aok && bok:
+		return bytes.Compare(as, bs) < 0
+	case aok != bok:
+		return aok
+	default:
+		return bytes.Compare(a, b) < 0
+	}
+}
@@ -0,0 +1,61 @@
+package msgpack
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSortMapKeys(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		spec   string
+		data   []byte
+		result []byte
+		error
+	}{
+		{spec: "scalar", data: []byte{0xa1, 'a'}, result: []byte{0xa1, 'a'}},
+		{spec: "sorted", data: []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02}, result: []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02}},
+		{spec: "unsorted",
+			data:   []byte{0x83, 0xa2, 'b', 'b', 0x01, 0x07, 0x02, 0xd9, 0x01, 'a', 0x03},
+			result: []byte{0x83, 0xd9, 0x01, 'a', 0x03, 0xa2, 'b', 'b', 0x01, 0x07, 0x02},
+		},
+		{spec: "non-string keys",
+			data:   []byte{0x83, 0x02, 0xc0, 0xa1, 'z', 0xc0, 0x01, 0xc0},
+			result: []byte{0x83, 0xa1, 'z', 0xc0, 0x01, 0xc0, 0x02, 0xc0},
+		},
+		{spec: "nested",
+			data: []byte{
+				0x92,
+				0x82, 0xa1, 'b', 0x81, 0xa1, 'y', 0xc0, 0xa1, 'a', 0x81, 0xa1, 'x', 0xc0,
+				0xde, 0x00, 0x02, 0xa1, 'd', 0x01, 0xa1, 'c', 0x02,
+			},
+			result: []byte{
+				0x92,
+				0x82, 0xa1, 'a', 0x81, 0xa1, 'x', 0xc0, 0xa1, 'b', 0x81, 0xa1, 'y', 0xc0,
+				0x82, 0xa1, 'c', 0x02, 0xa1, 'd', 0x01,
+			},
+		},
+		{spec: "truncated", data: []byte{0x82, 0xa1, 'a', 0x01}, error: ErrTruncated},
+		{spec: "invalid", data: []byte{0x81, 0xc1, 0x01}, error: ErrInvalidFormat},
+		{spec: "nested to maximum depth",
+			data:   append(bytes.Repeat([]byte{0x91}, MaxDepth), 0x00),
+			result: append(bytes.Repeat([]byte{0x91}, MaxDepth), 0x00),
+		},
+		{spec: "nested too deeply", data: append(bytes.Repeat([]byte{0x81, 0x00}, MaxDepth+1), 0x00), error: ErrDepthExceeded},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			// ACT
+			result, err := SortMapKeys(tc.data)
+
+			// ASSERT
+			testError(t, tc.error, err)
+
+			wanted := tc.result
+			got := result
+			if !bytes.Equal(wanted, got) {
+				t.Errorf("\nwanted %x\ngot    %x", wanted, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+	"unicode/utf8"
+)
+
+// FormatSpec describes a format defined by the msgpack specification:
+// the range of leading bytes (First..Last, inclusive) identifying the
+// format.  For formats identified by a single byte First and Last are
+// the same.
+type FormatSpec struct {
+	Format Format
+	First  byte
+	Last   byte
+}
+
+// spec is the format table of the msgpack specification.  The leading
+// bytes are transcribed from the specification rather than derived from
+// the constants used by the Encoder, so that the two may be checked
+// against each other (see SelfCheck).
+var spec = [...]FormatSpec{
+	{FormatFixInt, 0x00, 0x7f},
+	{FormatFixMap, 0x80, 0x8f},
+	{FormatFixArray, 0x90, 0x9f},
+	{FormatFixStr, 0xa0, 0xbf},
+	{FormatNil, 0xc0, 0xc0},
+	{FormatBool, 0xc2, 0xc3},
+	{FormatBin8, 0xc4, 0xc4},
+	{FormatBin16, 0xc5, 0xc5},
+	{FormatBin32, 0xc6, 0xc6},
+	{FormatExt8, 0xc7, 0xc7},
+	{FormatExt16, 0xc8, 0xc8},
+	{FormatExt32, 0xc9, 0xc9},
+	{FormatFloat32, 0xca, 0xca},
+	{FormatFloat64, 0xcb, 0xcb},
+	{FormatUint8, 0xcc, 0xcc},
+	{FormatUint16, 0xcd, 0xcd},
+	{FormatUint32, 0xce, 0xce},
+	{FormatUint64, 0xcf, 0xcf},
+	{FormatInt8, 0xd0, 0xd0},
+	{FormatInt16, 0xd1, 0xd1},
+	{FormatInt32, 0xd2, 0xd2},
+	{FormatInt64, 0xd3, 0xd3},
+	{FormatFixExt1, 0xd4, 0xd4},
+	{FormatFixExt2, 0xd5, 0xd5},
+	{FormatFixExt4, 0xd6, 0xd6},
+	{FormatFixExt8, 0xd7, 0xd7},
+	{FormatFixExt16, 0xd8, 0xd8},
+	{FormatStr8, 0xd9, 0xd9},
+	{FormatStr16, 0xda, 0xda},
+	{FormatStr32, 0xdb, 0xdb},
+	{FormatArray16, 0xdc, 0xdc},
+	{FormatArray32, 0xdd, 0xdd},
+	{FormatMap16, 0xde, 0xde},
+	{FormatMap32, 0xdf, 0xdf},
+	{FormatNegFixInt, 0xe0, 0xff},
+}
+
+// Spec returns the format table of the msgpack specification, in order
+// of leading byte.  Every byte other than 0xc1 (never used) is the
+// leading byte of exactly one format.
+//
+// FormatTimestamp is not included since it is identified by the
+// extension type following the header of an extension format, not by
+// a leading byte.
+func Spec() []FormatSpec {
+	return append([]FormatSpec(nil), spec[:]...)
+}
+
+// specOf returns the specification of a format, and true, or false if
+// the format is not identified by a leading byte.
+func specOf(f Format) (FormatSpec, bool) {
+	for _, s := range spec {
+		if s.Format == f {
+			return s, true
+		}
+	}
+	return FormatSpec{}, false
+}
+
+// VerifyAgainstSpec verifies that data consists of a single value
+// conforming to the msgpack specification, for use in tests of code
+// producing msgpack data (including code using the Encoder at a low
+// level, e.g. writing headers and raw bytes).  nil is returned if the
+// data conforms.
+//
+// The leading byte of every value (including the elements and entries
+// of arrays and maps) must identify a format in the specification and
+// the data must hold exactly the bytes required by the value.  Strings
+// must be valid utf-8 and a timestamp (an extension with type -1) must
+// be encoded in one of the 32, 64 or 96 bit formats, with nanoseconds
+// less than one second.
+//
+// An error wrapping ErrInvalidFormat, ErrInvalidUTF8 or ErrTruncated is
+// returned identifying the offset of the first value that does not
+// conform.
+func VerifyAgainstSpec(data []byte) error {
+	n, err := verifyValue(data)
+	switch {
+	case err != nil:
+		return fmt.Errorf("VerifyAgainstSpec: %w", err)
+	case n != len(data):
+		return fmt.Errorf("VerifyAgainstSpec: %w: %d bytes following the value", ErrInvalidFormat, len(data)-n)
+	}
+	return nil
+}
+
+// verifyValue returns the number of bytes of the value at the start of
+// data, verifying each value against the specification.
+func verifyValue(data []byte) (int, error) {
+	pos := 0
+	for pending := int64(1); pending > 0; pending-- {
+		f, n, hl, err := ReadHeader(data[pos:])
+		if err != nil {
+			return 0, fmt.Errorf("offset %d: %w", pos, err)
+		}
+		start := pos
+		pos += hl
+
+		if s, ok := specOf(f); ok && (data[start] < s.First || data[start] > s.Last) {
+			return 0, fmt.Errorf("offset %d: %w: %#02x is not a leading byte of %s", start, ErrInvalidFormat, data[start], f)
+		}
+
+		switch {
+		case f >= FormatFixArray && f <= FormatArray32:
+			pending += n
+			continue
+		case f >= FormatFixMap && f <= FormatMap32:
+			pending += 2 * n
+			continue
+		case n > int64(len(data)-pos):
+			return 0, fmt.Errorf("offset %d: %w: %s requires %d bytes, got %d", start, ErrTruncated, f, int64(hl)+n, len(data)-start)
+		}
+
+		value := data[pos : pos+int(n)]
+		pos += int(n)
+
+		switch f {
+		case FormatFixStr, FormatStr8, FormatStr16, FormatStr32:
+			if !utf8.Valid(value) {
+				return 0, fmt.Errorf("offset %d: %w", start, ErrInvalidUTF8)
+			}
+		case FormatTimestamp:
+			if err := verifyTimestamp(value); err != nil {
+				return 0, fmt.Errorf("offset %d: %w", start, err)
+			}
+		case FormatFixExt1, FormatFixExt2, FormatFixExt16, FormatExt8, FormatExt16, FormatExt32:
+			if int8(data[start+hl-1]) == extTimestamp {
+				return 0, fmt.Errorf("offset %d: %w: timestamp encoded as %s with %d bytes", start, ErrInvalidFormat, f, n)
+			}
+		}
+	}
+	return pos, nil
+}
+
+// verifyTimestamp verifies the data of a timestamp extension, returning
+// an error if the nanoseconds are not less than one second.
+func verifyTimestamp(p []byte) error {
+	var ns uint32
+	switch len(p) {
+	case 8:
+		ns = uint32(binary.BigEndian.Uint64(p) >> 34)
+	case 12:
+		ns = binary.BigEndian.Uint32(p)
+	}
+	if ns >= 1e9 {
+		return fmt.Errorf("%w: timestamp nanoseconds out of range: %d", ErrInvalidFormat, ns)
+	}
+	return nil
+}
+
+// SelfCheck cross-validates the encoding of the package against the
+// format table of the msgpack specification (see Spec), returning an
+// error describing the first discrepancy found, or nil.
+//
+// Every leading byte is checked to be identified (by FormatOf) as the
+// format specified for it, and a value of each format is encoded to
+// check that the leading byte emitted by the Encoder is specified for
+// that format.  The encoded values are also verified by
+// VerifyAgainstSpec.
+//
+// This is intended for use in the test suites of applications (or
+// forks) needing assurance that the encoding conforms to the
+// specification, e.g. after upgrading the package.
+func SelfCheck() error {
+	for b := 0; b <= 0xff; b++ {
+		wanted := FormatInvalid
+		for _, s := range spec {
+			if byte(b) >= s.First && byte(b) <= s.Last {
+				wanted = s.Format
+				break
+			}
+		}
+		if got := FormatOf(byte(b)); got != wanted {
+			return fmt.Errorf("SelfCheck: %#02x: FormatOf returned %s, specified as %s", b, got, wanted)
+		}
+	}
+
+	for _, p := range selfCheckProbes {
+		buf := &bytes.Buffer{}
+		if err := p.fn(newEncoder(buf)); err != nil {
+			return fmt.Errorf("SelfCheck: %s: %w", p.Format, err)
+		}
+
+		data := buf.Bytes()
+		s, _ := specOf(p.Format)
+		if data[0] < s.First || data[0] > s.Last {
+			return fmt.Errorf("SelfCheck: %s: emitted leading byte %#02x, specified as %#02x..%#02x", p.Format, data[0], s.First, s.Last)
+		}
+		if err := VerifyAgainstSpec(data); err != nil {
+			return fmt.Errorf("SelfCheck: %s: %w", p.Format, err)
+		}
+	}
+	return nil
+}
+
+// selfCheckProbes encode a value of each format, used by SelfCheck.
+var selfCheckProbes = []struct {
+	Format
+	fn func(Encoder) error
+}{
+	{FormatNil, func(enc Encoder) error { return enc.EncodeNil() }},
+	{FormatBool, func(enc Encoder) error { return enc.EncodeBool(false) }},
+	{FormatBool, func(enc Encoder) error { return enc.EncodeBool(true) }},
+	{FormatFixInt, func(enc Encoder) error { return enc.EncodeInt(127) }},
+	{FormatNegFixInt, func(enc Encoder) error { return enc.EncodeInt(-32) }},
+	{FormatInt8, func(enc Encoder) error { return enc.EncodeInt(math.MinInt8) }},
+	{FormatInt16, func(enc Encoder) error { return enc.EncodeInt(math.MinInt16) }},
+	{FormatInt32, func(enc Encoder) error { return enc.EncodeInt(math.MinInt32) }},
+	{FormatInt64, func(enc Encoder) error { return enc.EncodeInt64(math.MinInt64) }},
+	{FormatUint8, func(enc Encoder) error { return enc.EncodeUint(math.MaxUint8) }},
+	{FormatUint16, func(enc Encoder) error { return enc.EncodeUint(math.MaxUint16) }},
+	{FormatUint32, func(enc Encoder) error { return enc.EncodeUint(math.MaxUint32) }},
+	{FormatUint64, func(enc Encoder) error { return enc.EncodeUint64(math.MaxUint64) }},
+	{FormatFloat32, func(enc Encoder) error { return enc.EncodeFloat32(1.5) }},
+	{FormatFloat64, func(enc Encoder) error { return enc.EncodeFloat64(1.5) }},
+	{FormatFixStr, func(enc Encoder) error { return enc.EncodeString("fixstr") }},
+	{FormatStr8, func(enc Encoder) error { return enc.EncodeString(string(make([]byte, math.MaxUint8))) }},
+	{FormatStr16, func(enc Encoder) error { return enc.EncodeString(string(make([]byte, math.MaxUint16))) }},
+	{FormatStr32, func(enc Encoder) error { return enc.EncodeString(string(make([]byte, math.MaxUint16+1))) }},
+	{FormatBin8, func(enc Encoder) error { return enc.EncodeBytes(make([]byte, math.MaxUint8)) }},
+	{FormatBin16, func(enc Encoder) error { return enc.EncodeBytes(make([]byte, math.MaxUint16)) }},
+	{FormatBin32, func(enc Encoder) error { return enc.EncodeBytes(make([]byte, math.MaxUint16+1)) }},
+	{FormatFixArray, func(enc Encoder) error { return EncodeArray(enc, make([]int, 15), nil) }},
+	{FormatArray16, func(enc Encoder) error { return EncodeArray(enc, make([]int, math.MaxUint16), nil) }},
+	{FormatArray32, func(enc Encoder) error { return EncodeArray(enc, make([]int, math.MaxUint16+1), nil) }},
+	{FormatFixMap, func(enc Encoder) error { return EncodeMap(enc, selfCheckMap(15), nil) }},
+	{FormatMap16, func(enc Encoder) error { return EncodeMap(enc, selfCheckMap(math.MaxUint16), nil) }},
+	{FormatMap32, func(enc Encoder) error { return EncodeMap(enc, selfCheckMap(math.MaxUint16+1), nil) }},
+	{FormatFixExt4, func(enc Encoder) error { return enc.EncodeTime(time.Unix(math.MaxUint32, 0)) }},
+	{FormatFixExt8, func(enc Encoder) error { return enc.EncodeTime(time.Unix(1<<34-1, 999999999)) }},
+	{FormatExt8, func(enc Encoder) error { return enc.EncodeTime(time.Unix(-1, 0)) }},
+}
+
+// selfCheckMap returns a map with n entries, used by SelfCheck.
+func selfCheckMap(n int) map[int]int {
+	m := make(map[int]int, n)
+	for i := 0; i < n; i++ {
+		m[i] = i
+	}
+	return m
+}
@@ -0,0 +1,76 @@
+package msgpack
+
+import (
+	"testing"
+)
+
+func TestSpec(t *testing.T) {
+	// ACT
+	got := Spec()
+
+	// ASSERT
+	covered := map[byte]int{}
+	for _, s := range got {
+		for b := int(s.First); b <= int(s.Last); b++ {
+			covered[byte(b)]++
+		}
+	}
+	for b := 0; b <= 0xff; b++ {
+		wanted := 1
+		if b == 0xc1 {
+			wanted = 0
+		}
+		if covered[byte(b)] != wanted {
+			t.Errorf("%#02x: specified for %d formats", b, covered[byte(b)])
+		}
+	}
+
+	t.Run("returns a copy", func(t *testing.T) {
+		got[0].Last = 0xff
+		if Spec()[0].Last != 0x7f {
+			t.Error("modifying the table returned modified the specification")
+		}
+	})
+}
+
+func TestSelfCheck(t *testing.T) {
+	// ACT
+	err := SelfCheck()
+
+	// ASSERT
+	testError(t, nil, err)
+}
+
+func TestVerifyAgainstSpec(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		spec string
+		data []byte
+		error
+	}{
+		{spec: "nil", data: []byte{0xc0}},
+		{spec: "fixstr", data: []byte{0xa2, 'o', 'k'}},
+		{spec: "array of map", data: []byte{0x91, 0x81, 0xa1, 'a', 0xcd, 0x01, 0x00}},
+		{spec: "timestamp 32", data: []byte{0xd6, 0xff, 0x00, 0x00, 0x00, 0x01}},
+		{spec: "timestamp 64", data: []byte{0xd7, 0xff, 0xee, 0x6b, 0x27, 0xfc, 0x00, 0x00, 0x00, 0x01}},
+		{spec: "ext with other type", data: []byte{0xd4, 0x01, 0x00}},
+		{spec: "no data", data: []byte{}, error: ErrTruncated},
+		{spec: "never used", data: []byte{0xc1}, error: ErrInvalidFormat},
+		{spec: "nested never used", data: []byte{0x92, 0xc0, 0xc1}, error: ErrInvalidFormat},
+		{spec: "truncated", data: []byte{0xa3, 'n', 'o'}, error: ErrTruncated},
+		{spec: "truncated array", data: []byte{0x92, 0xc0}, error: ErrTruncated},
+		{spec: "trailing bytes", data: []byte{0xc0, 0xc0}, error: ErrInvalidFormat},
+		{spec: "invalid utf-8", data: []byte{0xa2, 0xc3, 0x28}, error: ErrInvalidUTF8},
+		{spec: "timestamp nanoseconds", data: []byte{0xd7, 0xff, 0xee, 0x6b, 0x28, 0x00, 0x00, 0x00, 0x00, 0x01}, error: ErrInvalidFormat},
+		{spec: "timestamp length", data: []byte{0xd5, 0xff, 0x00, 0x01}, error: ErrInvalidFormat},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			// ACT
+			err := VerifyAgainstSpec(tc.data)
+
+			// ASSERT
+			testError(t, tc.error, err)
+		})
+	}
+}
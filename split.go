@@ -0,0 +1,70 @@
+package msgpack
+
+import "fmt"
+
+// SplitArray splits an encoded array into smaller encoded arrays, each of
+// which is no more than maxSize bytes (including the array header), so
+// that the elements of a large array may be sent as a number of valid,
+// standalone messages (e.g. to a broker with a maximum message size).
+// The elements are copied without being decoded and in their original
+// order; each array holds as many elements as its size allows.
+//
+// No arrays are returned for an empty array.  An error wrapping
+// ErrTooLarge is returned if any element cannot be accommodated in an
+// array of maxSize bytes, or ErrTypeMismatch if data is not an encoded
+// array.
+func SplitArray(data []byte, maxSize int) ([][]byte, error) {
+	f, n, hl, err := ReadHeader(data)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("SplitArray: %w", err)
+	case f < FormatFixArray || f > FormatArray32:
+		return nil, fmt.Errorf("SplitArray: %w: expected array, got %s", ErrTypeMismatch, f)
+	}
+
+	var (
+		result [][]byte
+		start  = hl // offset of the first element of the current array
+		size   = 0  // size of the elements of the current array
+		count  = 0  // number of elements in the current array
+	)
+	emit := func() {
+		chunk := appendArrayHeader(make([]byte, 0, arrayHeaderLen(count)+size), int64(count))
+		result = append(result, append(chunk, data[start:start+size]...))
+		start, size, count = start+size, 0, 0
+	}
+
+	pos := hl
+	for i := int64(0); i < n; i++ {
+		l, err := valueLen(data[pos:])
+		if err != nil {
+			return nil, fmt.Errorf("SplitArray: [%d]: offset %d: %w", i, pos, err)
+		}
+		if arrayHeaderLen(1)+l > maxSize {
+			return nil, fmt.Errorf("SplitArray: [%d]: %w: element of %d bytes exceeds max size (%d bytes)", i, ErrTooLarge, l, maxSize)
+		}
+		if arrayHeaderLen(count+1)+size+l > maxSize {
+			emit()
+		}
+		size += l
+		count++
+		pos += l
+	}
+	if count > 0 {
+		emit()
+	}
+	return result, nil
+}
+
+// arrayHeaderLen returns the number of bytes of the header of an array
+// of n elements.
+func arrayHeaderLen(n int) int {
+	switch {
+	case n < 16:
+		return 1
+	case n < 65536:
+		return 3
+	default:
+		return 5
+	}
+}
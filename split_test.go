@@ -0,0 +1,48 @@
+package msgpack
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArray(t *testing.T) {
+	// ARRANGE
+	testcases := []struct {
+		spec    string
+		data    []byte
+		maxSize int
+		result  [][]byte
+		error
+	}{
+		{spec: "empty", data: []byte{0x90}, maxSize: 1},
+		{spec: "fits", data: []byte{0x92, 0x01, 0x02}, maxSize: 3, result: [][]byte{{0x92, 0x01, 0x02}}},
+		{spec: "split",
+			data:    []byte{0x94, 0x01, 0xa2, 'a', 'b', 0x02, 0x03},
+			maxSize: 4,
+			result:  [][]byte{{0x91, 0x01}, {0x91, 0xa2, 'a', 'b'}, {0x92, 0x02, 0x03}},
+		},
+		{spec: "one per array",
+			data:    []byte{0x93, 0x01, 0x02, 0x03},
+			maxSize: 2,
+			result:  [][]byte{{0x91, 0x01}, {0x91, 0x02}, {0x91, 0x03}},
+		},
+		{spec: "element too large", data: []byte{0x91, 0xa2, 'a', 'b'}, maxSize: 3, error: ErrTooLarge},
+		{spec: "not an array", data: []byte{0x80}, maxSize: 10, error: ErrTypeMismatch},
+		{spec: "truncated", data: []byte{0x92, 0x01}, maxSize: 10, error: ErrTruncated},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			// ACT
+			result, err := SplitArray(tc.data, tc.maxSize)
+
+			// ASSERT
+			testError(t, tc.error, err)
+
+			wanted := tc.result
+			got := result
+			if !reflect.DeepEqual(wanted, got) {
+				t.Errorf("\nwanted %x\ngot    %x", wanted, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package msgpack
+
+import "sync/atomic"
+
+// EncoderStats is a snapshot of the statistics collected by an Encoder
+// created with the CollectStats option.
+//
+// Values are counted by format family; the timestamp extension is
+// counted as an Ext value.
+type EncoderStats struct {
+	Nil    int64 // nil values encoded
+	Bool   int64 // bool values encoded
+	Int    int64 // int and uint values encoded (of any size, including fixints)
+	Float  int64 // float32 and float64 values encoded
+	String int64 // strings encoded
+	Binary int64 // binary (bin) values encoded
+	Array  int64 // array headers written
+	Map    int64 // map headers written
+	Ext    int64 // extension values encoded
+	Bytes  int64 // bytes written
+	Errors int64 // errors captured by the Encoder
+}
+
+// Values returns the total number of values encoded (including array
+// and map headers).
+func (s EncoderStats) Values() int64 {
+	return s.Nil + s.Bool + s.Int + s.Float + s.String + s.Binary + s.Array + s.Map + s.Ext
+}
+
+// FormatStats records the number of values encoded in a Format by an
+// Encoder created with the CollectStats option, and the number of bytes
+// written for those values.
+//
+// The bytes written for an array or map are those of the header only;
+// the elements or entries are counted in their own formats.
+type FormatStats struct {
+	Format Format
+	Values int64
+	Bytes  int64
+}
+
+// stats holds the statistics collected by an Encoder.  Counters are
+// updated atomically so that a snapshot may be taken (e.g. by a
+// metrics exporter) while the Encoder is in use.
+//
+// The int64 counters must be the first fields of the struct, so that
+// they are 64-bit aligned (as required by sync/atomic) on 32-bit
+// platforms.
+type stats struct {
+	values  [FormatTimestamp + 1]int64 // values encoded, indexed by Format
+	bytes   [FormatTimestamp + 1]int64 // bytes written, indexed by Format
+	errors  int64
+	current Format // format of the value currently being encoded
+}
+
+// value records a value encoded with the specified leading byte.
+func (s *stats) value(b byte) {
+	s.current = FormatOf(b)
+	atomic.AddInt64(&s.values[s.current], 1)
+}
+
+// wrote records bytes written for the current value and any error.
+func (s *stats) wrote(n int, err error) {
+	atomic.AddInt64(&s.bytes[s.current], int64(n))
+	if err != nil {
+		atomic.AddInt64(&s.errors, 1)
+	}
+}
+
+// add adds the values encoded and bytes written recorded by o.
+func (s *stats) add(o *stats) {
+	for f := range o.values {
+		atomic.AddInt64(&s.values[f], atomic.LoadInt64(&o.values[f]))
+		atomic.AddInt64(&s.bytes[f], atomic.LoadInt64(&o.bytes[f]))
+	}
+	atomic.AddInt64(&s.errors, atomic.LoadInt64(&o.errors))
+}
+
+// count returns the total number of values encoded in formats in the
+// range first..last (inclusive).
+func (s *stats) count(first, last Format) int64 {
+	n := int64(0)
+	for f := first; f <= last; f++ {
+		n += atomic.LoadInt64(&s.values[f])
+	}
+	return n
+}
+
+// written returns the total number of bytes written.
+func (s *stats) written() int64 {
+	n := int64(0)
+	for f := range s.bytes {
+		n += atomic.LoadInt64(&s.bytes[f])
+	}
+	return n
+}
+
+// Stats returns a snapshot of the statistics collected by the Encoder.
+// If the Encoder was not created with the CollectStats option, the
+// statistics are all zero.
+func (enc Encoder) Stats() EncoderStats {
+	s := enc.stats
+	if s == nil {
+		return EncoderStats{}
+	}
+	return EncoderStats{
+		Nil:    s.count(FormatNil, FormatNil),
+		Bool:   s.count(FormatBool, FormatBool),
+		Int:    s.count(FormatFixInt, FormatUint64),
+		Float:  s.count(FormatFloat32, FormatFloat64),
+		String: s.count(FormatFixStr, FormatStr32),
+		Binary: s.count(FormatBin8, FormatBin32),
+		Array:  s.count(FormatFixArray, FormatArray32),
+		Map:    s.count(FormatFixMap, FormatMap32),
+		Ext:    s.count(FormatFixExt1, FormatTimestamp),
+		Bytes:  s.written(),
+		Errors: atomic.LoadInt64(&s.errors),
+	}
+}
+
+// FormatStats returns a snapshot of the number of values encoded and
+// bytes written in each Format, in Format order, omitting any format
+// for which no values have been encoded.  This may be used to tune a
+// schema toward more compact representations.
+//
+// Extension values (including timestamps) are recorded in the FixExt or
+// Ext format used to encode them.  If the Encoder was not created with
+// the CollectStats option the result is nil.
+func (enc Encoder) FormatStats() []FormatStats {
+	s := enc.stats
+	if s == nil {
+		return nil
+	}
+	var result []FormatStats
+	for f := range s.values {
+		if n := atomic.LoadInt64(&s.values[f]); n > 0 {
+			result = append(result, FormatStats{
+				Format: Format(f),
+				Values: n,
+				Bytes:  atomic.LoadInt64(&s.bytes[f]),
+			})
+		}
+	}
+	return result
+}
@@ -0,0 +1,134 @@
+package msgpack
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncoderStats(t *testing.T) {
+	t.Run("when not enabled", func(t *testing.T) {
+		// ARRANGE
+		enc, _ := NewTestEncoder()
+		_ = enc.EncodeString("abc")
+
+		// ACT
+		got := enc.Stats()
+
+		// ASSERT
+		wanted := EncoderStats{}
+		if wanted != got {
+			t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+		}
+	})
+
+	t.Run("when enabled", func(t *testing.T) {
+		// ARRANGE
+		buf := &bytes.Buffer{}
+		enc := NewEncoder(buf, CollectStats())
+
+		_ = enc.WriteMapHeader(8)
+		_ = enc.EncodeStringField("nil", "")
+		_ = enc.EncodeNil()
+		_ = enc.EncodeBoolField("bool", true)
+		_ = enc.EncodeIntField("fixint", 1)
+		_ = enc.EncodeInt64Field("int64", 1<<40)
+		_ = enc.EncodeFloat64Field("float", 1.5)
+		_ = enc.EncodeBytesField("bin", []byte{1, 2, 3})
+		_ = enc.EncodeString("array")
+		_ = EncodeArray(enc, []int{1, 2}, nil)
+		_ = enc.EncodeString("time")
+		_ = enc.EncodeTime(time.Unix(1, 0))
+
+		// ACT
+		got := enc.Stats()
+
+		// ASSERT
+		wanted := EncoderStats{
+			Nil:    1,
+			Bool:   1,
+			Int:    4,
+			Float:  1,
+			String: 9,
+			Binary: 1,
+			Array:  1,
+			Map:    1,
+			Ext:    1,
+			Bytes:  int64(buf.Len()),
+		}
+		if wanted != got {
+			t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+		}
+
+		t.Run("values", func(t *testing.T) {
+			wanted := int64(20)
+			got := got.Values()
+			if wanted != got {
+				t.Errorf("\nwanted %d\ngot    %d", wanted, got)
+			}
+		})
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		// ARRANGE
+		werr := errors.New("write error")
+		enc := NewEncoder(&limitWriter{limit: 2, err: werr}, CollectStats())
+		defer func() { _ = enc.ResetError() }()
+
+		// ACT
+		_ = enc.EncodeString("abc")
+
+		// ASSERT
+		got := enc.Stats()
+		wanted := EncoderStats{String: 1, Bytes: 2, Errors: 1}
+		if wanted != got {
+			t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+		}
+	})
+}
+
+func TestEncoderFormatStats(t *testing.T) {
+	t.Run("when not enabled", func(t *testing.T) {
+		// ARRANGE
+		enc, _ := NewTestEncoder()
+		_ = enc.EncodeString("abc")
+
+		// ACT
+		got := enc.FormatStats()
+
+		// ASSERT
+		if got != nil {
+			t.Errorf("\nwanted nil\ngot    %#v", got)
+		}
+	})
+
+	t.Run("when enabled", func(t *testing.T) {
+		// ARRANGE
+		enc := NewEncoder(&bytes.Buffer{}, CollectStats())
+
+		_ = enc.WriteArrayHeader(5)
+		_ = enc.EncodeInt(1)
+		_ = enc.EncodeInt(2)
+		_ = enc.EncodeInt(1024)
+		_ = enc.EncodeString("abc")
+		_ = enc.EncodeString(strings.Repeat("x", 32))
+
+		// ACT
+		got := enc.FormatStats()
+
+		// ASSERT
+		wanted := []FormatStats{
+			{Format: FormatFixInt, Values: 2, Bytes: 2},
+			{Format: FormatUint16, Values: 1, Bytes: 3},
+			{Format: FormatFixStr, Values: 1, Bytes: 4},
+			{Format: FormatStr8, Values: 1, Bytes: 34},
+			{Format: FormatFixArray, Values: 1, Bytes: 1},
+		}
+		if !reflect.DeepEqual(wanted, got) {
+			t.Errorf("\nwanted %#v\ngot    %#v", wanted, got)
+		}
+	})
+}
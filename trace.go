@@ -0,0 +1,183 @@
+package msgpack
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// tracePreviewLen is the maximum number of bytes of a string, binary or
+// extension value included in a trace.
+const tracePreviewLen = 32
+
+// tracer parses the bytes written by an Encoder, describing each token
+// (a header and any data that follows it) to a writer.
+//
+// Tokens are described with their byte offset, format, length and a
+// preview of the value, indented according to the nesting of arrays and
+// maps in the output:
+//
+//	0: fixmap(len=1)
+//	1:   fixstr(len=4) "tags"
+//	6:   fixarray(len=2)
+//	7:     fixint 1
+//	8:     fixint 2
+type tracer struct {
+	w      io.Writer
+	offset int64   // offset of the next byte to be written
+	start  int64   // offset of the current token
+	hdr    []byte  // header of the current token (whilst incomplete)
+	format Format  // format of the current token
+	length int64   // length of the current token (as returned by ReadHeader)
+	data   []byte  // data (or a preview of the data) of the current token
+	remain int64   // data bytes of the current token not yet written
+	stack  []int64 // number of values remaining in each open container
+}
+
+// write parses bytes written by the Encoder.
+func (t *tracer) write(p []byte) {
+	for len(p) > 0 {
+		if t.remain > 0 {
+			n := int64(len(p))
+			if n > t.remain {
+				n = t.remain
+			}
+			if space := tracePreviewLen - len(t.data); space > 0 {
+				if int64(space) > n {
+					space = int(n)
+				}
+				t.data = append(t.data, p[:space]...)
+			}
+			p = p[n:]
+			t.offset += n
+			t.remain -= n
+			if t.remain == 0 {
+				t.emit()
+			}
+			continue
+		}
+
+		if len(t.hdr) == 0 {
+			t.start = t.offset
+		}
+		t.hdr = append(t.hdr, p[0])
+		p = p[1:]
+		t.offset++
+
+		f, n, _, err := ReadHeader(t.hdr)
+		if errors.Is(err, ErrTruncated) {
+			continue
+		}
+		t.format = f
+		t.length = n
+		t.data = t.data[:0]
+
+		switch {
+		case f >= FormatFixArray && f <= FormatMap32:
+			t.remain = 0
+		default:
+			t.remain = n
+		}
+		if t.remain == 0 {
+			t.emit()
+		}
+	}
+}
+
+// emit describes the current token and updates the stack of open
+// containers.
+func (t *tracer) emit() {
+	desc := t.format.String()
+	switch {
+	case t.format == FormatInvalid:
+		desc = Describe(t.hdr[0])
+	case t.format >= FormatFixStr && t.format <= FormatExt32:
+		desc = fmt.Sprintf("%s(len=%d)", t.format, t.length)
+	}
+	if preview := t.preview(); preview != "" {
+		desc += " " + preview
+	}
+	fmt.Fprintf(t.w, "%6d: %s%s\n", t.start, strings.Repeat("  ", len(t.stack)), desc)
+
+	if n := len(t.stack); n > 0 {
+		t.stack[n-1]--
+	}
+	switch {
+	case t.length == 0:
+	case t.format >= FormatFixArray && t.format <= FormatArray32:
+		t.stack = append(t.stack, t.length)
+	case t.format >= FormatFixMap && t.format <= FormatMap32:
+		t.stack = append(t.stack, t.length*2)
+	}
+	for n := len(t.stack); n > 0 && t.stack[n-1] == 0; n-- {
+		t.stack = t.stack[:n-1]
+	}
+
+	t.hdr = t.hdr[:0]
+}
+
+// preview returns a description of the value of the current token.
+func (t *tracer) preview() string {
+	h, d := t.hdr, t.data
+
+	more := ""
+	if t.length > int64(len(d)) {
+		more = "..."
+	}
+
+	switch t.format {
+	case FormatBool:
+		return fmt.Sprintf("%v", h[0] == atomTrue)
+	case FormatFixInt, FormatNegFixInt:
+		return fmt.Sprintf("%d", int8(h[0]))
+	case FormatInt8:
+		return fmt.Sprintf("%d", int8(d[0]))
+	case FormatInt16:
+		return fmt.Sprintf("%d", int16(binary.BigEndian.Uint16(d)))
+	case FormatInt32:
+		return fmt.Sprintf("%d", int32(binary.BigEndian.Uint32(d)))
+	case FormatInt64:
+		return fmt.Sprintf("%d", int64(binary.BigEndian.Uint64(d)))
+	case FormatUint8:
+		return fmt.Sprintf("%d", d[0])
+	case FormatUint16:
+		return fmt.Sprintf("%d", binary.BigEndian.Uint16(d))
+	case FormatUint32:
+		return fmt.Sprintf("%d", binary.BigEndian.Uint32(d))
+	case FormatUint64:
+		return fmt.Sprintf("%d", binary.BigEndian.Uint64(d))
+	case FormatFloat32:
+		return fmt.Sprintf("%v", math.Float32frombits(binary.BigEndian.Uint32(d)))
+	case FormatFloat64:
+		return fmt.Sprintf("%v", math.Float64frombits(binary.BigEndian.Uint64(d)))
+	case FormatFixStr, FormatStr8, FormatStr16, FormatStr32:
+		return fmt.Sprintf("%q%s", d, more)
+	case FormatBin8, FormatBin16, FormatBin32:
+		return fmt.Sprintf("% x%s", d, more)
+	case FormatFixExt1, FormatFixExt2, FormatFixExt4, FormatFixExt8, FormatFixExt16,
+		FormatExt8, FormatExt16, FormatExt32:
+		return fmt.Sprintf("type=%d % x%s", int8(h[len(h)-1]), d, more)
+	case FormatTimestamp:
+		return traceTimestamp(d).UTC().Format(time.RFC3339Nano)
+	default:
+		return ""
+	}
+}
+
+// traceTimestamp returns the time encoded in the data of a timestamp
+// extension value (4, 8 or 12 bytes).
+func traceTimestamp(d []byte) time.Time {
+	switch len(d) {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(d)), 0)
+	case 8:
+		v := binary.BigEndian.Uint64(d)
+		return time.Unix(int64(v&(1<<34-1)), int64(v>>34))
+	default:
+		return time.Unix(int64(binary.BigEndian.Uint64(d[4:])), int64(binary.BigEndian.Uint32(d)))
+	}
+}
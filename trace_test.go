@@ -0,0 +1,74 @@
+package msgpack
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrace(t *testing.T) {
+	t.Run("describes each token", func(t *testing.T) {
+		// ARRANGE
+		trace := &bytes.Buffer{}
+		enc := NewEncoder(&bytes.Buffer{}, Trace(trace))
+
+		// ACT
+		_ = enc.WriteMapHeader(4)
+		_ = enc.EncodeStringField("id", "abc")
+		_ = enc.EncodeString("tags")
+		_ = EncodeArray(enc, []any{1, -1, 1024, 1.5, nil, true}, nil)
+		_ = enc.EncodeBytesField("data", []byte{1, 2, 3})
+		_ = enc.EncodeString("time")
+		_ = enc.EncodeTime(time.Unix(1, 0))
+		_ = enc.EncodeString(strings.Repeat("x", 40))
+
+		// ASSERT
+		wanted := `     0: fixmap(len=4)
+     1:   fixstr(len=2) "id"
+     4:   fixstr(len=3) "abc"
+     8:   fixstr(len=4) "tags"
+    13:   fixarray(len=6)
+    14:     fixint 1
+    15:     negfixint -1
+    16:     uint16 1024
+    19:     float64 1.5
+    28:     nil
+    29:     bool true
+    30:   fixstr(len=4) "data"
+    35:   bin8(len=3) 01 02 03
+    40:   fixstr(len=4) "time"
+    45:   timestamp 1970-01-01T00:00:01Z
+    51: str8(len=40) "xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"...
+`
+		got := trace.String()
+		if wanted != got {
+			t.Errorf("\nwanted\n%s\ngot\n%s", wanted, got)
+		}
+	})
+
+	t.Run("values written in parts", func(t *testing.T) {
+		// ARRANGE
+		trace := &bytes.Buffer{}
+		enc := NewEncoder(&bytes.Buffer{}, Trace(trace))
+
+		// ACT
+		_ = enc.Write(typeArray16)
+		_ = enc.Write(byte(0))
+		_ = enc.Write(byte(1))
+		_ = enc.Write(typeInt32)
+		_ = enc.Write(int16(-1))
+		_ = enc.Write(int16(-2))
+		_ = enc.Write(byte(0xc1))
+
+		// ASSERT
+		wanted := `     0: array16(len=1)
+     3:   int32 -2
+     8: invalid(0xc1)
+`
+		got := trace.String()
+		if wanted != got {
+			t.Errorf("\nwanted\n%s\ngot\n%s", wanted, got)
+		}
+	})
+}
@@ -2,12 +2,52 @@ package msgpack
 
 type MapEncoder[K comparable, V any] func(Encoder, K, V) error
 
+// Ordered is a constraint permitting any type that supports the ordering
+// operators (<, <= etc), used by EncodeMapSorted.  This is equivalent to
+// cmp.Ordered, which is not available in the version of Go supported
+// by this module.
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 |
+		~string
+}
+
+// ctxCheckInterval is the number of elements (or entries) encoded
+// between checks of the context by EncodeArrayCtx and EncodeMapCtx.
+const ctxCheckInterval = 1024
+
+// MaxDepth is the maximum depth of nested arrays and maps in encoded data
+// processed by functions such as SortMapKeys and CopyFiltered (and by the
+// cbor and schema packages).  Data nested more deeply is rejected with an
+// error wrapping ErrDepthExceeded, rather than exhausting the stack.
+const MaxDepth = 1000
+
+// Atoms are single-byte msgpack encodings of a value.  These are
+// provided for use with the raw Encoder.Write method or when appending
+// msgpack data to a []byte, e.g:
+//
+//	b = append(b, msgpack.AtomNil)
+const (
+	AtomNil         byte = atomNil
+	AtomFalse       byte = atomFalse
+	AtomTrue        byte = atomTrue
+	AtomEmptyArray  byte = atomEmptyArray
+	AtomEmptyMap    byte = atomEmptyMap
+	AtomEmptyString byte = atomEmptyString
+	AtomZero        byte = atomZero
+)
+
 const (
 	minFixedInt  int8  = -32
 	maxFixedInt  int8  = 127
 	minFixedUint uint8 = 0
 	maxFixedUint uint8 = 127
 
+	// maxLength is the maximum length of a string, binary data, array
+	// or map (the largest length that can be encoded in 32 bits)
+	maxLength int64 = 1<<32 - 1
+
 	// atoms are single-byte values that encode both type and value in a single
 	// byte, with no following data bytes
 	atomNil         byte = atomNull
@@ -39,6 +79,16 @@ const (
 	typeBin16 byte = 0xc5
 	typeBin32 byte = 0xc6
 
+	// extensions
+	typeFixExt1  byte = 0xd4
+	typeFixExt2  byte = 0xd5
+	typeFixExt4  byte = 0xd6
+	typeFixExt8  byte = 0xd7
+	typeFixExt16 byte = 0xd8
+	typeExt8     byte = 0xc7
+	typeExt16    byte = 0xc8
+	typeExt32    byte = 0xc9
+
 	// floats
 	typeFloat32 byte = 0xca
 	typeFloat64 byte = 0xcb
@@ -55,12 +105,16 @@ const (
 
 	// unsigned ints
 	typeUint8  byte = 0xcc
-	typeUint16 byte = 0xcc
-	typeUint32 byte = 0xcd
-	typeUint64 byte = 0xce
+	typeUint16 byte = 0xcd
+	typeUint32 byte = 0xce
+	typeUint64 byte = 0xcf
 
 	// strings
 	typeString8  byte = 0xd9
 	typeString16 byte = 0xda
 	typeString32 byte = 0xdb
+
+	// extension types predefined by the msgpack spec
+	extTimestamp     int8 = -1
+	extTimestampByte byte = 0xff // extTimestamp, as written following the header of an ext format
 )
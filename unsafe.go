@@ -0,0 +1,31 @@
+package msgpack
+
+import "unsafe"
+
+// unsafeBytes returns the bytes of a string without copying them.  The
+// bytes must not be modified.
+func unsafeBytes(s string) []byte {
+	if s == "" {
+		return nil
+	}
+	// the first word of a string header is a pointer to its bytes
+	return unsafe.Slice(*(**byte)(unsafe.Pointer(&s)), len(s))
+}
+
+// dataWord returns the data word of an interface value.  For a value of
+// a pointer, map, channel or function type (or a struct or array
+// consisting only of one such value) this is the value itself, so may be
+// nil; for a value of any other type it is the (non-nil) address of the
+// value.  A nil data word does not therefore identify the type of the
+// value (see isNil).
+func dataWord(v any) unsafe.Pointer {
+	// an interface is a pair of words: its type and its data
+	return (*[2]unsafe.Pointer)(unsafe.Pointer(&v))[1]
+}
+
+// typeWord returns the type word of an interface value: the address of
+// the runtime type of its dynamic type, which is the same for all values
+// of a type.
+func typeWord(v any) unsafe.Pointer {
+	return (*[2]unsafe.Pointer)(unsafe.Pointer(&v))[0]
+}